@@ -4,6 +4,7 @@ import (
 	"log"
 	"log/slog"
 	"net/http"
+	_ "time/tzdata" // time zones of scheduled notifications, also on hosts without zoneinfo
 
 	"go-backend/internal/config"
 	"go-backend/internal/database"
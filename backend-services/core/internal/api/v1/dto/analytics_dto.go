@@ -0,0 +1,47 @@
+package dto
+
+import "time"
+
+// AnalyticsEventRequest is a single usage event reported by the host app
+type AnalyticsEventRequest struct {
+	AppID        string    `json:"appId" validate:"required,max=255"`
+	Type         string    `json:"type" validate:"required,oneof=launch session error"`
+	Version      string    `json:"version,omitempty" validate:"max=32"`
+	DurationMs   *int64    `json:"durationMs,omitempty" validate:"required_if=Type session,omitempty,min=0"`
+	ErrorMessage *string   `json:"errorMessage,omitempty" validate:"omitempty,max=1024"`
+	OccurredAt   time.Time `json:"occurredAt" validate:"required"`
+}
+
+type IngestAnalyticsEventsRequest struct {
+	Events []AnalyticsEventRequest `json:"events" validate:"required,min=1,max=500,dive"`
+}
+
+type IngestAnalyticsEventsResponse struct {
+	Accepted int `json:"accepted"`
+	// Events for unknown micro apps or outside the accepted time window
+	Dropped int `json:"dropped"`
+}
+
+// DailyUsageResponse is one row of the daily usage report. Group or Version is set
+// when the report is broken down by that dimension.
+type DailyUsageResponse struct {
+	Day          string `json:"day"`
+	AppID        string `json:"appId"`
+	Group        string `json:"group,omitempty"`
+	Version      string `json:"version,omitempty"`
+	ActiveUsers  int64  `json:"activeUsers"`
+	Launches     *int64 `json:"launches,omitempty"`
+	Sessions     *int64 `json:"sessions,omitempty"`
+	AvgSessionMs *int64 `json:"avgSessionMs,omitempty"`
+	Errors       *int64 `json:"errors,omitempty"`
+}
+
+// ActiveUsersResponse reports DAU on a day and MAU over the 30 days ending that day
+type ActiveUsersResponse struct {
+	AppID         string  `json:"appId"`
+	Group         string  `json:"group,omitempty"`
+	Version       string  `json:"version,omitempty"`
+	DAU           int64   `json:"dau"`
+	MAU           int64   `json:"mau"`
+	LastActiveDay *string `json:"lastActiveDay,omitempty"`
+}
@@ -0,0 +1,42 @@
+package dto
+
+import "time"
+
+type CatalogChangeResponse struct {
+	ID               int64                        `json:"id"`
+	AppID            string                       `json:"appId"`
+	Status           string                       `json:"status"`
+	Change           CreateMicroAppRequest        `json:"change"`
+	Replace          bool                         `json:"replace"`
+	SourceSnapshotID *int64                       `json:"sourceSnapshotId,omitempty"`
+	BaseVersion      *int64                       `json:"baseVersion,omitempty"`
+	CreatedBy        string                       `json:"createdBy"`
+	UpdatedBy        *string                      `json:"updatedBy,omitempty"`
+	ReviewedBy       *string                      `json:"reviewedBy,omitempty"`
+	ReviewedAt       *time.Time                   `json:"reviewedAt,omitempty"`
+	PublishedBy      *string                      `json:"publishedBy,omitempty"`
+	PublishedAt      *time.Time                   `json:"publishedAt,omitempty"`
+	CreatedAt        time.Time                    `json:"createdAt"`
+	Events           []CatalogChangeEventResponse `json:"events,omitempty"`
+}
+
+type CatalogChangeEventResponse struct {
+	Action    string    `json:"action"`
+	Actor     string    `json:"actor"`
+	Comment   *string   `json:"comment,omitempty"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// CatalogChangeActionRequest carries an optional comment for submit, approve, reject and publish
+type CatalogChangeActionRequest struct {
+	Comment *string `json:"comment,omitempty" validate:"omitempty,max=1024"`
+}
+
+type CatalogSnapshotResponse struct {
+	ID        int64                 `json:"id"`
+	AppID     string                `json:"appId"`
+	ChangeID  int64                 `json:"changeId"`
+	Snapshot  CreateMicroAppRequest `json:"snapshot"`
+	CreatedBy string                `json:"createdBy"`
+	CreatedAt time.Time             `json:"createdAt"`
+}
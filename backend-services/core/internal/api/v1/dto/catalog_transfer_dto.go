@@ -0,0 +1,24 @@
+package dto
+
+import "time"
+
+// CatalogDocument is the declarative form of the catalog used to promote micro apps between
+// environments. Each app is described exactly like a POST /micro-apps request.
+type CatalogDocument struct {
+	ExportedAt *time.Time              `json:"exportedAt,omitempty"`
+	Apps       []CreateMicroAppRequest `json:"apps" validate:"required,min=1,dive"`
+}
+
+// CatalogImportResponse lists what an import changed, or would change on a dry run
+type CatalogImportResponse struct {
+	DryRun bool                     `json:"dryRun"`
+	Apps   []CatalogImportAppResult `json:"apps"`
+}
+
+// CatalogImportAppResult is the outcome for one micro app: create, update or unchanged, with a
+// readable list of the differences to the target environment
+type CatalogImportAppResult struct {
+	AppID   string   `json:"appId"`
+	Action  string   `json:"action"`
+	Changes []string `json:"changes,omitempty"`
+}
@@ -0,0 +1,26 @@
+package dto
+
+type MicroAppDeepLinkResponse struct {
+	ID         int     `json:"id"`
+	AppID      string  `json:"appId"`
+	Pattern    string  `json:"pattern"`
+	TargetPath *string `json:"targetPath,omitempty"`
+}
+
+// UpsertMicroAppDeepLinkRequest registers a URL pattern such as /leave/requests/{id}. TargetPath
+// is the path opened inside the micro app and may use the pattern's placeholders; it defaults
+// to the link path.
+type UpsertMicroAppDeepLinkRequest struct {
+	Pattern    string  `json:"pattern" validate:"required,max=512"`
+	TargetPath *string `json:"targetPath,omitempty" validate:"omitempty,max=1024"`
+}
+
+// DeepLinkResolutionResponse tells the host app which micro app and in-app path a link opens.
+// Version and Build are those of the latest version available to the user, if any.
+type DeepLinkResolutionResponse struct {
+	AppID   string            `json:"appId"`
+	Version *string           `json:"version,omitempty"`
+	Build   *int              `json:"build,omitempty"`
+	Path    string            `json:"path"`
+	Params  map[string]string `json:"params"`
+}
@@ -0,0 +1,16 @@
+package dto
+
+type MicroAppCategoryResponse struct {
+	CategoryID   string  `json:"categoryId"`
+	Name         string  `json:"name"`
+	Description  *string `json:"description,omitempty"`
+	DisplayOrder int     `json:"displayOrder"`
+	Active       int     `json:"active"`
+}
+
+type UpsertMicroAppCategoryRequest struct {
+	CategoryID   string  `json:"categoryId" validate:"required,max=255"`
+	Name         string  `json:"name" validate:"required,max=255"`
+	Description  *string `json:"description,omitempty"`
+	DisplayOrder int     `json:"displayOrder"`
+}
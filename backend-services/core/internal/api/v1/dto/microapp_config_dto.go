@@ -5,9 +5,51 @@ import "encoding/json"
 type MicroAppConfigResponse struct {
 	ConfigKey   string          `json:"configKey"`
 	ConfigValue json.RawMessage `json:"configValue"`
+	// Scope reports which value won for the caller, e.g. "default" or "group:finance"
+	Scope string `json:"scope,omitempty"`
 }
 
 type CreateMicroAppConfigRequest struct {
-	ConfigKey   string          `json:"configKey" validate:"required"`
+	ConfigKey   string                                `json:"configKey" validate:"required"`
+	ConfigValue json.RawMessage                       `json:"configValue" validate:"required"`
+	Overrides   []CreateMicroAppConfigOverrideRequest `json:"overrides,omitempty" validate:"omitempty,dive"`
+}
+
+type MicroAppConfigOverrideResponse struct {
+	ConfigKey   string          `json:"configKey"`
+	ScopeType   string          `json:"scopeType"`
+	ScopeValue  string          `json:"scopeValue"`
+	ConfigValue json.RawMessage `json:"configValue"`
+	Priority    int             `json:"priority"`
+	Active      int             `json:"active"`
+}
+
+type CreateMicroAppConfigOverrideRequest struct {
+	ScopeType   string          `json:"scopeType" validate:"required,oneof=environment channel group"`
+	ScopeValue  string          `json:"scopeValue" validate:"required,max=255"`
 	ConfigValue json.RawMessage `json:"configValue" validate:"required"`
+	Priority    int             `json:"priority"`
+}
+
+type MicroAppConfigSchemaResponse struct {
+	ConfigKey string          `json:"configKey"`
+	Schema    json.RawMessage `json:"schema"`
+}
+
+type UpsertMicroAppConfigSchemaRequest struct {
+	Schema json.RawMessage `json:"schema" validate:"required"`
+}
+
+// ConfigValidationErrorResponse lists the config values that do not satisfy their schema
+type ConfigValidationErrorResponse struct {
+	Message string                     `json:"message"`
+	Errors  []ConfigFieldErrorResponse `json:"errors"`
+}
+
+// ConfigFieldErrorResponse points at one invalid value: the config key, the override scope
+// if any, then the JSON Pointer inside the value, e.g. "theme/colors/primary" or
+// "theme[group:finance]/colors/primary"
+type ConfigFieldErrorResponse struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
 }
@@ -1,24 +1,66 @@
 package dto
 
+import "time"
+
 type MicroAppResponse struct {
-	AppID       string                    `json:"appId"`
-	Name        string                    `json:"name"`
-	Description *string                   `json:"description,omitempty"`
-	IconURL     *string                   `json:"iconUrl,omitempty"`
-	Active      int                       `json:"active"`
-	Mandatory   int                       `json:"mandatory"`
-	Versions    []MicroAppVersionResponse `json:"versions,omitempty"`
-	Roles       []MicroAppRoleResponse    `json:"roles,omitempty"`
-	Configs     []MicroAppConfigResponse  `json:"configs,omitempty"`
+	AppID          string                    `json:"appId"`
+	Name           string                    `json:"name"`
+	Description    *string                   `json:"description,omitempty"`
+	PromoText      *string                   `json:"promoText,omitempty"`
+	IconURL        *string                   `json:"iconUrl,omitempty"`
+	BannerImageURL *string                   `json:"bannerImageUrl,omitempty"`
+	Active         int                       `json:"active"`
+	Mandatory      int                       `json:"mandatory"`
+	CategoryID     *string                   `json:"categoryId,omitempty"`
+	Tags           []string                  `json:"tags,omitempty"`
+	SortWeight     int                       `json:"sortWeight"`
+	Versions       []MicroAppVersionResponse `json:"versions,omitempty"`
+	Roles          []MicroAppRoleResponse    `json:"roles,omitempty"`
+	Configs        []MicroAppConfigResponse  `json:"configs,omitempty"`
+	// Approved bridge capabilities; the host app denies any other gated bridge call
+	Permissions []string   `json:"permissions"`
+	PublishAt   *time.Time `json:"publishAt,omitempty"`
+	ExpireAt    *time.Time `json:"expireAt,omitempty"`
+	// The calling user's library entry, on catalog reads, if the app is in their library
+	Library *UserMicroAppResponse `json:"library,omitempty"`
 }
 
 type CreateMicroAppRequest struct {
-	AppID       string                         `json:"appId" validate:"required"`
-	Name        string                         `json:"name" validate:"required"`
-	Description *string                        `json:"description,omitempty"`
-	IconURL     *string                        `json:"iconUrl,omitempty"`
-	Mandatory   int                            `json:"mandatory"`
-	Versions    []CreateMicroAppVersionRequest `json:"versions,omitempty" validate:"omitempty,dive"`
-	Roles       []CreateMicroAppRoleRequest    `json:"roles,omitempty" validate:"omitempty,dive"`
-	Configs     []CreateMicroAppConfigRequest  `json:"configs,omitempty" validate:"omitempty,dive"`
+	AppID          string                         `json:"appId" validate:"required"`
+	Name           string                         `json:"name" validate:"required"`
+	Description    *string                        `json:"description,omitempty"`
+	PromoText      *string                        `json:"promoText,omitempty" validate:"omitempty,max=1024"`
+	IconURL        *string                        `json:"iconUrl,omitempty"`
+	BannerImageURL *string                        `json:"bannerImageUrl,omitempty" validate:"omitempty,max=2083"`
+	Mandatory      int                            `json:"mandatory"`
+	CategoryID     *string                        `json:"categoryId,omitempty"`
+	Tags           []string                       `json:"tags,omitempty" validate:"omitempty,dive,required,max=100"`
+	SortWeight     int                            `json:"sortWeight"`
+	Versions       []CreateMicroAppVersionRequest `json:"versions,omitempty" validate:"omitempty,dive"`
+	Roles          []CreateMicroAppRoleRequest    `json:"roles,omitempty" validate:"omitempty,dive"`
+	Configs        []CreateMicroAppConfigRequest  `json:"configs,omitempty" validate:"omitempty,dive"`
+	// Optional schedule; the app is only offered between PublishAt and ExpireAt
+	PublishAt       *time.Time `json:"publishAt,omitempty"`
+	ExpireAt        *time.Time `json:"expireAt,omitempty"`
+	NotifyOnPublish bool       `json:"notifyOnPublish,omitempty"`
+}
+
+// MicroAppCategoryGroupResponse is a category section of the catalog when grouping by category.
+// Category is nil for the section holding uncategorised micro apps.
+type MicroAppCategoryGroupResponse struct {
+	Category *MicroAppCategoryResponse `json:"category"`
+	Apps     []MicroAppResponse        `json:"apps"`
+}
+
+// CatalogDeltaResponse holds the catalog changes since the revision in the client's sync token.
+// Changed apps are returned in full and replace the client's copy. When Full is true the client's
+// token no longer applies (e.g. the user's groups changed) and Apps is the complete catalog.
+type CatalogDeltaResponse struct {
+	Revision      int64              `json:"revision"`
+	SyncToken     string             `json:"syncToken"`
+	Full          bool               `json:"full"`
+	Apps          []MicroAppResponse `json:"apps"`
+	RemovedAppIDs []string           `json:"removedAppIds"`
+	// The user's complete library, since library changes do not advance the revision
+	Library []UserMicroAppResponse `json:"library"`
 }
@@ -0,0 +1,35 @@
+package dto
+
+import "time"
+
+type MicroAppPermissionResponse struct {
+	Capability string     `json:"capability"`
+	Status     string     `json:"status"`
+	ReviewedBy *string    `json:"reviewedBy,omitempty"`
+	ReviewedAt *time.Time `json:"reviewedAt,omitempty"`
+}
+
+type ReviewMicroAppPermissionRequest struct {
+	Status string `json:"status" validate:"required,oneof=approved rejected"`
+}
+
+// MicroAppVersionDiffResponse describes what changes between two builds of a micro app.
+// From is nil when To is the app's first build.
+type MicroAppVersionDiffResponse struct {
+	From               *MicroAppVersionRefResponse `json:"from,omitempty"`
+	To                 MicroAppVersionRefResponse  `json:"to"`
+	AddedPermissions   []string                    `json:"addedPermissions"`
+	RemovedPermissions []string                    `json:"removedPermissions"`
+	// Permissions To requires that are not approved yet; the host app blocks them until they are
+	PendingPermissions []string `json:"pendingPermissions"`
+}
+
+type MicroAppVersionRefResponse struct {
+	Version string `json:"version"`
+	Build   int    `json:"build"`
+}
+
+type PublishMicroAppVersionResponse struct {
+	Version MicroAppVersionResponse     `json:"version"`
+	Diff    MicroAppVersionDiffResponse `json:"diff"`
+}
@@ -0,0 +1,36 @@
+package dto
+
+import "time"
+
+type MicroAppPromotionResponse struct {
+	ID             int       `json:"id"`
+	MicroAppID     string    `json:"microAppId"`
+	PromoText      *string   `json:"promoText,omitempty"`
+	BannerImageURL *string   `json:"bannerImageUrl,omitempty"`
+	StartAt        time.Time `json:"startAt"`
+	EndAt          time.Time `json:"endAt"`
+	DisplayOrder   int       `json:"displayOrder"`
+	TargetGroups   []string  `json:"targetGroups,omitempty"`
+	Active         int       `json:"active"`
+}
+
+type UpsertMicroAppPromotionRequest struct {
+	AppID          string    `json:"appId" validate:"required"`
+	PromoText      *string   `json:"promoText,omitempty"`
+	BannerImageURL *string   `json:"bannerImageUrl,omitempty"`
+	StartAt        time.Time `json:"startAt" validate:"required"`
+	EndAt          time.Time `json:"endAt" validate:"required,gtfield=StartAt"`
+	DisplayOrder   int       `json:"displayOrder"`
+	TargetGroups   []string  `json:"targetGroups,omitempty" validate:"omitempty,dive,required"`
+}
+
+// FeaturedMicroAppResponse is a single slot of the home screen carousel
+type FeaturedMicroAppResponse struct {
+	PromotionID    int              `json:"promotionId"`
+	DisplayOrder   int              `json:"displayOrder"`
+	PromoText      *string          `json:"promoText,omitempty"`
+	BannerImageURL *string          `json:"bannerImageUrl,omitempty"`
+	StartAt        time.Time        `json:"startAt"`
+	EndAt          time.Time        `json:"endAt"`
+	App            MicroAppResponse `json:"app"`
+}
@@ -1,20 +1,30 @@
 package dto
 
+import "time"
+
 type MicroAppVersionResponse struct {
-	ID           int     `json:"id"`
-	MicroAppID   string  `json:"microAppId"`
-	Version      string  `json:"version"`
-	Build        int     `json:"build"`
-	ReleaseNotes *string `json:"releaseNotes,omitempty"`
-	IconURL      *string `json:"iconUrl,omitempty"`
-	DownloadURL  string  `json:"downloadUrl"`
-	Active       int     `json:"active"`
+	ID                  int        `json:"id"`
+	MicroAppID          string     `json:"microAppId"`
+	Version             string     `json:"version"`
+	Build               int        `json:"build"`
+	ReleaseNotes        *string    `json:"releaseNotes,omitempty"`
+	IconURL             *string    `json:"iconUrl,omitempty"`
+	DownloadURL         string     `json:"downloadUrl"`
+	RequiredPermissions []string   `json:"requiredPermissions,omitempty"`
+	Active              int        `json:"active"`
+	PublishAt           *time.Time `json:"publishAt,omitempty"`
+	ExpireAt            *time.Time `json:"expireAt,omitempty"`
 }
 
 type CreateMicroAppVersionRequest struct {
-	Version      string  `json:"version" validate:"required"`
-	Build        int     `json:"build" validate:"required,min=1"`
-	ReleaseNotes *string `json:"releaseNotes,omitempty"`
-	IconURL      *string `json:"iconUrl,omitempty"`
-	DownloadURL  string  `json:"downloadUrl" validate:"required"`
+	Version             string   `json:"version" validate:"required"`
+	Build               int      `json:"build" validate:"required,min=1"`
+	ReleaseNotes        *string  `json:"releaseNotes,omitempty"`
+	IconURL             *string  `json:"iconUrl,omitempty"`
+	DownloadURL         string   `json:"downloadUrl" validate:"required"`
+	RequiredPermissions []string `json:"requiredPermissions,omitempty" validate:"omitempty,dive,capability"`
+	// Optional schedule; the version is only offered between PublishAt and ExpireAt
+	PublishAt       *time.Time `json:"publishAt,omitempty"`
+	ExpireAt        *time.Time `json:"expireAt,omitempty"`
+	NotifyOnPublish bool       `json:"notifyOnPublish,omitempty"`
 }
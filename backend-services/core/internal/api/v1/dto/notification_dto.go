@@ -1,31 +1,83 @@
 package dto
 
+import "time"
+
 // RegisterDeviceTokenRequest represents the request to register a device token
 type RegisterDeviceTokenRequest struct {
 	Email    string `json:"email" validate:"required,email"`
 	Token    string `json:"token" validate:"required"`
 	Platform string `json:"platform" validate:"required,oneof=ios android"`
+	// Client-generated ID of the app install. Each device of a user keeps its own token;
+	// without it the token replaces the user's previous one on the same platform.
+	DeviceID   string  `json:"deviceId,omitempty" validate:"omitempty,max=255"`
+	DeviceName *string `json:"deviceName,omitempty" validate:"omitempty,max=255"`
+	AppVersion *string `json:"appVersion,omitempty" validate:"omitempty,max=50"`
 }
 
 // SendNotificationRequest represents the request to send a notification to specific users
 type SendNotificationRequest struct {
-	UserEmails []string               `json:"userEmails" validate:"required,min=1,dive,email"`
-	Title      string                 `json:"title" validate:"required"`
-	Body       string                 `json:"body" validate:"required"`
-	Data       map[string]interface{} `json:"data,omitempty"`
+	UserEmails []string `json:"userEmails" validate:"required,min=1,dive,email"`
+	// Title and body, or a registered template to render them from in each recipient's language
+	Title    string                   `json:"title,omitempty" validate:"required_without=Template,excluded_with=Template"`
+	Body     string                   `json:"body,omitempty" validate:"required_without=Template,excluded_with=Template"`
+	Template *NotificationTemplateRef `json:"template,omitempty"`
+	Data     map[string]interface{}   `json:"data,omitempty"`
+	// Optional link (e.g. https://superapp.example.com/leave/requests/42) the notification opens;
+	// it must match a registered deep link pattern
+	DeepLink string `json:"deepLink,omitempty" validate:"omitempty,max=2083"`
+	// Critical messages bypass the recipients' quiet hours and critical-only mode
+	Critical bool `json:"critical,omitempty"`
+	// Optional time to send the notification instead of right away. Either RFC 3339 with an
+	// offset, or a local time such as 2026-10-20T09:00:00 read in TimeZone.
+	SendAt   *string `json:"sendAt,omitempty"`
+	TimeZone string  `json:"timeZone,omitempty" validate:"omitempty,timezone"`
 }
 
 // SendToGroupsRequest represents the request to send a notification to user groups
 type SendToGroupsRequest struct {
-	Groups []string               `json:"groups" validate:"required,min=1"`
-	Title  string                 `json:"title" validate:"required"`
-	Body   string                 `json:"body" validate:"required"`
-	Data   map[string]interface{} `json:"data,omitempty"`
+	Groups []string `json:"groups" validate:"required,min=1,dive,required"`
+	// Users in any of these groups are left out even if they are in one of Groups
+	ExcludeGroups []string                 `json:"excludeGroups,omitempty" validate:"omitempty,dive,required"`
+	Title         string                   `json:"title,omitempty" validate:"required_without=Template,excluded_with=Template"`
+	Body          string                   `json:"body,omitempty" validate:"required_without=Template,excluded_with=Template"`
+	Template      *NotificationTemplateRef `json:"template,omitempty"`
+	Data          map[string]interface{}   `json:"data,omitempty"`
+	DeepLink      string                   `json:"deepLink,omitempty" validate:"omitempty,max=2083"`
+	SendAt        *string                  `json:"sendAt,omitempty"`
+	TimeZone      string                   `json:"timeZone,omitempty" validate:"omitempty,timezone"`
+	Critical      bool                     `json:"critical,omitempty"`
+}
+
+// NotificationTemplateRef selects a template of the sending micro app and the values of its
+// {{placeholders}}
+type NotificationTemplateRef struct {
+	Key    string            `json:"key" validate:"required,max=100"`
+	Params map[string]string `json:"params,omitempty"`
 }
 
-// NotificationResponse represents the response after sending notifications
-type NotificationResponse struct {
-	Success int    `json:"success"`
-	Failed  int    `json:"failed"`
-	Message string `json:"message"`
+// NotificationJobResponse represents a queued notification and, once sent, its outcome
+type NotificationJobResponse struct {
+	JobID    int64  `json:"jobId"`
+	Status   string `json:"status"`
+	Title    string `json:"title"`
+	Template string `json:"template,omitempty"`
+	Attempts int    `json:"attempts"`
+	// Set for scheduled notifications, with the time zone the send time was given in
+	SendAt   *time.Time `json:"sendAt,omitempty"`
+	TimeZone *string    `json:"timeZone,omitempty"`
+	// Distinct users the notification was addressed to
+	Recipients int `json:"recipients"`
+	Success    int `json:"success"`
+	Failed     int `json:"failed"`
+	// Device tokens deactivated because FCM reported them as no longer valid
+	Deactivated int `json:"deactivated"`
+	// Recipients whose preferences suppressed the push, and those it was deferred for until
+	// their quiet hours end
+	Suppressed int     `json:"suppressed"`
+	Deferred   int     `json:"deferred"`
+	LastError  *string `json:"lastError,omitempty"`
+	// Set while a failed job waits for its next attempt
+	NextAttemptAt *time.Time `json:"nextAttemptAt,omitempty"`
+	CreatedAt     time.Time  `json:"createdAt"`
+	CompletedAt   *time.Time `json:"completedAt,omitempty"`
 }
@@ -0,0 +1,38 @@
+package dto
+
+import "time"
+
+// InboxNotificationResponse is a notification in the user's inbox
+type InboxNotificationResponse struct {
+	ID     int64                  `json:"id"`
+	AppID  *string                `json:"appId,omitempty"`
+	Title  *string                `json:"title,omitempty"`
+	Body   *string                `json:"body,omitempty"`
+	Data   map[string]interface{} `json:"data,omitempty"`
+	SentAt time.Time              `json:"sentAt"`
+	Read   bool                   `json:"read"`
+	ReadAt *time.Time             `json:"readAt,omitempty"`
+}
+
+// NotificationInboxResponse is a page of the user's inbox, newest first. Pass NextCursor as
+// ?before= to fetch the next page; it is omitted on the last page.
+type NotificationInboxResponse struct {
+	Notifications []InboxNotificationResponse `json:"notifications"`
+	UnreadCount   int64                       `json:"unreadCount"`
+	NextCursor    *int64                      `json:"nextCursor,omitempty"`
+}
+
+// UnreadCountResponse is the number of unread notifications in the user's inbox
+type UnreadCountResponse struct {
+	UnreadCount int64 `json:"unreadCount"`
+}
+
+// UpdateInboxNotificationRequest marks a notification as read or unread
+type UpdateInboxNotificationRequest struct {
+	Read *bool `json:"read" validate:"required"`
+}
+
+// MarkAllReadResponse is the number of notifications marked as read
+type MarkAllReadResponse struct {
+	Updated int64 `json:"updated"`
+}
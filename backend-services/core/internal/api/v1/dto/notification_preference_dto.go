@@ -0,0 +1,36 @@
+package dto
+
+import "time"
+
+// NotificationPreferencesResponse is the logged-in user's push settings
+type NotificationPreferencesResponse struct {
+	QuietHoursStart *string                    `json:"quietHoursStart,omitempty"`
+	QuietHoursEnd   *string                    `json:"quietHoursEnd,omitempty"`
+	TimeZone        *string                    `json:"timeZone,omitempty"`
+	CriticalOnly    bool                       `json:"criticalOnly"`
+	Locale          *string                    `json:"locale,omitempty"`
+	Mutes           []NotificationMuteResponse `json:"mutes"`
+}
+
+// NotificationMuteResponse is a micro app the user muted, for good when MutedUntil is omitted
+type NotificationMuteResponse struct {
+	AppID      string     `json:"appId"`
+	MutedUntil *time.Time `json:"mutedUntil,omitempty"`
+}
+
+// UpdateNotificationPreferencesRequest replaces the user's push settings. Quiet hours are HH:MM
+// times in TimeZone (UTC when omitted) and may span midnight; omit both to turn them off.
+type UpdateNotificationPreferencesRequest struct {
+	QuietHoursStart *string `json:"quietHoursStart,omitempty" validate:"required_with=QuietHoursEnd,omitempty,datetime=15:04"`
+	QuietHoursEnd   *string `json:"quietHoursEnd,omitempty" validate:"required_with=QuietHoursStart,omitempty,datetime=15:04"`
+	TimeZone        *string `json:"timeZone,omitempty" validate:"omitempty,timezone"`
+	// Only critical messages are pushed; the others still reach the inbox
+	CriticalOnly bool `json:"criticalOnly"`
+	// Language (BCP 47 tag, e.g. fr-CA) templated notifications are rendered in
+	Locale *string `json:"locale,omitempty" validate:"omitempty,bcp47_language_tag"`
+}
+
+// MuteMicroAppRequest mutes a micro app's pushes, until Until or for good when it is omitted
+type MuteMicroAppRequest struct {
+	Until *time.Time `json:"until,omitempty"`
+}
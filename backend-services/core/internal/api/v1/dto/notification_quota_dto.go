@@ -0,0 +1,48 @@
+package dto
+
+import "time"
+
+// NotificationQuotaLimits are send limits of a micro app; 0 means the limit is not enforced
+type NotificationQuotaLimits struct {
+	PerMinute          int `json:"perMinute"`
+	PerDay             int `json:"perDay"`
+	PerRecipientPerDay int `json:"perRecipientPerDay"`
+}
+
+// UpdateNotificationQuotaRequest overrides a micro app's send limits. An omitted limit uses
+// the default; 0 lifts it.
+type UpdateNotificationQuotaRequest struct {
+	PerMinute          *int `json:"perMinute,omitempty" validate:"omitempty,min=0"`
+	PerDay             *int `json:"perDay,omitempty" validate:"omitempty,min=0"`
+	PerRecipientPerDay *int `json:"perRecipientPerDay,omitempty" validate:"omitempty,min=0"`
+}
+
+// NotificationQuotaUsageResponse is what a micro app sent in the current minute and UTC day
+type NotificationQuotaUsageResponse struct {
+	Minute int `json:"minute"`
+	Day    int `json:"day"`
+	// Most notifications sent to a single user today
+	MaxPerRecipient int `json:"maxPerRecipient"`
+}
+
+// NotificationQuotaResponse is a micro app's effective limits, the overrides they come from,
+// and its current usage
+type NotificationQuotaResponse struct {
+	AppID     string                         `json:"appId"`
+	Limits    NotificationQuotaLimits        `json:"limits"`
+	Overrides UpdateNotificationQuotaRequest `json:"overrides"`
+	Usage     NotificationQuotaUsageResponse `json:"usage"`
+	UpdatedBy *string                        `json:"updatedBy,omitempty"`
+	UpdatedAt *time.Time                     `json:"updatedAt,omitempty"`
+}
+
+// QuotaExceededResponse answers a send rejected with 429. RetryAfterSeconds matches the
+// Retry-After header; Recipients lists the users at their per-recipient limit, if that is the
+// limit exceeded.
+type QuotaExceededResponse struct {
+	Message           string   `json:"message"`
+	Limit             string   `json:"limit"`
+	Max               int      `json:"max"`
+	RetryAfterSeconds int      `json:"retryAfterSeconds"`
+	Recipients        []string `json:"recipients,omitempty"`
+}
@@ -0,0 +1,37 @@
+package dto
+
+import "time"
+
+// UpsertNotificationTemplateRequest replaces a template's copy. Titles and bodies may hold
+// {{placeholders}}; DefaultLocale is used for recipients whose language has no translation.
+type UpsertNotificationTemplateRequest struct {
+	DefaultLocale string                                   `json:"defaultLocale" validate:"required,bcp47_language_tag"`
+	Translations  []NotificationTemplateTranslationRequest `json:"translations" validate:"required,min=1,dive"`
+}
+
+// NotificationTemplateTranslationRequest is a template's copy in one locale (a BCP 47 tag)
+type NotificationTemplateTranslationRequest struct {
+	Locale string `json:"locale" validate:"required,bcp47_language_tag"`
+	Title  string `json:"title" validate:"required,max=255"`
+	Body   string `json:"body" validate:"required"`
+}
+
+// NotificationTemplateResponse is a registered template with the placeholders its copy uses
+type NotificationTemplateResponse struct {
+	AppID         string                                    `json:"appId"`
+	Key           string                                    `json:"key"`
+	DefaultLocale string                                    `json:"defaultLocale"`
+	Placeholders  []string                                  `json:"placeholders"`
+	Translations  []NotificationTemplateTranslationResponse `json:"translations"`
+	CreatedBy     string                                    `json:"createdBy"`
+	UpdatedBy     *string                                   `json:"updatedBy,omitempty"`
+	CreatedAt     time.Time                                 `json:"createdAt"`
+	UpdatedAt     *time.Time                                `json:"updatedAt,omitempty"`
+}
+
+// NotificationTemplateTranslationResponse is a template's copy in one locale
+type NotificationTemplateTranslationResponse struct {
+	Locale string `json:"locale"`
+	Title  string `json:"title"`
+	Body   string `json:"body"`
+}
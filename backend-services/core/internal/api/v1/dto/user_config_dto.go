@@ -7,6 +7,8 @@ type UserConfigResponse struct {
 	ConfigKey   string          `json:"configKey"`
 	ConfigValue json.RawMessage `json:"configValue"`
 	Active      int             `json:"active"`
+	// Row version; send it back as If-Match to update the config only if it is unchanged
+	Version int64 `json:"version"`
 }
 
 type UpsertUserConfigRequest struct {
@@ -0,0 +1,27 @@
+package dto
+
+import "time"
+
+// UserMicroAppResponse is an entry of the user's micro app library
+type UserMicroAppResponse struct {
+	AppID     string     `json:"appId"`
+	Installed bool       `json:"installed"`
+	Pinned    bool       `json:"pinned"`
+	Hidden    bool       `json:"hidden"`
+	Order     int        `json:"order"`
+	Mandatory bool       `json:"mandatory"`
+	UpdatedAt *time.Time `json:"updatedAt,omitempty"`
+}
+
+// UpdateUserMicroAppRequest changes a library entry; omitted fields keep their current value
+type UpdateUserMicroAppRequest struct {
+	Installed *bool `json:"installed,omitempty"`
+	Pinned    *bool `json:"pinned,omitempty"`
+	Hidden    *bool `json:"hidden,omitempty"`
+	Order     *int  `json:"order,omitempty" validate:"omitempty,min=0"`
+}
+
+// ReorderUserMicroAppsRequest sets the library order to the position of each app in AppIDs
+type ReorderUserMicroAppsRequest struct {
+	AppIDs []string `json:"appIds" validate:"required,min=1,max=500,unique,dive,required"`
+}
@@ -0,0 +1,406 @@
+package handler
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"sort"
+	"time"
+
+	"go-backend/internal/api/v1/dto"
+	"go-backend/internal/auth"
+	"go-backend/internal/models"
+
+	"gorm.io/gorm"
+)
+
+const (
+	// Report query parameters
+	queryParamFrom  = "from"
+	queryParamTo    = "to"
+	queryParamDate  = "date"
+	queryParamAppID = "appId"
+	queryParamBy    = "by"
+
+	// Report breakdowns
+	reportByApp     = "app"
+	reportByGroup   = "group"
+	reportByVersion = "version"
+
+	reportDayLayout = "2006-01-02"
+	mauWindowDays   = 30
+
+	// Clock skew tolerated on event timestamps from devices
+	analyticsMaxClockSkew = 5 * time.Minute
+)
+
+type AnalyticsHandler struct {
+	db *gorm.DB
+}
+
+func NewAnalyticsHandler(db *gorm.DB) *AnalyticsHandler {
+	return &AnalyticsHandler{db: db}
+}
+
+// IngestEvents handles a batch of micro app usage events from the host app. Events for unknown
+// micro apps, or too old to be rolled up, are dropped and counted in the response.
+func (h *AnalyticsHandler) IngestEvents(w http.ResponseWriter, r *http.Request) {
+	userInfo, ok := auth.GetUserInfo(r.Context())
+	if !ok {
+		http.Error(w, "user info not found in context", http.StatusUnauthorized)
+		return
+	}
+
+	if !validateContentType(w, r) {
+		return
+	}
+
+	limitRequestBody(w, r, 0) // 1MB default limit
+	var req dto.IngestAnalyticsEventsRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if !validateStruct(w, &req) {
+		return
+	}
+
+	appIDs := make([]string, 0, len(req.Events))
+	for _, e := range req.Events {
+		appIDs = append(appIDs, e.AppID)
+	}
+	var knownAppIDs []string
+	if err := h.db.Model(&models.MicroApp{}).Where("micro_app_id IN ?", appIDs).
+		Pluck("micro_app_id", &knownAppIDs).Error; err != nil {
+		slog.Error("Failed to fetch micro apps for analytics", "error", err)
+		http.Error(w, "failed to ingest events", http.StatusInternalServerError)
+		return
+	}
+	known := make(map[string]bool, len(knownAppIDs))
+	for _, id := range knownAppIDs {
+		known[id] = true
+	}
+
+	now := time.Now().UTC()
+	oldest := models.AnalyticsRollupStart(now)
+	latest := now.Add(analyticsMaxClockSkew)
+
+	events := make([]models.AnalyticsEvent, 0, len(req.Events))
+	for _, e := range req.Events {
+		if !known[e.AppID] || e.OccurredAt.Before(oldest) || e.OccurredAt.After(latest) {
+			continue
+		}
+		event := models.AnalyticsEvent{
+			MicroAppID:   e.AppID,
+			UserEmail:    userInfo.Email,
+			EventType:    e.Type,
+			Version:      e.Version,
+			ErrorMessage: e.ErrorMessage,
+			OccurredAt:   e.OccurredAt,
+		}
+		if e.Type == models.AnalyticsEventSession {
+			event.DurationMs = e.DurationMs
+		}
+		events = append(events, event)
+	}
+
+	if len(events) > 0 {
+		if err := h.db.CreateInBatches(&events, 100).Error; err != nil {
+			slog.Error("Failed to store analytics events", "error", err, "email", userInfo.Email)
+			http.Error(w, "failed to ingest events", http.StatusInternalServerError)
+			return
+		}
+	}
+
+	response := dto.IngestAnalyticsEventsResponse{Accepted: len(events), Dropped: len(req.Events) - len(events)}
+	if err := writeJSON(w, http.StatusAccepted, response); err != nil {
+		slog.Error("Failed to write JSON response", "error", err)
+		http.Error(w, "failed to write response", http.StatusInternalServerError)
+	}
+}
+
+// GetDailyUsage handles the daily usage report. Query parameters: from and to (YYYY-MM-DD,
+// default the last 30 days), appId, and by=app|group|version (default app).
+func (h *AnalyticsHandler) GetDailyUsage(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	by, ok := reportBreakdown(w, query.Get(queryParamBy))
+	if !ok {
+		return
+	}
+
+	to, ok := parseReportDay(w, query.Get(queryParamTo), today())
+	if !ok {
+		return
+	}
+	from, ok := parseReportDay(w, query.Get(queryParamFrom), to.AddDate(0, 0, -(mauWindowDays-1)))
+	if !ok {
+		return
+	}
+	if from.After(to) {
+		http.Error(w, "from must not be after to", http.StatusBadRequest)
+		return
+	}
+	appID := query.Get(queryParamAppID)
+	fromDay, toDay := from.Format(reportDayLayout), to.Format(reportDayLayout)
+
+	type usageRow struct {
+		Day         time.Time
+		MicroAppID  string
+		Dimension   string
+		ActiveUsers int64
+		Launches    int64
+		Sessions    int64
+		SessionMs   int64
+		Errors      int64
+	}
+	var rows []usageRow
+
+	switch by {
+	case reportByGroup:
+		// Counts are per app; group breakdowns only have distinct users
+		q := h.db.Table("analytics_daily_user AS u").
+			Select("u.day, u.micro_app_id, g.group_name AS dimension, COUNT(DISTINCT u.user_email) AS active_users").
+			Joins("JOIN user_groups g ON g.user_email = u.user_email").
+			Where("u.day BETWEEN ? AND ?", fromDay, toDay)
+		if appID != "" {
+			q = q.Where("u.micro_app_id = ?", appID)
+		}
+		if err := q.Group("u.day, u.micro_app_id, g.group_name").Scan(&rows).Error; err != nil {
+			slog.Error("Failed to fetch daily usage", "error", err)
+			http.Error(w, "failed to fetch usage report", http.StatusInternalServerError)
+			return
+		}
+	default:
+		dimension, groupBy := "''", "day, micro_app_id"
+		if by == reportByVersion {
+			dimension, groupBy = "version", groupBy+", version"
+		}
+		counts := h.db.Model(&models.AnalyticsDailyApp{}).
+			Select("day, micro_app_id, "+dimension+" AS dimension, SUM(launches) AS launches, SUM(sessions) AS sessions, SUM(session_ms) AS session_ms, SUM(errors) AS errors").
+			Where("day BETWEEN ? AND ?", fromDay, toDay)
+		users := h.db.Model(&models.AnalyticsDailyUser{}).
+			Select("day, micro_app_id, "+dimension+" AS dimension, COUNT(DISTINCT user_email) AS active_users").
+			Where("day BETWEEN ? AND ?", fromDay, toDay)
+		if appID != "" {
+			counts = counts.Where("micro_app_id = ?", appID)
+			users = users.Where("micro_app_id = ?", appID)
+		}
+		if err := counts.Group(groupBy).Scan(&rows).Error; err != nil {
+			slog.Error("Failed to fetch daily usage", "error", err)
+			http.Error(w, "failed to fetch usage report", http.StatusInternalServerError)
+			return
+		}
+		var userRows []usageRow
+		if err := users.Group(groupBy).Scan(&userRows).Error; err != nil {
+			slog.Error("Failed to fetch daily active users", "error", err)
+			http.Error(w, "failed to fetch usage report", http.StatusInternalServerError)
+			return
+		}
+		activeUsers := make(map[string]int64, len(userRows))
+		for _, u := range userRows {
+			activeUsers[u.Day.Format(reportDayLayout)+"|"+u.MicroAppID+"|"+u.Dimension] = u.ActiveUsers
+		}
+		for i := range rows {
+			rows[i].ActiveUsers = activeUsers[rows[i].Day.Format(reportDayLayout)+"|"+rows[i].MicroAppID+"|"+rows[i].Dimension]
+		}
+	}
+
+	response := make([]dto.DailyUsageResponse, 0, len(rows))
+	for _, row := range rows {
+		entry := dto.DailyUsageResponse{
+			Day:         row.Day.Format(reportDayLayout),
+			AppID:       row.MicroAppID,
+			ActiveUsers: row.ActiveUsers,
+		}
+		switch by {
+		case reportByGroup:
+			entry.Group = row.Dimension
+		case reportByVersion:
+			entry.Version = row.Dimension
+		}
+		if by != reportByGroup {
+			launches, sessions, errors := row.Launches, row.Sessions, row.Errors
+			entry.Launches, entry.Sessions, entry.Errors = &launches, &sessions, &errors
+			if row.Sessions > 0 {
+				avg := row.SessionMs / row.Sessions
+				entry.AvgSessionMs = &avg
+			}
+		}
+		response = append(response, entry)
+	}
+	sort.SliceStable(response, func(i, j int) bool {
+		a, b := response[i], response[j]
+		if a.Day != b.Day {
+			return a.Day < b.Day
+		}
+		if a.AppID != b.AppID {
+			return a.AppID < b.AppID
+		}
+		return a.Group+a.Version < b.Group+b.Version
+	})
+
+	if err := writeJSON(w, http.StatusOK, response); err != nil {
+		slog.Error("Failed to write JSON response", "error", err)
+		http.Error(w, "failed to write response", http.StatusInternalServerError)
+	}
+}
+
+// GetActiveUsers handles the DAU/MAU report: DAU on date (YYYY-MM-DD, default today) and MAU over
+// the 30 days ending on it, per app, optionally broken down by=group|version. Per-app reports list
+// every active micro app, including unused ones, with the last day each was used.
+func (h *AnalyticsHandler) GetActiveUsers(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	by, ok := reportBreakdown(w, query.Get(queryParamBy))
+	if !ok {
+		return
+	}
+	date, ok := parseReportDay(w, query.Get(queryParamDate), today())
+	if !ok {
+		return
+	}
+	appID := query.Get(queryParamAppID)
+	day := date.Format(reportDayLayout)
+	windowStart := date.AddDate(0, 0, -(mauWindowDays - 1)).Format(reportDayLayout)
+
+	q := h.db.Table("analytics_daily_user AS u").
+		Where("u.day BETWEEN ? AND ?", windowStart, day)
+	dimension, groupBy := "''", "u.micro_app_id"
+	switch by {
+	case reportByGroup:
+		q = q.Joins("JOIN user_groups g ON g.user_email = u.user_email")
+		dimension, groupBy = "g.group_name", groupBy+", g.group_name"
+	case reportByVersion:
+		dimension, groupBy = "u.version", groupBy+", u.version"
+	}
+	if appID != "" {
+		q = q.Where("u.micro_app_id = ?", appID)
+	}
+
+	type activeRow struct {
+		MicroAppID string
+		Dimension  string
+		DAU        int64 `gorm:"column:dau"`
+		MAU        int64 `gorm:"column:mau"`
+	}
+	var rows []activeRow
+	if err := q.Select("u.micro_app_id, "+dimension+" AS dimension, "+
+		"COUNT(DISTINCT CASE WHEN u.day = ? THEN u.user_email END) AS dau, "+
+		"COUNT(DISTINCT u.user_email) AS mau", day).
+		Group(groupBy).
+		Scan(&rows).Error; err != nil {
+		slog.Error("Failed to fetch active users", "error", err)
+		http.Error(w, "failed to fetch active users report", http.StatusInternalServerError)
+		return
+	}
+
+	response := make([]dto.ActiveUsersResponse, 0, len(rows))
+	for _, row := range rows {
+		entry := dto.ActiveUsersResponse{AppID: row.MicroAppID, DAU: row.DAU, MAU: row.MAU}
+		switch by {
+		case reportByGroup:
+			entry.Group = row.Dimension
+		case reportByVersion:
+			entry.Version = row.Dimension
+		}
+		response = append(response, entry)
+	}
+
+	if by == reportByApp {
+		var err error
+		if response, err = h.withUnusedApps(response, appID, day); err != nil {
+			slog.Error("Failed to fetch micro app usage history", "error", err)
+			http.Error(w, "failed to fetch active users report", http.StatusInternalServerError)
+			return
+		}
+	}
+
+	sort.SliceStable(response, func(i, j int) bool {
+		a, b := response[i], response[j]
+		if a.AppID != b.AppID {
+			return a.AppID < b.AppID
+		}
+		return a.Group+a.Version < b.Group+b.Version
+	})
+
+	if err := writeJSON(w, http.StatusOK, response); err != nil {
+		slog.Error("Failed to write JSON response", "error", err)
+		http.Error(w, "failed to write response", http.StatusInternalServerError)
+	}
+}
+
+// withUnusedApps adds a zero row for every active micro app missing from the report and fills in
+// the last day each app was used, so apps nobody opens stand out
+func (h *AnalyticsHandler) withUnusedApps(response []dto.ActiveUsersResponse, appID, day string) ([]dto.ActiveUsersResponse, error) {
+	appsQuery := h.db.Model(&models.MicroApp{}).Where("active = ?", models.StatusActive)
+	if appID != "" {
+		appsQuery = appsQuery.Where("micro_app_id = ?", appID)
+	}
+	var appIDs []string
+	if err := appsQuery.Pluck("micro_app_id", &appIDs).Error; err != nil {
+		return nil, err
+	}
+
+	type lastRow struct {
+		MicroAppID string
+		LastDay    time.Time
+	}
+	var lastRows []lastRow
+	lastQuery := h.db.Model(&models.AnalyticsDailyUser{}).
+		Select("micro_app_id, MAX(day) AS last_day").
+		Where("day <= ?", day)
+	if appID != "" {
+		lastQuery = lastQuery.Where("micro_app_id = ?", appID)
+	}
+	if err := lastQuery.Group("micro_app_id").Scan(&lastRows).Error; err != nil {
+		return nil, err
+	}
+	lastDays := make(map[string]string, len(lastRows))
+	for _, l := range lastRows {
+		lastDays[l.MicroAppID] = l.LastDay.Format(reportDayLayout)
+	}
+
+	seen := make(map[string]bool, len(response))
+	for i := range response {
+		seen[response[i].AppID] = true
+	}
+	for _, id := range appIDs {
+		if !seen[id] {
+			response = append(response, dto.ActiveUsersResponse{AppID: id})
+		}
+	}
+	for i := range response {
+		if last, ok := lastDays[response[i].AppID]; ok {
+			response[i].LastActiveDay = &last
+		}
+	}
+	return response, nil
+}
+
+func reportBreakdown(w http.ResponseWriter, by string) (string, bool) {
+	switch by {
+	case "":
+		return reportByApp, true
+	case reportByApp, reportByGroup, reportByVersion:
+		return by, true
+	}
+	http.Error(w, "by must be one of app, group, version", http.StatusBadRequest)
+	return "", false
+}
+
+func parseReportDay(w http.ResponseWriter, value string, fallback time.Time) (time.Time, bool) {
+	if value == "" {
+		return fallback, true
+	}
+	day, err := time.Parse(reportDayLayout, value)
+	if err != nil {
+		http.Error(w, "dates must be formatted as YYYY-MM-DD", http.StatusBadRequest)
+		return time.Time{}, false
+	}
+	return day, true
+}
+
+// today is the current UTC day, which is what rollups are keyed on
+func today() time.Time {
+	return time.Now().UTC().Truncate(24 * time.Hour)
+}
@@ -0,0 +1,84 @@
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"go-backend/internal/api/v1/dto"
+	"go-backend/internal/auth"
+	"go-backend/internal/models"
+	"go-backend/internal/testdb"
+)
+
+func TestReportParams(t *testing.T) {
+	for by, want := range map[string]string{"": reportByApp, "group": reportByGroup, "version": reportByVersion} {
+		if got, ok := reportBreakdown(httptest.NewRecorder(), by); !ok || got != want {
+			t.Errorf("reportBreakdown(%q) = %q %v, want %q", by, got, ok, want)
+		}
+	}
+	w := httptest.NewRecorder()
+	if _, ok := reportBreakdown(w, "user"); ok || w.Code != http.StatusBadRequest {
+		t.Errorf("reportBreakdown(user) = %v %d, want 400", ok, w.Code)
+	}
+
+	fallback := time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)
+	if day, ok := parseReportDay(httptest.NewRecorder(), "", fallback); !ok || !day.Equal(fallback) {
+		t.Errorf("empty day = %v, want the fallback", day)
+	}
+	if day, ok := parseReportDay(httptest.NewRecorder(), "2026-10-18", fallback); !ok || !day.Equal(time.Date(2026, 10, 18, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("day = %v, want 2026-10-18", day)
+	}
+	w = httptest.NewRecorder()
+	if _, ok := parseReportDay(w, "18/10/2026", fallback); ok || w.Code != http.StatusBadRequest {
+		t.Errorf("malformed day = %v %d, want 400", ok, w.Code)
+	}
+}
+
+func TestIngestEvents(t *testing.T) {
+	db := testdb.Open(t)
+	if err := db.Create(&models.MicroApp{MicroAppID: "analytics-test", Name: "Test app", CreatedBy: "admin@example.com"}).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	now := time.Now().UTC()
+	event := func(appID, eventType string, occurredAt time.Time, extra string) string {
+		return fmt.Sprintf(`{"appId":%q,"type":%q,"occurredAt":%q%s}`, appID, eventType, occurredAt.Format(time.RFC3339), extra)
+	}
+	body := `{"events":[` + strings.Join([]string{
+		event("analytics-test", "launch", now.Add(-time.Minute), ""),
+		event("analytics-test", "session", now.Add(-time.Minute), `,"durationMs":1500`),
+		event("unknown-app", "launch", now, ""),
+		event("analytics-test", "launch", now.AddDate(0, 0, -10), ""),
+		event("analytics-test", "launch", now.Add(time.Hour), ""),
+	}, ",") + `]}`
+
+	r := httptest.NewRequest(http.MethodPost, "/analytics/events", strings.NewReader(body))
+	r.Header.Set("Content-Type", "application/json")
+	r = auth.SetUserInfo(r, &auth.CustomJwtPayload{Email: "a@example.com"})
+	w := httptest.NewRecorder()
+	NewAnalyticsHandler(db).IngestEvents(w, r)
+	if w.Code != http.StatusAccepted {
+		t.Fatalf("status = %d %s, want 202", w.Code, w.Body)
+	}
+
+	var response dto.IngestAnalyticsEventsResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+		t.Fatal(err)
+	}
+	if response.Accepted != 2 || response.Dropped != 3 {
+		t.Errorf("accepted %d dropped %d, want 2 and 3", response.Accepted, response.Dropped)
+	}
+
+	var stored []models.AnalyticsEvent
+	if err := db.Where("micro_app_id = ?", "analytics-test").Order("id").Find(&stored).Error; err != nil {
+		t.Fatal(err)
+	}
+	if len(stored) != 2 || stored[0].UserEmail != "a@example.com" || stored[1].DurationMs == nil || *stored[1].DurationMs != 1500 {
+		t.Errorf("stored = %+v, want the launch and the 1500ms session of a@example.com", stored)
+	}
+}
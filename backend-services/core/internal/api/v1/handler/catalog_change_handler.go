@@ -0,0 +1,732 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strconv"
+	"time"
+
+	"go-backend/internal/api/v1/dto"
+	"go-backend/internal/auth"
+	"go-backend/internal/config"
+	"go-backend/internal/models"
+
+	fileservice "go-backend/plugins/file-service"
+
+	"github.com/go-chi/chi/v5"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// CatalogChangeHandler implements the maker-checker workflow for micro app changes:
+// a change is drafted, submitted, approved by an admin who did not author it, then published.
+type CatalogChangeHandler struct {
+	db          *gorm.DB
+	fileService fileservice.FileService
+}
+
+// Statuses of changes that may still be published
+var openCatalogChangeStatuses = []string{
+	models.CatalogChangeDraft,
+	models.CatalogChangeSubmitted,
+	models.CatalogChangeApproved,
+	models.CatalogChangeRejected,
+}
+
+func NewCatalogChangeHandler(db *gorm.DB, fileService fileservice.FileService) *CatalogChangeHandler {
+	return &CatalogChangeHandler{db: db, fileService: fileService}
+}
+
+// Answer to live catalog writes while catalog changes must go through review
+const catalogReviewRequiredMessage = "catalog changes require review; submit them through /catalog-changes"
+
+// RequireLiveCatalogWrites rejects requests that write to the live catalog directly while
+// CATALOG_REVIEW_REQUIRED is set, so that no admin can change what users see on their own
+func RequireLiveCatalogWrites(cfg *config.Config) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if cfg.CatalogReviewRequired {
+				http.Error(w, catalogReviewRequiredMessage, http.StatusForbidden)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// GetAll handles listing catalog changes, optionally filtered by ?status= and ?appId=
+func (h *CatalogChangeHandler) GetAll(w http.ResponseWriter, r *http.Request) {
+	query := h.db.Order("id DESC")
+	if status := r.URL.Query().Get("status"); status != "" {
+		query = query.Where("status = ?", status)
+	}
+	if appID := r.URL.Query().Get("appId"); appID != "" {
+		query = query.Where("micro_app_id = ?", appID)
+	}
+
+	var changes []models.CatalogChange
+	if err := query.Find(&changes).Error; err != nil {
+		slog.Error("Failed to fetch catalog changes", "error", err)
+		http.Error(w, "failed to fetch catalog changes", http.StatusInternalServerError)
+		return
+	}
+
+	response := make([]dto.CatalogChangeResponse, 0, len(changes))
+	for _, c := range changes {
+		response = append(response, toCatalogChangeResponse(c))
+	}
+
+	if err := writeJSON(w, http.StatusOK, response); err != nil {
+		slog.Error("Failed to write JSON response", "error", err)
+		http.Error(w, "failed to write response", http.StatusInternalServerError)
+	}
+}
+
+// GetByID handles fetching a catalog change with its audit trail
+func (h *CatalogChangeHandler) GetByID(w http.ResponseWriter, r *http.Request) {
+	id, ok := changeIDParam(w, r)
+	if !ok {
+		return
+	}
+
+	var change models.CatalogChange
+	if err := h.db.Preload("Events", func(db *gorm.DB) *gorm.DB {
+		return db.Order("id ASC")
+	}).First(&change, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			http.Error(w, "catalog change not found", http.StatusNotFound)
+			return
+		}
+		slog.Error("Failed to fetch catalog change", "error", err, "changeID", id)
+		http.Error(w, "failed to fetch catalog change", http.StatusInternalServerError)
+		return
+	}
+
+	if err := writeJSON(w, http.StatusOK, toCatalogChangeResponse(change)); err != nil {
+		slog.Error("Failed to write JSON response", "error", err)
+		http.Error(w, "failed to write response", http.StatusInternalServerError)
+	}
+}
+
+// Create handles saving a micro app upsert request as a draft change
+func (h *CatalogChangeHandler) Create(w http.ResponseWriter, r *http.Request) {
+	userInfo, ok := auth.GetUserInfo(r.Context())
+	if !ok {
+		http.Error(w, "user info not found in context", http.StatusUnauthorized)
+		return
+	}
+
+	req, ok := h.decodeMicroAppRequest(w, r)
+	if !ok {
+		return
+	}
+
+	base, err := liveRowVersion(h.db, req.AppID)
+	if err != nil {
+		slog.Error("Failed to fetch micro app", "error", err, "appID", req.AppID)
+		http.Error(w, "failed to create catalog change", http.StatusInternalServerError)
+		return
+	}
+
+	change, err := createDraftChange(h.db, req, base, userInfo.Email, false, nil, nil)
+	if err != nil {
+		slog.Error("Failed to create catalog change", "error", err, "appID", req.AppID)
+		http.Error(w, "failed to create catalog change", http.StatusInternalServerError)
+		return
+	}
+
+	if err := writeJSON(w, http.StatusCreated, toCatalogChangeResponse(change)); err != nil {
+		slog.Error("Failed to write JSON response", "error", err)
+		http.Error(w, "failed to write response", http.StatusInternalServerError)
+	}
+}
+
+// Update handles editing a draft or rejected change; a rejected change goes back to draft
+func (h *CatalogChangeHandler) Update(w http.ResponseWriter, r *http.Request) {
+	userInfo, ok := auth.GetUserInfo(r.Context())
+	if !ok {
+		http.Error(w, "user info not found in context", http.StatusUnauthorized)
+		return
+	}
+
+	id, ok := changeIDParam(w, r)
+	if !ok {
+		return
+	}
+
+	req, ok := h.decodeMicroAppRequest(w, r)
+	if !ok {
+		return
+	}
+	payload, err := json.Marshal(req)
+	if err != nil {
+		slog.Error("Failed to encode catalog change", "error", err, "changeID", id)
+		http.Error(w, "failed to update catalog change", http.StatusInternalServerError)
+		return
+	}
+
+	h.transition(w, id, userInfo.Email, models.CatalogChangeActionUpdated, nil,
+		[]string{models.CatalogChangeDraft, models.CatalogChangeRejected},
+		func(tx *gorm.DB, change *models.CatalogChange) error {
+			if change.MicroAppID != req.AppID {
+				return &requestError{status: http.StatusBadRequest, message: "appId cannot be changed; create a new change instead"}
+			}
+			change.Payload = payload
+			change.Status = models.CatalogChangeDraft
+			change.ReviewedBy = nil
+			change.ReviewedAt = nil
+			return nil
+		})
+}
+
+// Submit handles sending a draft for review
+func (h *CatalogChangeHandler) Submit(w http.ResponseWriter, r *http.Request) {
+	h.review(w, r, models.CatalogChangeActionSubmitted, []string{models.CatalogChangeDraft},
+		func(tx *gorm.DB, change *models.CatalogChange, actor string) error {
+			change.Status = models.CatalogChangeSubmitted
+			return nil
+		})
+}
+
+// Approve handles approving a submitted change. Nobody who drafted or edited the change may approve it.
+func (h *CatalogChangeHandler) Approve(w http.ResponseWriter, r *http.Request) {
+	h.review(w, r, models.CatalogChangeActionApproved, []string{models.CatalogChangeSubmitted},
+		func(tx *gorm.DB, change *models.CatalogChange, actor string) error {
+			var authors []string
+			if err := tx.Model(&models.CatalogChangeEvent{}).
+				Where("change_id = ? AND action IN ?", change.ID,
+					[]string{models.CatalogChangeActionCreated, models.CatalogChangeActionUpdated}).
+				Distinct().Pluck("actor", &authors).Error; err != nil {
+				return err
+			}
+			if slices.Contains(authors, actor) {
+				return &requestError{status: http.StatusForbidden, message: "a change must be approved by an admin who did not author it"}
+			}
+
+			now := time.Now()
+			change.Status = models.CatalogChangeApproved
+			change.ReviewedBy = &actor
+			change.ReviewedAt = &now
+			return nil
+		})
+}
+
+// Reject handles sending a submitted change back to its authors. Bundles uploaded for the
+// change are discarded and their versions dropped from it; a fixed bundle is published anew.
+func (h *CatalogChangeHandler) Reject(w http.ResponseWriter, r *http.Request) {
+	var staged []string
+	ok := h.review(w, r, models.CatalogChangeActionRejected, []string{models.CatalogChangeSubmitted},
+		func(tx *gorm.DB, change *models.CatalogChange, actor string) error {
+			var err error
+			if staged, err = dropStagedVersions(tx, change); err != nil {
+				return err
+			}
+
+			now := time.Now()
+			change.Status = models.CatalogChangeRejected
+			change.ReviewedBy = &actor
+			change.ReviewedAt = &now
+			return nil
+		})
+	if !ok {
+		return
+	}
+
+	for _, fileName := range staged {
+		if err := h.fileService.DeleteFile(fileName); err != nil {
+			slog.Warn("Failed to delete staged bundle file", "error", err, "fileName", fileName)
+		}
+	}
+}
+
+// Publish handles applying an approved change to the live catalog and snapshotting the result.
+// The payload replaces the app, so publishing fails with 409 if the live app changed after the
+// change was drafted; the change must then be redrafted from the current app.
+func (h *CatalogChangeHandler) Publish(w http.ResponseWriter, r *http.Request) {
+	h.review(w, r, models.CatalogChangeActionPublished, []string{models.CatalogChangeApproved},
+		func(tx *gorm.DB, change *models.CatalogChange, actor string) error {
+			var req dto.CreateMicroAppRequest
+			if err := json.Unmarshal(change.Payload, &req); err != nil {
+				return err
+			}
+
+			app, err := lockMicroApp(tx, req.AppID, nil)
+			if err != nil {
+				return err
+			}
+			if change.BaseRowVersion != nil {
+				current := int64(0)
+				if app != nil {
+					current = app.RowVersion
+				}
+				if current != *change.BaseRowVersion {
+					return &requestError{status: http.StatusConflict, message: "the micro app changed after this change was drafted; create a new change from the current app"}
+				}
+			}
+
+			// Categories and config schemas may have changed since the draft was saved
+			reqErr, err := validateMicroAppRequest(tx, &req)
+			if err != nil {
+				return err
+			}
+			if reqErr != nil {
+				return reqErr
+			}
+
+			if err := upsertMicroApp(tx, &req, actor, change.Replace); err != nil {
+				return err
+			}
+
+			snapshot, err := snapshotMicroApp(tx, req.AppID)
+			if err != nil {
+				return err
+			}
+			if err := tx.Create(&models.CatalogSnapshot{
+				MicroAppID: req.AppID,
+				ChangeID:   change.ID,
+				Snapshot:   snapshot,
+				CreatedBy:  actor,
+			}).Error; err != nil {
+				return err
+			}
+
+			now := time.Now()
+			change.Status = models.CatalogChangePublished
+			change.PublishedBy = &actor
+			change.PublishedAt = &now
+			return nil
+		})
+}
+
+// GetSnapshots handles listing published states of micro apps, optionally filtered by ?appId=
+func (h *CatalogChangeHandler) GetSnapshots(w http.ResponseWriter, r *http.Request) {
+	query := h.db.Order("id DESC")
+	if appID := r.URL.Query().Get("appId"); appID != "" {
+		query = query.Where("micro_app_id = ?", appID)
+	}
+
+	var snapshots []models.CatalogSnapshot
+	if err := query.Find(&snapshots).Error; err != nil {
+		slog.Error("Failed to fetch catalog snapshots", "error", err)
+		http.Error(w, "failed to fetch catalog snapshots", http.StatusInternalServerError)
+		return
+	}
+
+	response := make([]dto.CatalogSnapshotResponse, 0, len(snapshots))
+	for _, s := range snapshots {
+		var snapshot dto.CreateMicroAppRequest
+		if err := json.Unmarshal(s.Snapshot, &snapshot); err != nil {
+			slog.Error("Failed to decode catalog snapshot", "error", err, "snapshotID", s.ID)
+			http.Error(w, "failed to fetch catalog snapshots", http.StatusInternalServerError)
+			return
+		}
+		response = append(response, dto.CatalogSnapshotResponse{
+			ID:        s.ID,
+			AppID:     s.MicroAppID,
+			ChangeID:  s.ChangeID,
+			Snapshot:  snapshot,
+			CreatedBy: s.CreatedBy,
+			CreatedAt: s.CreatedAt,
+		})
+	}
+
+	if err := writeJSON(w, http.StatusOK, response); err != nil {
+		slog.Error("Failed to write JSON response", "error", err)
+		http.Error(w, "failed to write response", http.StatusInternalServerError)
+	}
+}
+
+// RestoreSnapshot handles rolling a micro app back to a published state. It opens a new draft that
+// replaces the app's current state, so the rollback goes through review like any other change.
+func (h *CatalogChangeHandler) RestoreSnapshot(w http.ResponseWriter, r *http.Request) {
+	userInfo, ok := auth.GetUserInfo(r.Context())
+	if !ok {
+		http.Error(w, "user info not found in context", http.StatusUnauthorized)
+		return
+	}
+
+	id, err := strconv.ParseInt(chi.URLParam(r, "snapshotID"), 10, 64)
+	if err != nil {
+		http.Error(w, "invalid snapshot ID", http.StatusBadRequest)
+		return
+	}
+
+	var snapshot models.CatalogSnapshot
+	if err := h.db.First(&snapshot, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			http.Error(w, "catalog snapshot not found", http.StatusNotFound)
+			return
+		}
+		slog.Error("Failed to fetch catalog snapshot", "error", err, "snapshotID", id)
+		http.Error(w, "failed to restore catalog snapshot", http.StatusInternalServerError)
+		return
+	}
+
+	var req dto.CreateMicroAppRequest
+	if err := json.Unmarshal(snapshot.Snapshot, &req); err != nil {
+		slog.Error("Failed to decode catalog snapshot", "error", err, "snapshotID", id)
+		http.Error(w, "failed to restore catalog snapshot", http.StatusInternalServerError)
+		return
+	}
+
+	base, err := liveRowVersion(h.db, req.AppID)
+	if err != nil {
+		slog.Error("Failed to fetch micro app", "error", err, "appID", req.AppID)
+		http.Error(w, "failed to restore catalog snapshot", http.StatusInternalServerError)
+		return
+	}
+
+	comment := fmt.Sprintf("restored from snapshot %d", snapshot.ID)
+	change, err := createDraftChange(h.db, &req, base, userInfo.Email, true, &snapshot.ID, &comment)
+	if err != nil {
+		slog.Error("Failed to create catalog change", "error", err, "snapshotID", id)
+		http.Error(w, "failed to restore catalog snapshot", http.StatusInternalServerError)
+		return
+	}
+
+	if err := writeJSON(w, http.StatusCreated, toCatalogChangeResponse(change)); err != nil {
+		slog.Error("Failed to write JSON response", "error", err)
+		http.Error(w, "failed to write response", http.StatusInternalServerError)
+	}
+}
+
+// review decodes the optional comment of a workflow action and applies it through transition
+func (h *CatalogChangeHandler) review(w http.ResponseWriter, r *http.Request, action string, from []string,
+	apply func(tx *gorm.DB, change *models.CatalogChange, actor string) error) bool {
+	userInfo, ok := auth.GetUserInfo(r.Context())
+	if !ok {
+		http.Error(w, "user info not found in context", http.StatusUnauthorized)
+		return false
+	}
+
+	id, ok := changeIDParam(w, r)
+	if !ok {
+		return false
+	}
+
+	// The body is optional for workflow actions
+	var req dto.CatalogChangeActionRequest
+	if r.ContentLength != 0 {
+		limitRequestBody(w, r, 0) // 1MB default limit
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "invalid request body", http.StatusBadRequest)
+			return false
+		}
+		if !validateStruct(w, &req) {
+			return false
+		}
+	}
+
+	return h.transition(w, id, userInfo.Email, action, req.Comment, from,
+		func(tx *gorm.DB, change *models.CatalogChange) error {
+			return apply(tx, change, userInfo.Email)
+		})
+}
+
+// transition locks the change, checks it is in one of the from states, applies the mutation
+// and records the action in the audit trail, all in one transaction. It reports whether the
+// transition was committed.
+func (h *CatalogChangeHandler) transition(w http.ResponseWriter, id int64, actor, action string, comment *string, from []string,
+	apply func(tx *gorm.DB, change *models.CatalogChange) error) bool {
+	var change models.CatalogChange
+	err := catalogTransaction(h.db, func(tx *gorm.DB) error {
+		change = models.CatalogChange{}
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&change, id).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return &requestError{status: http.StatusNotFound, message: "catalog change not found"}
+			}
+			return err
+		}
+		if !slices.Contains(from, change.Status) {
+			return &requestError{status: http.StatusConflict, message: fmt.Sprintf("cannot %s a %s change", actionVerb(action), change.Status)}
+		}
+
+		if err := apply(tx, &change); err != nil {
+			return err
+		}
+		change.UpdatedBy = &actor
+		if err := tx.Save(&change).Error; err != nil {
+			return err
+		}
+
+		return tx.Create(&models.CatalogChangeEvent{
+			ChangeID: change.ID,
+			Action:   action,
+			Actor:    actor,
+			Comment:  comment,
+		}).Error
+	})
+
+	if err != nil {
+		writeUpsertError(w, err, "failed to update catalog change")
+		slog.Error("Failed to update catalog change", "error", err, "changeID", id, "action", action)
+		return false
+	}
+
+	if err := writeJSON(w, http.StatusOK, toCatalogChangeResponse(change)); err != nil {
+		slog.Error("Failed to write JSON response", "error", err)
+		http.Error(w, "failed to write response", http.StatusInternalServerError)
+	}
+	return true
+}
+
+func (h *CatalogChangeHandler) decodeMicroAppRequest(w http.ResponseWriter, r *http.Request) (*dto.CreateMicroAppRequest, bool) {
+	if !validateContentType(w, r) {
+		return nil, false
+	}
+
+	limitRequestBody(w, r, 0) // 1MB default limit
+	var req dto.CreateMicroAppRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid request body", http.StatusBadRequest)
+		return nil, false
+	}
+
+	if !validateStruct(w, &req) {
+		return nil, false
+	}
+	if !checkMicroAppRequest(w, h.db, &req, "failed to save catalog change") {
+		return nil, false
+	}
+	return &req, true
+}
+
+// createDraftChange stores req as a new draft, drafted against the given row version of the live
+// app, and records who created it and the files uploaded for it
+func createDraftChange(db *gorm.DB, req *dto.CreateMicroAppRequest, base *int64, userEmail string, replace bool, sourceSnapshotID *int64, comment *string, stagedFiles ...string) (models.CatalogChange, error) {
+	change := models.CatalogChange{
+		MicroAppID:       req.AppID,
+		Status:           models.CatalogChangeDraft,
+		Replace:          replace,
+		SourceSnapshotID: sourceSnapshotID,
+		BaseRowVersion:   base,
+		StagedFiles:      stagedFiles,
+		CreatedBy:        userEmail,
+	}
+	payload, err := json.Marshal(req)
+	if err != nil {
+		return change, err
+	}
+	change.Payload = payload
+
+	err = db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&change).Error; err != nil {
+			return err
+		}
+		return tx.Create(&models.CatalogChangeEvent{
+			ChangeID: change.ID,
+			Action:   models.CatalogChangeActionCreated,
+			Actor:    userEmail,
+			Comment:  comment,
+		}).Error
+	})
+	return change, err
+}
+
+// dropStagedVersions removes from the change the versions whose files were uploaded for it, and
+// returns the names of those files that no live version uses, after deleting their records.
+// Only the files recorded on the change are considered, never files the payload URLs name.
+func dropStagedVersions(tx *gorm.DB, change *models.CatalogChange) ([]string, error) {
+	if len(change.StagedFiles) == 0 {
+		return nil, nil
+	}
+	var req dto.CreateMicroAppRequest
+	if err := json.Unmarshal(change.Payload, &req); err != nil {
+		return nil, err
+	}
+
+	var live []models.MicroAppVersion
+	if err := tx.Select("download_url", "icon_url").Where("micro_app_id = ?", change.MicroAppID).
+		Find(&live).Error; err != nil {
+		return nil, err
+	}
+	inUse := func(fileName string) bool {
+		return slices.ContainsFunc(live, func(v models.MicroAppVersion) bool {
+			return fileNameFromURL(v.DownloadURL) == fileName || (v.IconURL != nil && fileNameFromURL(*v.IconURL) == fileName)
+		})
+	}
+
+	var stored []string
+	if err := tx.Model(&models.MicroAppStoredFile{}).
+		Where("micro_app_id = ? AND file_name IN ?", change.MicroAppID, []string(change.StagedFiles)).
+		Pluck("file_name", &stored).Error; err != nil {
+		return nil, err
+	}
+	staged := slices.DeleteFunc(stored, inUse)
+	if len(staged) > 0 {
+		if err := tx.Where("file_name IN ?", staged).Delete(&models.MicroAppStoredFile{}).Error; err != nil {
+			return nil, err
+		}
+	}
+
+	req.Versions = slices.DeleteFunc(req.Versions, func(v dto.CreateMicroAppVersionRequest) bool {
+		return slices.Contains(change.StagedFiles, fileNameFromURL(v.DownloadURL))
+	})
+	payload, err := json.Marshal(req)
+	if err != nil {
+		return nil, err
+	}
+	change.Payload = payload
+	change.StagedFiles = nil
+	return staged, nil
+}
+
+// liveRowVersion returns the row version of the live micro app, or 0 if it does not exist
+func liveRowVersion(db *gorm.DB, appID string) (*int64, error) {
+	var versions []int64
+	if err := db.Model(&models.MicroApp{}).Where("micro_app_id = ?", appID).
+		Pluck("version", &versions).Error; err != nil {
+		return nil, err
+	}
+	version := int64(0)
+	if len(versions) > 0 {
+		version = versions[0]
+	}
+	return &version, nil
+}
+
+// snapshotMicroApp captures the live state of a micro app in upsert request form
+func snapshotMicroApp(db *gorm.DB, appID string) (json.RawMessage, error) {
+	req, err := loadMicroAppRequest(db, appID)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(req)
+}
+
+// loadMicroAppRequest loads a micro app with its active relations as the upsert request that recreates it
+func loadMicroAppRequest(db *gorm.DB, appID string) (dto.CreateMicroAppRequest, error) {
+	var app models.MicroApp
+	if err := preloadEditableRelations(db.Where("micro_app_id = ?", appID)).First(&app).Error; err != nil {
+		return dto.CreateMicroAppRequest{}, err
+	}
+	return microAppToRequest(app), nil
+}
+
+// preloadEditableRelations preloads what microAppToRequest needs. Unlike catalog reads it keeps
+// versions outside their schedule window, since those are part of the app's definition.
+func preloadEditableRelations(query *gorm.DB) *gorm.DB {
+	return query.
+		Preload("Versions", "active = ?", models.StatusActive).
+		Preload("Roles", "active = ?", models.StatusActive).
+		Preload("Configs", "active = ?", models.StatusActive).
+		Preload("ConfigOverrides", "active = ?", models.StatusActive).
+		Preload("Tags")
+}
+
+// microAppToRequest converts a micro app with preloaded relations into the upsert request that recreates it
+func microAppToRequest(app models.MicroApp) dto.CreateMicroAppRequest {
+	req := dto.CreateMicroAppRequest{
+		AppID:           app.MicroAppID,
+		Name:            app.Name,
+		Description:     app.Description,
+		PromoText:       app.PromoText,
+		IconURL:         app.IconURL,
+		BannerImageURL:  app.BannerImageURL,
+		Mandatory:       app.Mandatory,
+		CategoryID:      app.CategoryID,
+		Tags:            []string{},
+		SortWeight:      app.SortWeight,
+		PublishAt:       app.PublishAt,
+		ExpireAt:        app.ExpireAt,
+		NotifyOnPublish: app.NotifyOnPublish,
+	}
+	for _, t := range app.Tags {
+		req.Tags = append(req.Tags, t.Tag)
+	}
+	for _, v := range app.Versions {
+		req.Versions = append(req.Versions, dto.CreateMicroAppVersionRequest{
+			Version:             v.Version,
+			Build:               v.Build,
+			ReleaseNotes:        v.ReleaseNotes,
+			IconURL:             v.IconURL,
+			DownloadURL:         v.DownloadURL,
+			RequiredPermissions: v.RequiredPermissions,
+			PublishAt:           v.PublishAt,
+			ExpireAt:            v.ExpireAt,
+			NotifyOnPublish:     v.NotifyOnPublish,
+		})
+	}
+	for _, r := range app.Roles {
+		req.Roles = append(req.Roles, dto.CreateMicroAppRoleRequest{Role: r.Role})
+	}
+	for _, c := range app.Configs {
+		configReq := dto.CreateMicroAppConfigRequest{
+			ConfigKey:   c.ConfigKey,
+			ConfigValue: c.ConfigValue,
+		}
+		for _, o := range app.ConfigOverrides {
+			if o.ConfigKey != c.ConfigKey {
+				continue
+			}
+			configReq.Overrides = append(configReq.Overrides, dto.CreateMicroAppConfigOverrideRequest{
+				ScopeType:   o.ScopeType,
+				ScopeValue:  o.ScopeValue,
+				ConfigValue: o.ConfigValue,
+				Priority:    o.Priority,
+			})
+		}
+		req.Configs = append(req.Configs, configReq)
+	}
+	return req
+}
+
+func toCatalogChangeResponse(c models.CatalogChange) dto.CatalogChangeResponse {
+	response := dto.CatalogChangeResponse{
+		ID:               c.ID,
+		AppID:            c.MicroAppID,
+		Status:           c.Status,
+		Replace:          c.Replace,
+		SourceSnapshotID: c.SourceSnapshotID,
+		BaseVersion:      c.BaseRowVersion,
+		CreatedBy:        c.CreatedBy,
+		UpdatedBy:        c.UpdatedBy,
+		ReviewedBy:       c.ReviewedBy,
+		ReviewedAt:       c.ReviewedAt,
+		PublishedBy:      c.PublishedBy,
+		PublishedAt:      c.PublishedAt,
+		CreatedAt:        c.CreatedAt,
+	}
+	if err := json.Unmarshal(c.Payload, &response.Change); err != nil {
+		slog.Error("Failed to decode catalog change payload", "error", err, "changeID", c.ID)
+	}
+	for _, e := range c.Events {
+		response.Events = append(response.Events, dto.CatalogChangeEventResponse{
+			Action:    e.Action,
+			Actor:     e.Actor,
+			Comment:   e.Comment,
+			CreatedAt: e.CreatedAt,
+		})
+	}
+	return response
+}
+
+func changeIDParam(w http.ResponseWriter, r *http.Request) (int64, bool) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "changeID"), 10, 64)
+	if err != nil {
+		http.Error(w, "invalid change ID", http.StatusBadRequest)
+		return 0, false
+	}
+	return id, true
+}
+
+// actionVerb turns an audit action back into the verb used in error messages
+func actionVerb(action string) string {
+	switch action {
+	case models.CatalogChangeActionUpdated:
+		return "update"
+	case models.CatalogChangeActionSubmitted:
+		return "submit"
+	case models.CatalogChangeActionApproved:
+		return "approve"
+	case models.CatalogChangeActionRejected:
+		return "reject"
+	case models.CatalogChangeActionPublished:
+		return "publish"
+	}
+	return action
+}
@@ -0,0 +1,106 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"go-backend/internal/auth"
+	"go-backend/internal/models"
+	"go-backend/internal/testdb"
+
+	"github.com/go-chi/chi/v5"
+	"gorm.io/gorm"
+)
+
+// changeAction runs a workflow action on a change as the given user and returns the recorded response
+func changeAction(action http.HandlerFunc, id int64, email string) *httptest.ResponseRecorder {
+	routeCtx := chi.NewRouteContext()
+	routeCtx.URLParams.Add("changeID", strconv.FormatInt(id, 10))
+	r := httptest.NewRequest(http.MethodPost, "/catalog-changes/"+strconv.FormatInt(id, 10), nil)
+	r = r.WithContext(context.WithValue(r.Context(), chi.RouteCtxKey, routeCtx))
+	r = auth.SetUserInfo(r, &auth.CustomJwtPayload{Email: email})
+
+	w := httptest.NewRecorder()
+	action(w, r)
+	return w
+}
+
+// createChange stores a change for the app in the given state, created by author
+func createChange(t *testing.T, db *gorm.DB, appID, status, author string, base *int64) models.CatalogChange {
+	t.Helper()
+	payload, err := json.Marshal(map[string]any{"appId": appID, "name": "Test app"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	change := models.CatalogChange{
+		MicroAppID:     appID,
+		Status:         status,
+		Payload:        payload,
+		BaseRowVersion: base,
+		CreatedBy:      author,
+	}
+	if err := db.Create(&change).Error; err != nil {
+		t.Fatalf("create change: %v", err)
+	}
+	if err := db.Create(&models.CatalogChangeEvent{
+		ChangeID: change.ID,
+		Action:   models.CatalogChangeActionCreated,
+		Actor:    author,
+	}).Error; err != nil {
+		t.Fatalf("create change event: %v", err)
+	}
+	return change
+}
+
+func reloadChange(t *testing.T, db *gorm.DB, id int64) models.CatalogChange {
+	t.Helper()
+	var change models.CatalogChange
+	if err := db.First(&change, id).Error; err != nil {
+		t.Fatalf("reload change: %v", err)
+	}
+	return change
+}
+
+func TestApproveByAuthor(t *testing.T) {
+	db := testdb.Open(t)
+	h := NewCatalogChangeHandler(db, nil)
+	change := createChange(t, db, "change-approve", models.CatalogChangeSubmitted, "author@example.com", nil)
+
+	if w := changeAction(h.Approve, change.ID, "author@example.com"); w.Code != http.StatusForbidden {
+		t.Fatalf("approve by the author = %d %s, want 403", w.Code, w.Body)
+	}
+	if got := reloadChange(t, db, change.ID).Status; got != models.CatalogChangeSubmitted {
+		t.Fatalf("status after a refused approval = %s, want submitted", got)
+	}
+
+	if w := changeAction(h.Approve, change.ID, "reviewer@example.com"); w.Code != http.StatusOK {
+		t.Fatalf("approve by another admin = %d %s, want 200", w.Code, w.Body)
+	}
+	if got := reloadChange(t, db, change.ID); got.Status != models.CatalogChangeApproved ||
+		got.ReviewedBy == nil || *got.ReviewedBy != "reviewer@example.com" {
+		t.Errorf("change = %s reviewed by %v, want approved by reviewer@example.com", got.Status, got.ReviewedBy)
+	}
+}
+
+func TestPublishStaleBase(t *testing.T) {
+	db := testdb.Open(t)
+	h := NewCatalogChangeHandler(db, nil)
+	app := models.MicroApp{MicroAppID: "change-stale", Name: "Test app", CreatedBy: "author@example.com", RowVersion: 2}
+	if err := db.Create(&app).Error; err != nil {
+		t.Fatalf("create app: %v", err)
+	}
+	// Drafted against version 1; the live app has been edited since
+	base := int64(1)
+	change := createChange(t, db, app.MicroAppID, models.CatalogChangeApproved, "author@example.com", &base)
+
+	if w := changeAction(h.Publish, change.ID, "reviewer@example.com"); w.Code != http.StatusConflict {
+		t.Fatalf("publish = %d %s, want 409", w.Code, w.Body)
+	}
+	if got := reloadChange(t, db, change.ID).Status; got != models.CatalogChangeApproved {
+		t.Errorf("status after a stale publish = %s, want approved", got)
+	}
+}
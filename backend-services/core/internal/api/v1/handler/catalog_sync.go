@@ -0,0 +1,159 @@
+package handler
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"go-backend/internal/api/v1/dto"
+	"go-backend/internal/models"
+)
+
+const (
+	// HTTP headers for conditional GETs and delta sync
+	headerETag          = "ETag"
+	headerIfNoneMatch   = "If-None-Match"
+	headerCatalogSync   = "X-Catalog-Sync-Token"
+	querySince          = "since"
+	syncTokenSeparator  = "."
+	catalogHashLength   = 16
+	syncTokenPartsCount = 2
+)
+
+// Computes a short hash of everything about the caller that changes what the catalog looks like to them.
+// It is part of the ETag and the sync token so that a change in the user's groups, channel or
+// environment forces a full refresh.
+func catalogContextHash(scope configScope) string {
+	return shortHash(scope.key())
+}
+
+// Builds the ETag of a catalog response from the catalog revision, the caller context and the query string.
+func catalogETag(revision int64, contextHash string, r *http.Request) string {
+	return fmt.Sprintf(`W/"%d-%s"`, revision, shortHash(contextHash+"?"+r.URL.RawQuery))
+}
+
+// Reports whether the request's If-None-Match header matches the given ETag
+func etagMatches(r *http.Request, etag string) bool {
+	header := r.Header.Get(headerIfNoneMatch)
+	if header == "" {
+		return false
+	}
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == strings.TrimPrefix(etag, "W/") {
+			return true
+		}
+	}
+	return false
+}
+
+func formatSyncToken(revision int64, contextHash string) string {
+	return strconv.FormatInt(revision, 10) + syncTokenSeparator + contextHash
+}
+
+// Parses a sync token returned by a previous catalog response into its revision and context hash
+func parseSyncToken(token string) (int64, string, error) {
+	parts := strings.SplitN(token, syncTokenSeparator, syncTokenPartsCount)
+	if len(parts) != syncTokenPartsCount || parts[1] == "" {
+		return 0, "", fmt.Errorf("malformed sync token")
+	}
+	revision, err := strconv.ParseInt(parts[0], 10, 64)
+	if err != nil || revision < 0 {
+		return 0, "", fmt.Errorf("malformed sync token")
+	}
+	return revision, parts[1], nil
+}
+
+func shortHash(value string) string {
+	sum := sha256.Sum256([]byte(value))
+	return hex.EncodeToString(sum[:])[:catalogHashLength]
+}
+
+// Writes the catalog changes since the given revision for the calling user.
+// Apps changed since then are returned in full if the user can still see them and listed as
+// removed otherwise, which covers deactivation, role changes and permanent deletes alike.
+// Removals are only reported for apps that have, or had, a role for one of the user's groups,
+// so clients never learn the IDs of apps they could not have held.
+// The user's library is always returned in full.
+func (h *MicroAppHandler) writeDelta(w http.ResponseWriter, scope configScope, since int64, full bool, revision int64, syncToken string, authorizedAppIDs []string, libraryRows []models.UserMicroApp) {
+	response := dto.CatalogDeltaResponse{
+		Revision:      revision,
+		SyncToken:     syncToken,
+		Full:          full,
+		Apps:          []dto.MicroAppResponse{},
+		RemovedAppIDs: []string{},
+	}
+
+	if full {
+		since = 0
+	}
+
+	var apps []models.MicroApp
+	if err := preloadActiveRelations(h.db.Where("revision > ?", since)).
+		Order("sort_weight DESC, name ASC").
+		Find(&apps).Error; err != nil {
+		slog.Error("Failed to fetch changed micro apps from database", "error", err, "since", since)
+		http.Error(w, "failed to fetch micro apps", http.StatusInternalServerError)
+		return
+	}
+
+	// Apps with a role for the user's groups in any state; role rows are deactivated, not
+	// deleted, so this includes apps the user has since lost access to
+	var heldAppIDs []string
+	if !full && len(scope.groups) > 0 {
+		if err := h.db.Model(&models.MicroAppRole{}).Distinct("micro_app_id").
+			Where("role IN ?", scope.groups).Pluck("micro_app_id", &heldAppIDs).Error; err != nil {
+			slog.Error("Failed to fetch micro app roles from database", "error", err, "groups", scope.groups)
+			http.Error(w, "failed to fetch micro apps", http.StatusInternalServerError)
+			return
+		}
+	}
+
+	now := time.Now()
+	visible := make(map[string]bool)
+	for _, app := range apps {
+		if app.Active == models.StatusActive && app.LiveAt(now) && slices.Contains(authorizedAppIDs, app.MicroAppID) {
+			visible[app.MicroAppID] = true
+			response.Apps = append(response.Apps, h.convertToResponseFromPreloaded(app, scope))
+		} else if !full && slices.Contains(heldAppIDs, app.MicroAppID) {
+			response.RemovedAppIDs = append(response.RemovedAppIDs, app.MicroAppID)
+		}
+	}
+
+	if !full {
+		var tombstones []models.CatalogTombstone
+		if err := h.db.Where("revision > ?", since).Find(&tombstones).Error; err != nil {
+			slog.Error("Failed to fetch catalog tombstones from database", "error", err, "since", since)
+			http.Error(w, "failed to fetch micro apps", http.StatusInternalServerError)
+			return
+		}
+		for _, t := range tombstones {
+			if visible[t.MicroAppID] || slices.Contains(response.RemovedAppIDs, t.MicroAppID) {
+				continue
+			}
+			if slices.ContainsFunc(t.Roles, func(role string) bool { return slices.Contains(scope.groups, role) }) {
+				response.RemovedAppIDs = append(response.RemovedAppIDs, t.MicroAppID)
+			}
+		}
+	}
+
+	library, err := buildLibrary(h.db, libraryRows, authorizedAppIDs)
+	if err != nil {
+		slog.Error("Failed to fetch micro app library", "error", err)
+		http.Error(w, "failed to fetch micro apps", http.StatusInternalServerError)
+		return
+	}
+	response.Library = library
+	attachLibrary(response.Apps, library)
+
+	if err := writeJSON(w, http.StatusOK, response); err != nil {
+		slog.Error("Failed to write JSON response", "error", err)
+		http.Error(w, "failed to write response", http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,66 @@
+package handler
+
+import (
+	"net/http/httptest"
+	"testing"
+)
+
+func TestSyncToken(t *testing.T) {
+	hash := catalogContextHash(configScope{groups: []string{"hr"}, channel: "beta"})
+	revision, gotHash, err := parseSyncToken(formatSyncToken(42, hash))
+	if err != nil || revision != 42 || gotHash != hash {
+		t.Fatalf("round trip = %d %q %v, want 42 %q", revision, gotHash, err, hash)
+	}
+
+	for _, token := range []string{"", "42", "42.", "abc." + hash, "-1." + hash} {
+		if _, _, err := parseSyncToken(token); err == nil {
+			t.Errorf("parseSyncToken(%q) accepted a malformed token", token)
+		}
+	}
+}
+
+func TestCatalogContextHash(t *testing.T) {
+	hr := catalogContextHash(configScope{groups: []string{"hr", "it"}})
+	if hr != catalogContextHash(configScope{groups: []string{"it", "hr"}}) {
+		t.Error("context hash depends on group order")
+	}
+	if hr == catalogContextHash(configScope{groups: []string{"hr"}}) {
+		t.Error("context hash ignores a group change")
+	}
+	if hr == catalogContextHash(configScope{groups: []string{"hr", "it"}, environment: "staging"}) {
+		t.Error("context hash ignores the environment")
+	}
+}
+
+func TestCatalogETag(t *testing.T) {
+	r := httptest.NewRequest("GET", "/micro-apps?category=hr", nil)
+	etag := catalogETag(7, "ctx", r)
+	if etag != catalogETag(7, "ctx", httptest.NewRequest("GET", "/micro-apps?category=hr", nil)) {
+		t.Error("ETag is not stable")
+	}
+	if etag == catalogETag(8, "ctx", r) || etag == catalogETag(7, "other", r) ||
+		etag == catalogETag(7, "ctx", httptest.NewRequest("GET", "/micro-apps?category=it", nil)) {
+		t.Error("ETag ignores the revision, the caller context or the query")
+	}
+
+	tests := []struct {
+		ifNoneMatch string
+		want        bool
+	}{
+		{"", false},
+		{etag, true},
+		{`"other", ` + etag, true},
+		{etag[2:], true}, // a strong form of the weak ETag still matches
+		{"*", true},
+		{`W/"1-abc"`, false},
+	}
+	for _, tt := range tests {
+		r := httptest.NewRequest("GET", "/micro-apps", nil)
+		if tt.ifNoneMatch != "" {
+			r.Header.Set(headerIfNoneMatch, tt.ifNoneMatch)
+		}
+		if got := etagMatches(r, etag); got != tt.want {
+			t.Errorf("If-None-Match %q matches = %v, want %v", tt.ifNoneMatch, got, tt.want)
+		}
+	}
+}
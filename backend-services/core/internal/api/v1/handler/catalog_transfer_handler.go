@@ -0,0 +1,498 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"mime"
+	"net/http"
+	"slices"
+	"strconv"
+	"time"
+
+	"go-backend/internal/api/v1/dto"
+	"go-backend/internal/auth"
+	"go-backend/internal/config"
+	"go-backend/internal/models"
+
+	"gopkg.in/yaml.v3"
+	"gorm.io/gorm"
+)
+
+const (
+	// Catalog export and import options
+	queryParamFormat           = "format"
+	queryParamDryRun           = "dryRun"
+	queryParamOverwriteRoles   = "overwriteRoles"
+	queryParamOverwriteConfigs = "overwriteConfigs"
+
+	formatJSON      = "json"
+	formatYAML      = "yaml"
+	contentTypeYAML = "application/yaml"
+
+	// Import outcomes
+	importActionCreate    = "create"
+	importActionUpdate    = "update"
+	importActionUnchanged = "unchanged"
+
+	maxCatalogImportSize = 10 << 20 // 10MB
+)
+
+type CatalogTransferHandler struct {
+	db  *gorm.DB
+	cfg *config.Config
+}
+
+func NewCatalogTransferHandler(db *gorm.DB, cfg *config.Config) *CatalogTransferHandler {
+	return &CatalogTransferHandler{db: db, cfg: cfg}
+}
+
+// Export handles exporting the active micro apps with their versions, roles, tags and configs as
+// a catalog document. Query parameters: format=json|yaml (default json) and appId (repeatable)
+// to export only some apps.
+func (h *CatalogTransferHandler) Export(w http.ResponseWriter, r *http.Request) {
+	format := r.URL.Query().Get(queryParamFormat)
+	if format == "" {
+		format = formatJSON
+	}
+	if format != formatJSON && format != formatYAML {
+		http.Error(w, "format must be json or yaml", http.StatusBadRequest)
+		return
+	}
+
+	query := h.db.Where("active = ?", models.StatusActive)
+	if appIDs := r.URL.Query()[queryParamAppID]; len(appIDs) > 0 {
+		query = query.Where("micro_app_id IN ?", appIDs)
+	}
+	var apps []models.MicroApp
+	if err := preloadEditableRelations(query).Order("micro_app_id ASC").Find(&apps).Error; err != nil {
+		slog.Error("Failed to fetch micro apps for export", "error", err)
+		http.Error(w, "failed to export catalog", http.StatusInternalServerError)
+		return
+	}
+
+	now := time.Now().UTC()
+	doc := dto.CatalogDocument{ExportedAt: &now, Apps: make([]dto.CreateMicroAppRequest, 0, len(apps))}
+	for _, app := range apps {
+		doc.Apps = append(doc.Apps, microAppToRequest(app))
+	}
+
+	write := writeJSON
+	if format == formatYAML {
+		write = writeYAML
+	}
+	if err := write(w, http.StatusOK, doc); err != nil {
+		slog.Error("Failed to write export response", "error", err)
+		http.Error(w, "failed to write response", http.StatusInternalServerError)
+	}
+}
+
+// Import handles applying a catalog document (JSON or YAML, by Content-Type) in one transaction.
+// Apps and versions in the document are created or updated; nothing is deleted. Roles and configs
+// of existing apps are left alone unless overwriteRoles / overwriteConfigs is set, in which case
+// they are replaced by the document's. With dryRun=true only the differences are reported.
+func (h *CatalogTransferHandler) Import(w http.ResponseWriter, r *http.Request) {
+	userInfo, ok := auth.GetUserInfo(r.Context())
+	if !ok {
+		http.Error(w, "user info not found in context", http.StatusUnauthorized)
+		return
+	}
+
+	var opts catalogImportOptions
+	for param, target := range map[string]*bool{
+		queryParamDryRun:           &opts.dryRun,
+		queryParamOverwriteRoles:   &opts.overwriteRoles,
+		queryParamOverwriteConfigs: &opts.overwriteConfigs,
+	} {
+		if value := r.URL.Query().Get(param); value != "" {
+			parsed, err := strconv.ParseBool(value)
+			if err != nil {
+				http.Error(w, fmt.Sprintf("invalid %s value", param), http.StatusBadRequest)
+				return
+			}
+			*target = parsed
+		}
+	}
+
+	if !opts.dryRun && h.cfg.CatalogReviewRequired {
+		http.Error(w, catalogReviewRequiredMessage, http.StatusForbidden)
+		return
+	}
+
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	format := formatJSON
+	switch mediaType {
+	case contentTypeJSON:
+	case contentTypeYAML, "application/x-yaml", "text/yaml":
+		format = formatYAML
+	default:
+		http.Error(w, "Content-Type must be application/json or application/yaml", http.StatusUnsupportedMediaType)
+		return
+	}
+
+	limitRequestBody(w, r, maxCatalogImportSize)
+	doc, err := decodeCatalogDocument(r.Body, format)
+	if err != nil {
+		http.Error(w, "invalid catalog document: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if !validateStruct(w, doc) {
+		return
+	}
+
+	seen := make(map[string]bool, len(doc.Apps))
+	for i := range doc.Apps {
+		app := &doc.Apps[i]
+		if seen[app.AppID] {
+			http.Error(w, fmt.Sprintf("micro app %s is listed more than once", app.AppID), http.StatusBadRequest)
+			return
+		}
+		seen[app.AppID] = true
+
+		// The document is the full definition of the app's tags
+		if app.Tags == nil {
+			app.Tags = []string{}
+		}
+
+		reqErr, err := validateMicroAppRequest(h.db, app)
+		if err != nil {
+			slog.Error("Failed to validate micro app request", "error", err, "appID", app.AppID)
+			http.Error(w, "failed to import catalog", http.StatusInternalServerError)
+			return
+		}
+		if reqErr != nil {
+			reqErr.message = app.AppID + ": " + reqErr.message
+			writeRequestError(w, reqErr)
+			return
+		}
+	}
+
+	response := dto.CatalogImportResponse{DryRun: opts.dryRun}
+	if opts.dryRun {
+		plans, err := planCatalogImport(h.db, doc.Apps, opts)
+		if err != nil {
+			slog.Error("Failed to plan catalog import", "error", err)
+			http.Error(w, "failed to import catalog", http.StatusInternalServerError)
+			return
+		}
+		response.Apps = importResults(plans)
+	} else {
+		err = catalogTransaction(h.db, func(tx *gorm.DB) error {
+			plans, err := planCatalogImport(tx, doc.Apps, opts)
+			if err != nil {
+				return err
+			}
+			if err := applyCatalogImport(tx, plans, userInfo.Email); err != nil {
+				return err
+			}
+			response.Apps = importResults(plans)
+			return nil
+		})
+		if err != nil {
+			writeUpsertError(w, err, "failed to import catalog")
+			slog.Error("Failed to import catalog", "error", err, "email", userInfo.Email)
+			return
+		}
+		slog.Info("Catalog imported", "apps", len(doc.Apps), "email", userInfo.Email)
+	}
+
+	if err := writeJSON(w, http.StatusOK, response); err != nil {
+		slog.Error("Failed to write JSON response", "error", err)
+		http.Error(w, "failed to write response", http.StatusInternalServerError)
+	}
+}
+
+type catalogImportOptions struct {
+	dryRun           bool
+	overwriteRoles   bool
+	overwriteConfigs bool
+}
+
+// catalogImportPlan is what importing one app does: req is the upsert to run, and the prune
+// flags drop the app's current roles or configs first so only the document's remain
+type catalogImportPlan struct {
+	result       dto.CatalogImportAppResult
+	req          dto.CreateMicroAppRequest
+	pruneRoles   bool
+	pruneConfigs bool
+}
+
+func planCatalogImport(db *gorm.DB, apps []dto.CreateMicroAppRequest, opts catalogImportOptions) ([]catalogImportPlan, error) {
+	plans := make([]catalogImportPlan, 0, len(apps))
+	for _, app := range apps {
+		plan := catalogImportPlan{
+			result: dto.CatalogImportAppResult{AppID: app.AppID},
+			req:    app,
+		}
+
+		var existing models.MicroApp
+		err := preloadEditableRelations(db.Where("micro_app_id = ?", app.AppID)).First(&existing).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			plan.result.Action = importActionCreate
+			plans = append(plans, plan)
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		// Keep the target environment's roles and configs unless they are to be overwritten
+		if !opts.overwriteRoles {
+			plan.req.Roles = nil
+		}
+		if !opts.overwriteConfigs {
+			plan.req.Configs = nil
+		}
+		plan.pruneRoles = opts.overwriteRoles
+		plan.pruneConfigs = opts.overwriteConfigs
+
+		current := microAppToRequest(existing)
+		changes := diffMicroAppRequests(&current, &app, opts)
+		if existing.Active != models.StatusActive {
+			changes = append([]string{"reactivated"}, changes...)
+		}
+		plan.result.Changes = changes
+		plan.result.Action = importActionUnchanged
+		if len(changes) > 0 {
+			plan.result.Action = importActionUpdate
+		}
+		plans = append(plans, plan)
+	}
+	return plans, nil
+}
+
+func applyCatalogImport(tx *gorm.DB, plans []catalogImportPlan, userEmail string) error {
+	for i := range plans {
+		plan := &plans[i]
+		if plan.result.Action == importActionUnchanged {
+			continue
+		}
+
+		var children []any
+		if plan.pruneRoles {
+			children = append(children, &models.MicroAppRole{})
+		}
+		if plan.pruneConfigs {
+			children = append(children, &models.MicroAppConfig{}, &models.MicroAppConfigOverride{})
+		}
+		for _, child := range children {
+			if err := tx.Model(child).
+				Where("micro_app_id = ? AND active = ?", plan.req.AppID, models.StatusActive).
+				Updates(map[string]any{"active": models.StatusInactive, "updated_by": userEmail}).Error; err != nil {
+				return err
+			}
+		}
+
+		if err := upsertMicroApp(tx, &plan.req, userEmail, false); err != nil {
+			var reqErr *requestError
+			if errors.As(err, &reqErr) {
+				return &requestError{status: reqErr.status, message: fmt.Sprintf("micro app %s: %s", plan.req.AppID, reqErr.message)}
+			}
+			return fmt.Errorf("micro app %s: %w", plan.req.AppID, err)
+		}
+	}
+	return nil
+}
+
+func importResults(plans []catalogImportPlan) []dto.CatalogImportAppResult {
+	results := make([]dto.CatalogImportAppResult, 0, len(plans))
+	for _, p := range plans {
+		results = append(results, p.result)
+	}
+	return results
+}
+
+// diffMicroAppRequests describes how the target definition differs from the current one.
+// Roles and configs are only compared when the import overwrites them.
+func diffMicroAppRequests(current, target *dto.CreateMicroAppRequest, opts catalogImportOptions) []string {
+	var changes []string
+	field := func(name string, same bool) {
+		if !same {
+			changes = append(changes, name+" changed")
+		}
+	}
+
+	field("name", current.Name == target.Name)
+	field("description", samePtr(current.Description, target.Description))
+	field("promoText", samePtr(current.PromoText, target.PromoText))
+	field("iconUrl", samePtr(current.IconURL, target.IconURL))
+	field("bannerImageUrl", samePtr(current.BannerImageURL, target.BannerImageURL))
+	field("mandatory", current.Mandatory == target.Mandatory)
+	field("categoryId", samePtr(current.CategoryID, target.CategoryID))
+	field("sortWeight", current.SortWeight == target.SortWeight)
+	field("tags", sameSet(current.Tags, target.Tags))
+	field("publishAt", sameTime(current.PublishAt, target.PublishAt))
+	field("expireAt", sameTime(current.ExpireAt, target.ExpireAt))
+	field("notifyOnPublish", current.NotifyOnPublish == target.NotifyOnPublish)
+
+	// Versions are only ever added or updated
+	currentVersions := make(map[string]dto.CreateMicroAppVersionRequest, len(current.Versions))
+	for _, v := range current.Versions {
+		currentVersions[versionLabel(v)] = v
+	}
+	for _, v := range target.Versions {
+		existing, ok := currentVersions[versionLabel(v)]
+		switch {
+		case !ok:
+			changes = append(changes, "version "+versionLabel(v)+" added")
+		case !sameVersion(existing, v):
+			changes = append(changes, "version "+versionLabel(v)+" changed")
+		}
+	}
+
+	if opts.overwriteRoles {
+		var currentRoles, targetRoles []string
+		for _, role := range current.Roles {
+			currentRoles = append(currentRoles, role.Role)
+		}
+		for _, role := range target.Roles {
+			targetRoles = append(targetRoles, role.Role)
+		}
+		for _, role := range targetRoles {
+			if !slices.Contains(currentRoles, role) {
+				changes = append(changes, "role "+role+" added")
+			}
+		}
+		for _, role := range currentRoles {
+			if !slices.Contains(targetRoles, role) {
+				changes = append(changes, "role "+role+" removed")
+			}
+		}
+	}
+
+	if opts.overwriteConfigs {
+		currentConfigs := make(map[string]dto.CreateMicroAppConfigRequest, len(current.Configs))
+		for _, c := range current.Configs {
+			currentConfigs[c.ConfigKey] = c
+		}
+		targetKeys := make(map[string]bool, len(target.Configs))
+		for _, c := range target.Configs {
+			targetKeys[c.ConfigKey] = true
+			existing, ok := currentConfigs[c.ConfigKey]
+			switch {
+			case !ok:
+				changes = append(changes, "config "+c.ConfigKey+" added")
+			case !sameConfig(existing, c):
+				changes = append(changes, "config "+c.ConfigKey+" changed")
+			}
+		}
+		for _, c := range current.Configs {
+			if !targetKeys[c.ConfigKey] {
+				changes = append(changes, "config "+c.ConfigKey+" removed")
+			}
+		}
+	}
+
+	return changes
+}
+
+func versionLabel(v dto.CreateMicroAppVersionRequest) string {
+	return fmt.Sprintf("%s (build %d)", v.Version, v.Build)
+}
+
+func sameVersion(a, b dto.CreateMicroAppVersionRequest) bool {
+	return samePtr(a.ReleaseNotes, b.ReleaseNotes) &&
+		samePtr(a.IconURL, b.IconURL) &&
+		a.DownloadURL == b.DownloadURL &&
+		sameSet(a.RequiredPermissions, b.RequiredPermissions) &&
+		sameTime(a.PublishAt, b.PublishAt) &&
+		sameTime(a.ExpireAt, b.ExpireAt) &&
+		a.NotifyOnPublish == b.NotifyOnPublish
+}
+
+func sameConfig(a, b dto.CreateMicroAppConfigRequest) bool {
+	if !sameJSON(a.ConfigValue, b.ConfigValue) || len(a.Overrides) != len(b.Overrides) {
+		return false
+	}
+	for _, o := range b.Overrides {
+		i := slices.IndexFunc(a.Overrides, func(e dto.CreateMicroAppConfigOverrideRequest) bool {
+			return e.ScopeType == o.ScopeType && e.ScopeValue == o.ScopeValue
+		})
+		if i < 0 || a.Overrides[i].Priority != o.Priority || !sameJSON(a.Overrides[i].ConfigValue, o.ConfigValue) {
+			return false
+		}
+	}
+	return true
+}
+
+func samePtr[T comparable](a, b *T) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
+
+func sameTime(a, b *time.Time) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	// The database keeps whole seconds
+	return a.Truncate(time.Second).Equal(b.Truncate(time.Second))
+}
+
+func sameSet(a, b []string) bool {
+	a, b = slices.Clone(a), slices.Clone(b)
+	slices.Sort(a)
+	slices.Sort(b)
+	return slices.Equal(slices.Compact(a), slices.Compact(b))
+}
+
+// sameJSON compares JSON values regardless of formatting and key order
+func sameJSON(a, b json.RawMessage) bool {
+	var va, vb any
+	if json.Unmarshal(a, &va) != nil || json.Unmarshal(b, &vb) != nil {
+		return bytes.Equal(a, b)
+	}
+	ca, _ := json.Marshal(va)
+	cb, _ := json.Marshal(vb)
+	return bytes.Equal(ca, cb)
+}
+
+// decodeCatalogDocument reads a JSON or YAML catalog document. YAML is converted through JSON
+// so both formats use the same field names.
+func decodeCatalogDocument(body io.Reader, format string) (*dto.CatalogDocument, error) {
+	raw, err := io.ReadAll(body)
+	if err != nil {
+		return nil, err
+	}
+	if format == formatYAML {
+		var generic any
+		if err := yaml.Unmarshal(raw, &generic); err != nil {
+			return nil, err
+		}
+		if raw, err = json.Marshal(generic); err != nil {
+			return nil, err
+		}
+	}
+
+	var doc dto.CatalogDocument
+	if err := json.Unmarshal(raw, &doc); err != nil {
+		return nil, err
+	}
+	return &doc, nil
+}
+
+// Writes the given data as YAML, using the same field names as its JSON form.
+func writeYAML(w http.ResponseWriter, status int, data any) error {
+	raw, err := json.Marshal(data)
+	if err != nil {
+		return fmt.Errorf("failed to encode YAML: %w", err)
+	}
+	var generic any
+	if err := json.Unmarshal(raw, &generic); err != nil {
+		return fmt.Errorf("failed to encode YAML: %w", err)
+	}
+	out, err := yaml.Marshal(generic)
+	if err != nil {
+		return fmt.Errorf("failed to encode YAML: %w", err)
+	}
+
+	w.Header().Set("Content-Type", contentTypeYAML)
+	w.WriteHeader(status)
+	_, err = w.Write(out)
+	return err
+}
@@ -0,0 +1,96 @@
+package handler
+
+import (
+	"encoding/json"
+	"slices"
+	"strings"
+	"testing"
+
+	"go-backend/internal/api/v1/dto"
+)
+
+func transferApp() dto.CreateMicroAppRequest {
+	return dto.CreateMicroAppRequest{
+		AppID: "leave",
+		Name:  "Leave",
+		Tags:  []string{"hr", "time"},
+		Versions: []dto.CreateMicroAppVersionRequest{
+			{Version: "1.0", Build: 1, DownloadURL: "https://files/leave-1.zip"},
+		},
+		Roles: []dto.CreateMicroAppRoleRequest{{Role: "hr"}},
+		Configs: []dto.CreateMicroAppConfigRequest{
+			{ConfigKey: "theme", ConfigValue: json.RawMessage(`{"color":"blue","dark":false}`)},
+		},
+	}
+}
+
+func TestDiffMicroAppRequests(t *testing.T) {
+	current := transferApp()
+
+	same := transferApp()
+	same.Tags = []string{"time", "hr"}
+	same.Configs[0].ConfigValue = json.RawMessage(`{ "dark": false, "color": "blue" }`)
+	if changes := diffMicroAppRequests(&current, &same, catalogImportOptions{overwriteRoles: true, overwriteConfigs: true}); len(changes) != 0 {
+		t.Errorf("changes = %v, want none for reordered tags and reformatted JSON", changes)
+	}
+
+	target := transferApp()
+	target.Name = "Leave requests"
+	target.Versions = append(target.Versions, dto.CreateMicroAppVersionRequest{Version: "1.1", Build: 2, DownloadURL: "https://files/leave-2.zip"})
+	target.Roles = []dto.CreateMicroAppRoleRequest{{Role: "managers"}}
+	target.Configs = []dto.CreateMicroAppConfigRequest{{ConfigKey: "limit", ConfigValue: json.RawMessage(`10`)}}
+
+	// Roles and configs are kept unless the import overwrites them
+	changes := diffMicroAppRequests(&current, &target, catalogImportOptions{})
+	if want := []string{"name changed", "version 1.1 (build 2) added"}; !slices.Equal(changes, want) {
+		t.Errorf("changes = %v, want %v", changes, want)
+	}
+
+	changes = diffMicroAppRequests(&current, &target, catalogImportOptions{overwriteRoles: true, overwriteConfigs: true})
+	want := []string{
+		"name changed", "version 1.1 (build 2) added",
+		"role managers added", "role hr removed",
+		"config limit added", "config theme removed",
+	}
+	if !slices.Equal(changes, want) {
+		t.Errorf("changes = %v, want %v", changes, want)
+	}
+}
+
+func TestDecodeCatalogDocument(t *testing.T) {
+	yamlDoc := `
+apps:
+  - appId: leave
+    name: Leave
+    versions:
+      - version: "1.0"
+        build: 1
+        downloadUrl: https://files/leave-1.zip
+    configs:
+      - configKey: theme
+        configValue:
+          color: blue
+`
+	jsonDoc := `{"apps":[{"appId":"leave","name":"Leave",` +
+		`"versions":[{"version":"1.0","build":1,"downloadUrl":"https://files/leave-1.zip"}],` +
+		`"configs":[{"configKey":"theme","configValue":{"color":"blue"}}]}]}`
+
+	fromYAML, err := decodeCatalogDocument(strings.NewReader(yamlDoc), formatYAML)
+	if err != nil {
+		t.Fatalf("decode YAML: %v", err)
+	}
+	fromJSON, err := decodeCatalogDocument(strings.NewReader(jsonDoc), formatJSON)
+	if err != nil {
+		t.Fatalf("decode JSON: %v", err)
+	}
+	if len(fromYAML.Apps) != 1 || len(fromJSON.Apps) != 1 {
+		t.Fatalf("got %d and %d apps, want 1 each", len(fromYAML.Apps), len(fromJSON.Apps))
+	}
+	if changes := diffMicroAppRequests(&fromJSON.Apps[0], &fromYAML.Apps[0], catalogImportOptions{overwriteConfigs: true}); len(changes) != 0 {
+		t.Errorf("YAML and JSON documents differ: %v", changes)
+	}
+
+	if _, err := decodeCatalogDocument(strings.NewReader("apps: [unclosed"), formatYAML); err == nil {
+		t.Error("decoded malformed YAML")
+	}
+}
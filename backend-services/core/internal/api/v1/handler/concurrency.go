@@ -0,0 +1,129 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"go-backend/internal/models"
+
+	"github.com/go-sql-driver/mysql"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	headerIfMatch = "If-Match"
+
+	// MySQL error number of a transaction rolled back to break a deadlock
+	mysqlErrDeadlock = 1213
+	// Attempts of a catalog write transaction that keeps losing deadlocks
+	maxDeadlockAttempts = 3
+)
+
+// versionConflictError reports that a row changed since the client read it. Current is the
+// row's version now, or 0 if it no longer exists.
+type versionConflictError struct {
+	current int64
+}
+
+func (e *versionConflictError) Error() string {
+	return "the resource was modified by someone else; reload it and retry"
+}
+
+// rowETag formats a row version as a strong ETag
+func rowETag(version int64) string {
+	return `"` + strconv.FormatInt(version, 10) + `"`
+}
+
+// ifMatchVersion parses the If-Match header of a write. It returns nil when the header is absent,
+// in which case the write is unconditional.
+func ifMatchVersion(r *http.Request) (*int64, error) {
+	header := strings.TrimSpace(r.Header.Get(headerIfMatch))
+	if header == "" {
+		return nil, nil
+	}
+	version, err := strconv.ParseInt(strings.Trim(header, `"`), 10, 64)
+	if err != nil || version < 1 {
+		return nil, fmt.Errorf("If-Match must be an ETag returned by this API")
+	}
+	return &version, nil
+}
+
+// readIfMatch is ifMatchVersion for handlers; it writes the error response on a malformed header
+func readIfMatch(w http.ResponseWriter, r *http.Request) (*int64, bool) {
+	expected, err := ifMatchVersion(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return nil, false
+	}
+	return expected, true
+}
+
+// lockMicroApp locks the micro app row for the rest of the transaction, which serialises
+// concurrent writes to the app and its children, and checks it against the If-Match version.
+// It returns nil if the app does not exist and no version was expected.
+func lockMicroApp(tx *gorm.DB, appID string, expected *int64) (*models.MicroApp, error) {
+	var app models.MicroApp
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("micro_app_id = ?", appID).First(&app).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		if expected != nil {
+			return nil, &versionConflictError{}
+		}
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if expected != nil && app.RowVersion != *expected {
+		return nil, &versionConflictError{current: app.RowVersion}
+	}
+	return &app, nil
+}
+
+// catalogTransaction runs fn in a transaction, retrying it when MySQL rolls it back to break a
+// deadlock. Locking an app row that does not exist yet takes a gap lock, so concurrent creates
+// of the same app deadlock on insert; the retry then finds the row the winner created. A write
+// that still deadlocks after maxDeadlockAttempts fails with a *versionConflictError.
+func catalogTransaction(db *gorm.DB, fn func(tx *gorm.DB) error) error {
+	var err error
+	for range maxDeadlockAttempts {
+		err = db.Transaction(fn)
+		if !isDeadlock(err) {
+			return err
+		}
+	}
+	return &versionConflictError{}
+}
+
+// isDeadlock reports whether err is MySQL rolling back the transaction to break a deadlock
+func isDeadlock(err error) bool {
+	var mysqlErr *mysql.MySQLError
+	return errors.As(err, &mysqlErr) && mysqlErr.Number == mysqlErrDeadlock
+}
+
+// writeVersionConflict answers a write whose If-Match no longer matches, with the current ETag
+// so the client can reload
+func writeVersionConflict(w http.ResponseWriter, e *versionConflictError) {
+	if e.current > 0 {
+		w.Header().Set(headerETag, rowETag(e.current))
+	}
+	http.Error(w, e.Error(), http.StatusConflict)
+}
+
+// writeUpsertError answers a failed catalog write: version conflicts and request errors raised
+// inside the transaction go back to the client, anything else is a server error
+func writeUpsertError(w http.ResponseWriter, err error, failMessage string) {
+	var conflict *versionConflictError
+	var reqErr *requestError
+	switch {
+	case errors.As(err, &conflict):
+		writeVersionConflict(w, conflict)
+	case errors.As(err, &reqErr):
+		writeRequestError(w, reqErr)
+	default:
+		http.Error(w, failMessage, http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,66 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"go-backend/internal/testdb"
+
+	"github.com/go-sql-driver/mysql"
+	"gorm.io/gorm"
+)
+
+func TestIsDeadlock(t *testing.T) {
+	deadlock := &mysql.MySQLError{Number: mysqlErrDeadlock}
+	if !isDeadlock(fmt.Errorf("create version: %w", deadlock)) {
+		t.Error("wrapped deadlock not recognised")
+	}
+	if isDeadlock(&mysql.MySQLError{Number: 1062}) || isDeadlock(errors.New("deadlock")) || isDeadlock(nil) {
+		t.Error("other errors taken for a deadlock")
+	}
+}
+
+func TestCatalogTransactionRetriesDeadlocks(t *testing.T) {
+	db := testdb.Open(t)
+
+	// A transaction that loses one deadlock is retried and succeeds
+	calls := 0
+	err := catalogTransaction(db, func(tx *gorm.DB) error {
+		calls++
+		if calls == 1 {
+			return &mysql.MySQLError{Number: mysqlErrDeadlock}
+		}
+		return nil
+	})
+	if err != nil || calls != 2 {
+		t.Fatalf("err = %v after %d calls, want success on the second", err, calls)
+	}
+
+	// One that keeps deadlocking gives up as a conflict the client can retry
+	calls = 0
+	err = catalogTransaction(db, func(tx *gorm.DB) error {
+		calls++
+		return &mysql.MySQLError{Number: mysqlErrDeadlock}
+	})
+	var conflict *versionConflictError
+	if !errors.As(err, &conflict) || calls != maxDeadlockAttempts {
+		t.Fatalf("err = %v after %d calls, want a version conflict after %d", err, calls, maxDeadlockAttempts)
+	}
+	w := httptest.NewRecorder()
+	writeUpsertError(w, err, "failed")
+	if w.Code != http.StatusConflict {
+		t.Errorf("status = %d, want 409", w.Code)
+	}
+
+	// Other errors are not retried
+	calls = 0
+	if err := catalogTransaction(db, func(tx *gorm.DB) error {
+		calls++
+		return errors.New("boom")
+	}); err == nil || calls != 1 {
+		t.Errorf("err = %v after %d calls, want the error after one", err, calls)
+	}
+}
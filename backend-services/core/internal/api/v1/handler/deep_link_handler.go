@@ -0,0 +1,314 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strconv"
+	"time"
+
+	"go-backend/internal/api/v1/dto"
+	"go-backend/internal/auth"
+	"go-backend/internal/deeplink"
+	"go-backend/internal/models"
+
+	"github.com/go-chi/chi/v5"
+	"gorm.io/gorm"
+)
+
+const queryParamURL = "url"
+
+// MicroAppHandler to handle listing the deep link patterns registered for a micro app
+func (h *MicroAppHandler) GetDeepLinks(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "appID")
+	if id == "" {
+		http.Error(w, "missing micro_app_id", http.StatusBadRequest)
+		return
+	}
+
+	var links []models.MicroAppDeepLink
+	if err := h.db.Where("micro_app_id = ? AND active = ?", id, models.StatusActive).
+		Order("pattern ASC").
+		Find(&links).Error; err != nil {
+		slog.Error("Failed to fetch deep links", "error", err, "appID", id)
+		http.Error(w, "failed to fetch deep links", http.StatusInternalServerError)
+		return
+	}
+
+	response := make([]dto.MicroAppDeepLinkResponse, 0, len(links))
+	for _, l := range links {
+		response = append(response, toDeepLinkResponse(l))
+	}
+
+	if err := writeJSON(w, http.StatusOK, response); err != nil {
+		slog.Error("Failed to write JSON response", "error", err)
+		http.Error(w, "failed to write response", http.StatusInternalServerError)
+	}
+}
+
+// MicroAppHandler to handle registering a deep link pattern for a micro app. A pattern belongs to
+// one micro app at a time; registering one that is active for another app is a conflict.
+func (h *MicroAppHandler) UpsertDeepLink(w http.ResponseWriter, r *http.Request) {
+	userInfo, ok := auth.GetUserInfo(r.Context())
+	if !ok {
+		http.Error(w, "user info not found in context", http.StatusUnauthorized)
+		return
+	}
+	userEmail := userInfo.Email
+
+	id := chi.URLParam(r, "appID")
+	if id == "" {
+		http.Error(w, "missing micro_app_id", http.StatusBadRequest)
+		return
+	}
+
+	if !validateContentType(w, r) {
+		return
+	}
+
+	limitRequestBody(w, r, 0) // 1MB default limit
+	var req dto.UpsertMicroAppDeepLinkRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if !validateStruct(w, &req) {
+		return
+	}
+
+	pattern, err := deeplink.Parse(req.Pattern)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if req.TargetPath != nil {
+		if err := pattern.ValidateTarget(*req.TargetPath); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+
+	var app models.MicroApp
+	if err := h.db.Where("micro_app_id = ?", id).First(&app).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			http.Error(w, "micro app not found", http.StatusNotFound)
+			return
+		}
+		slog.Error("Failed to fetch micro app", "error", err, "appID", id)
+		http.Error(w, "failed to upsert deep link", http.StatusInternalServerError)
+		return
+	}
+
+	var link models.MicroAppDeepLink
+	err = h.db.Where("pattern = ?", req.Pattern).First(&link).Error
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		slog.Error("Failed to fetch deep link", "error", err, "pattern", req.Pattern)
+		http.Error(w, "failed to upsert deep link", http.StatusInternalServerError)
+		return
+	}
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		link = models.MicroAppDeepLink{
+			MicroAppID: id,
+			Pattern:    req.Pattern,
+			TargetPath: req.TargetPath,
+			Active:     models.StatusActive,
+			CreatedBy:  userEmail,
+		}
+		err = h.db.Create(&link).Error
+	case link.Active == models.StatusActive && link.MicroAppID != id:
+		http.Error(w, "pattern is already registered by micro app "+link.MicroAppID, http.StatusConflict)
+		return
+	default:
+		link.MicroAppID = id
+		link.TargetPath = req.TargetPath
+		link.Active = models.StatusActive
+		link.UpdatedBy = &userEmail
+		err = h.db.Save(&link).Error
+	}
+	if err != nil {
+		slog.Error("Failed to upsert deep link", "error", err, "appID", id, "pattern", req.Pattern)
+		http.Error(w, "failed to upsert deep link", http.StatusInternalServerError)
+		return
+	}
+
+	if err := writeJSON(w, http.StatusCreated, toDeepLinkResponse(link)); err != nil {
+		slog.Error("Failed to write JSON response", "error", err)
+		http.Error(w, "failed to write response", http.StatusInternalServerError)
+	}
+}
+
+// MicroAppHandler to handle removing a deep link pattern from a micro app
+func (h *MicroAppHandler) DeactivateDeepLink(w http.ResponseWriter, r *http.Request) {
+	userInfo, ok := auth.GetUserInfo(r.Context())
+	if !ok {
+		http.Error(w, "user info not found in context", http.StatusUnauthorized)
+		return
+	}
+
+	id := chi.URLParam(r, "appID")
+	linkID, err := strconv.Atoi(chi.URLParam(r, "linkID"))
+	if id == "" || err != nil {
+		http.Error(w, "missing micro_app_id or invalid deep link ID", http.StatusBadRequest)
+		return
+	}
+
+	result := h.db.Model(&models.MicroAppDeepLink{}).
+		Where("id = ? AND micro_app_id = ? AND active = ?", linkID, id, models.StatusActive).
+		Updates(map[string]any{
+			"active":     models.StatusInactive,
+			"updated_by": userInfo.Email,
+		})
+	if result.Error != nil {
+		slog.Error("Failed to deactivate deep link", "error", result.Error, "appID", id, "linkID", linkID)
+		http.Error(w, "failed to deactivate deep link", http.StatusInternalServerError)
+		return
+	}
+	if result.RowsAffected == 0 {
+		http.Error(w, "deep link not found", http.StatusNotFound)
+		return
+	}
+
+	if err := writeJSON(w, http.StatusOK, map[string]string{"message": "Deep link deactivated successfully"}); err != nil {
+		slog.Error("Failed to write JSON response", "error", err)
+		http.Error(w, "failed to write response", http.StatusInternalServerError)
+	}
+}
+
+type DeepLinkHandler struct {
+	db *gorm.DB
+}
+
+func NewDeepLinkHandler(db *gorm.DB) *DeepLinkHandler {
+	return &DeepLinkHandler{db: db}
+}
+
+// Resolve handles resolving a link to the micro app, version and in-app path it opens for the
+// logged-in user. Links to micro apps the user's groups have no access to are forbidden.
+func (h *DeepLinkHandler) Resolve(w http.ResponseWriter, r *http.Request) {
+	userInfo, ok := auth.GetUserInfo(r.Context())
+	if !ok {
+		http.Error(w, "user info not found in context", http.StatusUnauthorized)
+		return
+	}
+
+	link := r.URL.Query().Get(queryParamURL)
+	if link == "" {
+		http.Error(w, "missing url", http.StatusBadRequest)
+		return
+	}
+
+	match, err := resolveDeepLink(h.db, link)
+	if err != nil {
+		if errors.Is(err, errInvalidLink) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		slog.Error("Failed to resolve deep link", "error", err, "url", link)
+		http.Error(w, "failed to resolve deep link", http.StatusInternalServerError)
+		return
+	}
+	if match == nil {
+		http.Error(w, "no micro app handles this link", http.StatusNotFound)
+		return
+	}
+
+	authorizedAppIDs, err := authorizedMicroAppIDs(h.db, userInfo.Groups)
+	if err != nil {
+		slog.Error("Failed to get authorized app IDs", "error", err, "groups", userInfo.Groups)
+		http.Error(w, "failed to resolve deep link", http.StatusInternalServerError)
+		return
+	}
+	if !slices.Contains(authorizedAppIDs, match.AppID) {
+		slog.Warn("User not authorized to open deep link", "appID", match.AppID, "email", userInfo.Email)
+		http.Error(w, "forbidden", http.StatusForbidden)
+		return
+	}
+
+	response := dto.DeepLinkResolutionResponse{AppID: match.AppID, Path: match.Path, Params: match.Params}
+	var version models.MicroAppVersion
+	now := time.Now()
+	err = h.db.Where("micro_app_id = ? AND active = ?", match.AppID, models.StatusActive).
+		Where(models.LiveCondition, now, now).
+		Order("build DESC").
+		First(&version).Error
+	switch {
+	case err == nil:
+		response.Version = &version.Version
+		response.Build = &version.Build
+	case !errors.Is(err, gorm.ErrRecordNotFound):
+		slog.Error("Failed to fetch micro app version", "error", err, "appID", match.AppID)
+		http.Error(w, "failed to resolve deep link", http.StatusInternalServerError)
+		return
+	}
+
+	if err := writeJSON(w, http.StatusOK, response); err != nil {
+		slog.Error("Failed to write JSON response", "error", err)
+		http.Error(w, "failed to write response", http.StatusInternalServerError)
+	}
+}
+
+var errInvalidLink = errors.New("invalid link")
+
+// deepLinkMatch is the micro app screen a link resolves to
+type deepLinkMatch struct {
+	AppID  string
+	Path   string
+	Params map[string]string
+}
+
+// resolveDeepLink finds the most specific active pattern of a live micro app matching the link.
+// It returns nil when no pattern matches. Access checks are left to the caller.
+func resolveDeepLink(db *gorm.DB, link string) (*deepLinkMatch, error) {
+	path, rawQuery, err := deeplink.SplitLink(link)
+	if err != nil {
+		return nil, errors.Join(errInvalidLink, err)
+	}
+
+	var links []models.MicroAppDeepLink
+	now := time.Now()
+	if err := db.Where("active = ? AND micro_app_id IN (?)", models.StatusActive,
+		db.Model(&models.MicroApp{}).Select("micro_app_id").
+			Where("active = ?", models.StatusActive).
+			Where(models.LiveCondition, now, now)).
+		Order("id ASC").
+		Find(&links).Error; err != nil {
+		return nil, err
+	}
+
+	var best *deepLinkMatch
+	bestSpecificity := -1
+	for _, l := range links {
+		pattern, err := deeplink.Parse(l.Pattern)
+		if err != nil {
+			slog.Warn("Skipping invalid deep link pattern", "error", err, "id", l.ID)
+			continue
+		}
+		params, ok := pattern.Match(path)
+		if !ok || pattern.Specificity() <= bestSpecificity {
+			continue
+		}
+		target := path
+		if l.TargetPath != nil {
+			target = deeplink.Expand(*l.TargetPath, params)
+		}
+		if rawQuery != "" {
+			target += "?" + rawQuery
+		}
+		best = &deepLinkMatch{AppID: l.MicroAppID, Path: target, Params: params}
+		bestSpecificity = pattern.Specificity()
+	}
+	return best, nil
+}
+
+func toDeepLinkResponse(l models.MicroAppDeepLink) dto.MicroAppDeepLinkResponse {
+	return dto.MicroAppDeepLinkResponse{
+		ID:         l.ID,
+		AppID:      l.MicroAppID,
+		Pattern:    l.Pattern,
+		TargetPath: l.TargetPath,
+	}
+}
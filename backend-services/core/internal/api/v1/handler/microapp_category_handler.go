@@ -0,0 +1,160 @@
+package handler
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+
+	"go-backend/internal/api/v1/dto"
+	"go-backend/internal/auth"
+	"go-backend/internal/catalog"
+	"go-backend/internal/models"
+
+	"github.com/go-chi/chi/v5"
+	"gorm.io/gorm"
+)
+
+type MicroAppCategoryHandler struct {
+	db *gorm.DB
+}
+
+func NewMicroAppCategoryHandler(db *gorm.DB) *MicroAppCategoryHandler {
+	return &MicroAppCategoryHandler{db: db}
+}
+
+// GetAll handles fetching all active categories in display order
+func (h *MicroAppCategoryHandler) GetAll(w http.ResponseWriter, r *http.Request) {
+	var categories []models.MicroAppCategory
+	if err := h.db.Where("active = ?", models.StatusActive).
+		Order("display_order ASC, name ASC").
+		Find(&categories).Error; err != nil {
+		slog.Error("Failed to fetch categories from database", "error", err)
+		http.Error(w, "failed to fetch categories", http.StatusInternalServerError)
+		return
+	}
+
+	response := make([]dto.MicroAppCategoryResponse, 0, len(categories))
+	for _, c := range categories {
+		response = append(response, toCategoryResponse(c))
+	}
+
+	if err := writeJSON(w, http.StatusOK, response); err != nil {
+		slog.Error("Failed to write JSON response", "error", err)
+		http.Error(w, "failed to write response", http.StatusInternalServerError)
+	}
+}
+
+// Upsert handles creating or updating a category
+func (h *MicroAppCategoryHandler) Upsert(w http.ResponseWriter, r *http.Request) {
+	userInfo, ok := auth.GetUserInfo(r.Context())
+	if !ok {
+		http.Error(w, "user info not found in context", http.StatusUnauthorized)
+		return
+	}
+	userEmail := userInfo.Email
+
+	if !validateContentType(w, r) {
+		return
+	}
+
+	limitRequestBody(w, r, 0) // 1MB default limit
+	var req dto.UpsertMicroAppCategoryRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if !validateStruct(w, &req) {
+		return
+	}
+
+	// Categories shape the grouped catalog, so changes bump the catalog revision
+	category := models.MicroAppCategory{}
+	err := h.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Where("category_id = ?", req.CategoryID).
+			Assign(map[string]any{
+				"name":          req.Name,
+				"description":   req.Description,
+				"display_order": req.DisplayOrder,
+				"active":        models.StatusActive,
+				"updated_by":    userEmail,
+			}).
+			Attrs(models.MicroAppCategory{
+				CategoryID: req.CategoryID,
+				CreatedBy:  userEmail,
+			}).FirstOrCreate(&category)
+		if result.Error != nil {
+			return result.Error
+		}
+
+		_, err := catalog.Touch(tx)
+		return err
+	})
+
+	if err != nil {
+		slog.Error("Failed to upsert category", "error", err, "categoryID", req.CategoryID)
+		http.Error(w, "failed to upsert category", http.StatusInternalServerError)
+		return
+	}
+
+	if err := writeJSON(w, http.StatusCreated, toCategoryResponse(category)); err != nil {
+		slog.Error("Failed to write JSON response", "error", err)
+		http.Error(w, "failed to write response", http.StatusInternalServerError)
+	}
+}
+
+// Deactivate handles deactivating a category. Micro apps in it are shown as uncategorised.
+func (h *MicroAppCategoryHandler) Deactivate(w http.ResponseWriter, r *http.Request) {
+	userInfo, ok := auth.GetUserInfo(r.Context())
+	if !ok {
+		http.Error(w, "user info not found in context", http.StatusUnauthorized)
+		return
+	}
+
+	id := chi.URLParam(r, "categoryID")
+	if id == "" {
+		http.Error(w, "missing category_id", http.StatusBadRequest)
+		return
+	}
+
+	var rowsAffected int64
+	err := h.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&models.MicroAppCategory{}).Where("category_id = ?", id).Updates(map[string]any{
+			"active":     models.StatusInactive,
+			"updated_by": userInfo.Email,
+		})
+		if result.Error != nil {
+			return result.Error
+		}
+		rowsAffected = result.RowsAffected
+
+		_, err := catalog.Touch(tx)
+		return err
+	})
+	if err != nil {
+		slog.Error("Failed to deactivate category", "error", err, "categoryID", id)
+		http.Error(w, "failed to deactivate category", http.StatusInternalServerError)
+		return
+	}
+	if rowsAffected == 0 {
+		http.Error(w, "category not found", http.StatusNotFound)
+		return
+	}
+
+	if err := writeJSON(w, http.StatusOK, map[string]string{"message": "Category deactivated successfully"}); err != nil {
+		slog.Error("Failed to write JSON response", "error", err)
+		http.Error(w, "failed to write response", http.StatusInternalServerError)
+	}
+}
+
+// Helper functions
+
+func toCategoryResponse(c models.MicroAppCategory) dto.MicroAppCategoryResponse {
+	return dto.MicroAppCategoryResponse{
+		CategoryID:   c.CategoryID,
+		Name:         c.Name,
+		Description:  c.Description,
+		DisplayOrder: c.DisplayOrder,
+		Active:       c.Active,
+	}
+}
@@ -0,0 +1,246 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"go-backend/internal/api/v1/dto"
+	"go-backend/internal/auth"
+	"go-backend/internal/configschema"
+	"go-backend/internal/models"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/santhosh-tekuri/jsonschema/v6"
+	"gorm.io/gorm"
+)
+
+// MicroAppHandler to handle listing the config schemas registered for a micro app
+func (h *MicroAppHandler) GetConfigSchemas(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "appID")
+	if id == "" {
+		http.Error(w, "missing micro_app_id", http.StatusBadRequest)
+		return
+	}
+
+	var schemas []models.MicroAppConfigSchema
+	if err := h.db.Where("micro_app_id = ? AND active = ?", id, models.StatusActive).
+		Order("config_key ASC").
+		Find(&schemas).Error; err != nil {
+		slog.Error("Failed to fetch config schemas", "error", err, "appID", id)
+		http.Error(w, "failed to fetch config schemas", http.StatusInternalServerError)
+		return
+	}
+
+	response := make([]dto.MicroAppConfigSchemaResponse, 0, len(schemas))
+	for _, s := range schemas {
+		response = append(response, dto.MicroAppConfigSchemaResponse{
+			ConfigKey: s.ConfigKey,
+			Schema:    s.Schema,
+		})
+	}
+
+	if err := writeJSON(w, http.StatusOK, response); err != nil {
+		slog.Error("Failed to write JSON response", "error", err)
+		http.Error(w, "failed to write response", http.StatusInternalServerError)
+	}
+}
+
+// MicroAppHandler to handle registering the JSON Schema of a config key. The schema is
+// rejected if the key's current values (including overrides) do not satisfy it.
+func (h *MicroAppHandler) UpsertConfigSchema(w http.ResponseWriter, r *http.Request) {
+	userInfo, ok := auth.GetUserInfo(r.Context())
+	if !ok {
+		http.Error(w, "user info not found in context", http.StatusUnauthorized)
+		return
+	}
+	userEmail := userInfo.Email
+
+	id := chi.URLParam(r, "appID")
+	configKey := chi.URLParam(r, "configKey")
+	if id == "" || configKey == "" {
+		http.Error(w, "missing micro_app_id or config key", http.StatusBadRequest)
+		return
+	}
+
+	if !validateContentType(w, r) {
+		return
+	}
+
+	limitRequestBody(w, r, 0) // 1MB default limit
+	var req dto.UpsertMicroAppConfigSchemaRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if !validateStruct(w, &req) {
+		return
+	}
+
+	compiled, err := configschema.Compile(req.Schema)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	var app models.MicroApp
+	if err := h.db.Where("micro_app_id = ?", id).First(&app).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			http.Error(w, "micro app not found", http.StatusNotFound)
+			return
+		}
+		slog.Error("Failed to fetch micro app", "error", err, "appID", id)
+		http.Error(w, "failed to upsert config schema", http.StatusInternalServerError)
+		return
+	}
+
+	// Existing values must keep passing, otherwise the catalog would serve values the schema forbids
+	var configs []models.MicroAppConfig
+	if err := h.db.Where("micro_app_id = ? AND config_key = ? AND active = ?", id, configKey, models.StatusActive).
+		Find(&configs).Error; err != nil {
+		slog.Error("Failed to fetch configs", "error", err, "appID", id, "configKey", configKey)
+		http.Error(w, "failed to upsert config schema", http.StatusInternalServerError)
+		return
+	}
+	var overrides []models.MicroAppConfigOverride
+	if err := h.db.Where("micro_app_id = ? AND config_key = ? AND active = ?", id, configKey, models.StatusActive).
+		Find(&overrides).Error; err != nil {
+		slog.Error("Failed to fetch config overrides", "error", err, "appID", id, "configKey", configKey)
+		http.Error(w, "failed to upsert config schema", http.StatusInternalServerError)
+		return
+	}
+
+	var fieldErrors []dto.ConfigFieldErrorResponse
+	for _, c := range configs {
+		fieldErrors = appendConfigFieldErrors(fieldErrors, compiled, c.ConfigKey, "", c.ConfigValue)
+	}
+	for _, o := range overrides {
+		fieldErrors = appendConfigFieldErrors(fieldErrors, compiled, o.ConfigKey, o.ScopeType+":"+o.ScopeValue, o.ConfigValue)
+	}
+	if len(fieldErrors) > 0 {
+		writeConfigValidationErrors(w, http.StatusConflict, "existing config values do not match the schema", fieldErrors)
+		return
+	}
+
+	schema := models.MicroAppConfigSchema{}
+	result := h.db.Where("micro_app_id = ? AND config_key = ?", id, configKey).
+		Assign(map[string]any{
+			"schema":     req.Schema,
+			"active":     models.StatusActive,
+			"updated_by": userEmail,
+		}).
+		Attrs(models.MicroAppConfigSchema{
+			MicroAppID: id,
+			ConfigKey:  configKey,
+			CreatedBy:  userEmail,
+		}).FirstOrCreate(&schema)
+	if result.Error != nil {
+		slog.Error("Failed to upsert config schema", "error", result.Error, "appID", id, "configKey", configKey)
+		http.Error(w, "failed to upsert config schema", http.StatusInternalServerError)
+		return
+	}
+
+	response := dto.MicroAppConfigSchemaResponse{ConfigKey: configKey, Schema: req.Schema}
+	if err := writeJSON(w, http.StatusCreated, response); err != nil {
+		slog.Error("Failed to write JSON response", "error", err)
+		http.Error(w, "failed to write response", http.StatusInternalServerError)
+	}
+}
+
+// MicroAppHandler to handle removing the schema of a config key; its values are no longer validated
+func (h *MicroAppHandler) DeactivateConfigSchema(w http.ResponseWriter, r *http.Request) {
+	userInfo, ok := auth.GetUserInfo(r.Context())
+	if !ok {
+		http.Error(w, "user info not found in context", http.StatusUnauthorized)
+		return
+	}
+
+	id := chi.URLParam(r, "appID")
+	configKey := chi.URLParam(r, "configKey")
+	if id == "" || configKey == "" {
+		http.Error(w, "missing micro_app_id or config key", http.StatusBadRequest)
+		return
+	}
+
+	result := h.db.Model(&models.MicroAppConfigSchema{}).
+		Where("micro_app_id = ? AND config_key = ? AND active = ?", id, configKey, models.StatusActive).
+		Updates(map[string]any{
+			"active":     models.StatusInactive,
+			"updated_by": userInfo.Email,
+		})
+	if result.Error != nil {
+		slog.Error("Failed to deactivate config schema", "error", result.Error, "appID", id, "configKey", configKey)
+		http.Error(w, "failed to deactivate config schema", http.StatusInternalServerError)
+		return
+	}
+	if result.RowsAffected == 0 {
+		http.Error(w, "config schema not found", http.StatusNotFound)
+		return
+	}
+
+	if err := writeJSON(w, http.StatusOK, map[string]string{"message": "Config schema deactivated successfully"}); err != nil {
+		slog.Error("Failed to write JSON response", "error", err)
+		http.Error(w, "failed to write response", http.StatusInternalServerError)
+	}
+}
+
+// validateConfigRequests checks the requested config values and overrides against the
+// app's registered schemas. Keys without a schema are accepted as-is.
+func validateConfigRequests(db *gorm.DB, appID string, configs []dto.CreateMicroAppConfigRequest) ([]dto.ConfigFieldErrorResponse, error) {
+	if len(configs) == 0 {
+		return nil, nil
+	}
+
+	var schemas []models.MicroAppConfigSchema
+	if err := db.Where("micro_app_id = ? AND active = ?", appID, models.StatusActive).Find(&schemas).Error; err != nil {
+		return nil, err
+	}
+	compiled := make(map[string]*jsonschema.Schema, len(schemas))
+	for _, s := range schemas {
+		c, err := configschema.Compile(s.Schema)
+		if err != nil {
+			return nil, err
+		}
+		compiled[s.ConfigKey] = c
+	}
+
+	var fieldErrors []dto.ConfigFieldErrorResponse
+	for _, configReq := range configs {
+		schema, ok := compiled[configReq.ConfigKey]
+		if !ok {
+			continue
+		}
+		fieldErrors = appendConfigFieldErrors(fieldErrors, schema, configReq.ConfigKey, "", configReq.ConfigValue)
+		for _, overrideReq := range configReq.Overrides {
+			fieldErrors = appendConfigFieldErrors(fieldErrors, schema, configReq.ConfigKey,
+				overrideReq.ScopeType+":"+overrideReq.ScopeValue, overrideReq.ConfigValue)
+		}
+	}
+	return fieldErrors, nil
+}
+
+func appendConfigFieldErrors(dst []dto.ConfigFieldErrorResponse, schema *jsonschema.Schema, configKey, scope string, value json.RawMessage) []dto.ConfigFieldErrorResponse {
+	field := configKey
+	if scope != "" {
+		field += "[" + scope + "]"
+	}
+	for _, fe := range configschema.Validate(schema, value) {
+		dst = append(dst, dto.ConfigFieldErrorResponse{
+			Field:   field + fe.Pointer,
+			Message: fe.Message,
+		})
+	}
+	return dst
+}
+
+func writeConfigValidationErrors(w http.ResponseWriter, status int, message string, fieldErrors []dto.ConfigFieldErrorResponse) {
+	if err := writeJSON(w, status, dto.ConfigValidationErrorResponse{
+		Message: message,
+		Errors:  fieldErrors,
+	}); err != nil {
+		slog.Error("Failed to write JSON response", "error", err)
+		http.Error(w, "failed to write response", http.StatusInternalServerError)
+	}
+}
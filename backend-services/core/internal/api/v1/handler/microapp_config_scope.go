@@ -0,0 +1,122 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"slices"
+	"sort"
+	"strings"
+
+	"go-backend/internal/api/v1/dto"
+	"go-backend/internal/auth"
+	"go-backend/internal/models"
+)
+
+const (
+	// Headers the host app uses to identify its release channel and environment
+	headerAppChannel     = "X-App-Channel"
+	headerAppEnvironment = "X-App-Environment"
+
+	configScopeDefault = "default"
+)
+
+// Specificity of each override scope; the most specific matching override wins
+var configScopeRank = map[string]int{
+	models.ConfigScopeEnvironment: 1,
+	models.ConfigScopeChannel:     2,
+	models.ConfigScopeGroup:       3,
+}
+
+// configScope describes the calling user for config override resolution
+type configScope struct {
+	groups      []string
+	channel     string
+	environment string
+}
+
+// Builds the config scope of a request from the user's groups and the channel and environment headers.
+// The environment header is only honoured for users in one of the APP_ENVIRONMENT_GROUPS; everyone
+// else gets the server's APP_ENVIRONMENT.
+func (h *MicroAppHandler) configScopeFor(r *http.Request, userInfo *auth.CustomJwtPayload) configScope {
+	return configScope{
+		groups:      userInfo.Groups,
+		channel:     r.Header.Get(headerAppChannel),
+		environment: requestEnvironment(r, userInfo, h.cfg.AppEnvironment, h.cfg.AppEnvironmentGroups),
+	}
+}
+
+// Returns the environment a request asked for if the user may choose one, else the fallback
+func requestEnvironment(r *http.Request, userInfo *auth.CustomJwtPayload, fallback string, allowedGroups []string) string {
+	environment := r.Header.Get(headerAppEnvironment)
+	if environment == "" || !slices.ContainsFunc(userInfo.Groups, func(g string) bool {
+		return slices.Contains(allowedGroups, g)
+	}) {
+		return fallback
+	}
+	return environment
+}
+
+// Reports whether an override applies to the scope
+func (s configScope) matches(o models.MicroAppConfigOverride) bool {
+	switch o.ScopeType {
+	case models.ConfigScopeGroup:
+		return slices.Contains(s.groups, o.ScopeValue)
+	case models.ConfigScopeChannel:
+		return s.channel != "" && s.channel == o.ScopeValue
+	case models.ConfigScopeEnvironment:
+		return s.environment != "" && s.environment == o.ScopeValue
+	}
+	return false
+}
+
+// Identifies everything in the scope that changes the resolved configs, for use in cache keys
+func (s configScope) key() string {
+	groups := slices.Clone(s.groups)
+	slices.Sort(groups)
+	return strings.Join(groups, "\n") + "\x00" + s.channel + "\x00" + s.environment
+}
+
+// Resolves the config values of a micro app for the scope. Each active config key gets its
+// base value unless an override matches, in which case the most specific scope wins, then the
+// highest priority, then the lowest scope value so the result is deterministic.
+func resolveConfigs(configs []models.MicroAppConfig, overrides []models.MicroAppConfigOverride, scope configScope) []dto.MicroAppConfigResponse {
+	best := make(map[string]models.MicroAppConfigOverride)
+	for _, o := range overrides {
+		if !scope.matches(o) {
+			continue
+		}
+		current, exists := best[o.ConfigKey]
+		if !exists || overrideBeats(o, current) {
+			best[o.ConfigKey] = o
+		}
+	}
+
+	var responses []dto.MicroAppConfigResponse
+	for _, c := range configs {
+		response := dto.MicroAppConfigResponse{
+			ConfigKey:   c.ConfigKey,
+			ConfigValue: json.RawMessage(c.ConfigValue),
+			Scope:       configScopeDefault,
+		}
+		if o, exists := best[c.ConfigKey]; exists {
+			response.ConfigValue = json.RawMessage(o.ConfigValue)
+			response.Scope = o.ScopeType + ":" + o.ScopeValue
+		}
+		responses = append(responses, response)
+	}
+
+	sort.SliceStable(responses, func(i, j int) bool {
+		return responses[i].ConfigKey < responses[j].ConfigKey
+	})
+	return responses
+}
+
+func overrideBeats(a, b models.MicroAppConfigOverride) bool {
+	if configScopeRank[a.ScopeType] != configScopeRank[b.ScopeType] {
+		return configScopeRank[a.ScopeType] > configScopeRank[b.ScopeType]
+	}
+	if a.Priority != b.Priority {
+		return a.Priority > b.Priority
+	}
+	return a.ScopeValue < b.ScopeValue
+}
@@ -0,0 +1,101 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"testing"
+
+	"go-backend/internal/auth"
+	"go-backend/internal/models"
+)
+
+func TestResolveConfigs(t *testing.T) {
+	configs := []models.MicroAppConfig{
+		{ConfigKey: "theme", ConfigValue: json.RawMessage(`"light"`)},
+		{ConfigKey: "apiUrl", ConfigValue: json.RawMessage(`"https://api"`)},
+		{ConfigKey: "limit", ConfigValue: json.RawMessage(`10`)},
+		{ConfigKey: "banner", ConfigValue: json.RawMessage(`false`)},
+	}
+	overrides := []models.MicroAppConfigOverride{
+		// Environment and channel overrides lose to a matching group override
+		{ConfigKey: "theme", ScopeType: models.ConfigScopeEnvironment, ScopeValue: "staging", ConfigValue: json.RawMessage(`"env"`)},
+		{ConfigKey: "theme", ScopeType: models.ConfigScopeChannel, ScopeValue: "beta", ConfigValue: json.RawMessage(`"channel"`)},
+		{ConfigKey: "theme", ScopeType: models.ConfigScopeGroup, ScopeValue: "admins", ConfigValue: json.RawMessage(`"group"`)},
+		// Same scope: the higher priority wins
+		{ConfigKey: "limit", ScopeType: models.ConfigScopeGroup, ScopeValue: "admins", Priority: 1, ConfigValue: json.RawMessage(`20`)},
+		{ConfigKey: "limit", ScopeType: models.ConfigScopeGroup, ScopeValue: "hr", Priority: 5, ConfigValue: json.RawMessage(`50`)},
+		// Same scope and priority: the lowest scope value wins
+		{ConfigKey: "apiUrl", ScopeType: models.ConfigScopeGroup, ScopeValue: "hr", ConfigValue: json.RawMessage(`"https://hr"`)},
+		{ConfigKey: "apiUrl", ScopeType: models.ConfigScopeGroup, ScopeValue: "admins", ConfigValue: json.RawMessage(`"https://admins"`)},
+		// Overrides for other scopes never apply
+		{ConfigKey: "banner", ScopeType: models.ConfigScopeGroup, ScopeValue: "finance", ConfigValue: json.RawMessage(`true`)},
+		{ConfigKey: "banner", ScopeType: models.ConfigScopeChannel, ScopeValue: "stable", ConfigValue: json.RawMessage(`true`)},
+	}
+	scope := configScope{groups: []string{"hr", "admins"}, channel: "beta", environment: "staging"}
+
+	got := resolveConfigs(configs, overrides, scope)
+	want := []struct{ key, value, scope string }{
+		{"apiUrl", `"https://admins"`, "group:admins"},
+		{"banner", `false`, configScopeDefault},
+		{"limit", `50`, "group:hr"},
+		{"theme", `"group"`, "group:admins"},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("got %d configs, want %d", len(got), len(want))
+	}
+	for i, w := range want {
+		if got[i].ConfigKey != w.key || string(got[i].ConfigValue) != w.value || got[i].Scope != w.scope {
+			t.Errorf("config %d = %s %s %s, want %s %s %s", i,
+				got[i].ConfigKey, got[i].ConfigValue, got[i].Scope, w.key, w.value, w.scope)
+		}
+	}
+}
+
+func TestResolveConfigsWithoutScope(t *testing.T) {
+	configs := []models.MicroAppConfig{{ConfigKey: "theme", ConfigValue: json.RawMessage(`"light"`)}}
+	overrides := []models.MicroAppConfigOverride{
+		{ConfigKey: "theme", ScopeType: models.ConfigScopeChannel, ScopeValue: "", ConfigValue: json.RawMessage(`"dark"`)},
+		{ConfigKey: "theme", ScopeType: models.ConfigScopeEnvironment, ScopeValue: "", ConfigValue: json.RawMessage(`"dark"`)},
+	}
+
+	// A request without channel or environment headers must not match overrides for empty values
+	got := resolveConfigs(configs, overrides, configScope{})
+	if len(got) != 1 || string(got[0].ConfigValue) != `"light"` || got[0].Scope != configScopeDefault {
+		t.Errorf("got %+v, want the default value", got)
+	}
+}
+
+func TestConfigScopeKey(t *testing.T) {
+	a := configScope{groups: []string{"b", "a"}, channel: "beta"}
+	b := configScope{groups: []string{"a", "b"}, channel: "beta"}
+	if a.key() != b.key() {
+		t.Error("scope key depends on group order")
+	}
+	if a.key() == (configScope{groups: []string{"a", "b"}, environment: "beta"}).key() {
+		t.Error("scope key does not tell channel from environment")
+	}
+}
+
+func TestRequestEnvironment(t *testing.T) {
+	allowed := []string{"testers"}
+	tests := []struct {
+		name   string
+		header string
+		groups []string
+		want   string
+	}{
+		{"tester picks an environment", "staging", []string{"hr", "testers"}, "staging"},
+		{"other users get the server environment", "staging", []string{"hr"}, "production"},
+		{"no header", "", []string{"testers"}, "production"},
+	}
+	for _, tt := range tests {
+		r := httptest.NewRequest("GET", "/micro-apps", nil)
+		if tt.header != "" {
+			r.Header.Set(headerAppEnvironment, tt.header)
+		}
+		got := requestEnvironment(r, &auth.CustomJwtPayload{Groups: tt.groups}, "production", allowed)
+		if got != tt.want {
+			t.Errorf("%s: environment = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
@@ -2,24 +2,49 @@ package handler
 
 import (
 	"encoding/json"
+	"errors"
 	"log/slog"
 	"net/http"
+	"net/url"
+	"path"
 	"slices"
+	"time"
 
 	"go-backend/internal/api/v1/dto"
 	"go-backend/internal/auth"
+	"go-backend/internal/catalog"
+	"go-backend/internal/config"
 	"go-backend/internal/models"
 
+	fileservice "go-backend/plugins/file-service"
+
 	"github.com/go-chi/chi/v5"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	// Query parameter that must repeat the micro app ID to confirm a permanent delete
+	queryParamConfirm = "confirm"
+
+	// Catalog filtering and grouping
+	queryParamCategory = "category"
+	queryParamTag      = "tag"
+	queryParamGroupBy  = "groupBy"
+	groupByCategory    = "category"
+
+	// How often the recorded groups of a user whose groups did not change are stamped again
+	userGroupsRefreshInterval = 24 * time.Hour
 )
 
 type MicroAppHandler struct {
-	db *gorm.DB
+	db          *gorm.DB
+	cfg         *config.Config
+	fileService fileservice.FileService
 }
 
-func NewMicroAppHandler(db *gorm.DB) *MicroAppHandler {
-	return &MicroAppHandler{db: db}
+func NewMicroAppHandler(db *gorm.DB, cfg *config.Config, fileService fileservice.FileService) *MicroAppHandler {
+	return &MicroAppHandler{db: db, cfg: cfg, fileService: fileService}
 }
 
 // MicroAppHandler to handle fetching all micro apps
@@ -31,6 +56,33 @@ func (h *MicroAppHandler) GetAll(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Remember the user's groups so background jobs can find who an app is offered to
+	recordUserGroups(h.db, userInfo.Email, userInfo.Groups)
+
+	// Answer conditional requests before touching the catalog tables
+	revision, err := catalog.CurrentRevision(h.db)
+	if err != nil {
+		slog.Error("Failed to fetch catalog revision", "error", err)
+		http.Error(w, "failed to fetch micro apps", http.StatusInternalServerError)
+		return
+	}
+	libraryRows, err := loadLibraryRows(h.db, userInfo.Email)
+	if err != nil {
+		slog.Error("Failed to fetch micro app library", "error", err, "email", userInfo.Email)
+		http.Error(w, "failed to fetch micro apps", http.StatusInternalServerError)
+		return
+	}
+	scope := h.configScopeFor(r, userInfo)
+	contextHash := catalogContextHash(scope)
+	etag := catalogETag(revision, contextHash+libraryStamp(libraryRows), r)
+	syncToken := formatSyncToken(revision, contextHash)
+	w.Header().Set(headerETag, etag)
+	w.Header().Set(headerCatalogSync, syncToken)
+	if etagMatches(r, etag) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
 	// Get app IDs the user has access to based on their groups
 	authorizedAppIDs, err := h.getMicroAppIDsByGroups(userInfo.Groups)
 	if err != nil {
@@ -39,6 +91,18 @@ func (h *MicroAppHandler) GetAll(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Delta mode: only what changed since the client's last sync
+	if token := r.URL.Query().Get(querySince); token != "" {
+		since, sinceContextHash, err := parseSyncToken(token)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		full := sinceContextHash != contextHash || since > revision
+		h.writeDelta(w, scope, since, full, revision, syncToken, authorizedAppIDs, libraryRows)
+		return
+	}
+
 	if len(authorizedAppIDs) == 0 {
 		if err := writeJSON(w, http.StatusOK, []dto.MicroAppResponse{}); err != nil {
 			slog.Error("Failed to write JSON response", "error", err)
@@ -49,11 +113,19 @@ func (h *MicroAppHandler) GetAll(w http.ResponseWriter, r *http.Request) {
 
 	var apps []models.MicroApp
 
-	// Fetch only active micro apps with their active versions, roles, and configs that the user has access to
-	if err := h.db.Where("active = ? AND micro_app_id IN ?", models.StatusActive, authorizedAppIDs).
-		Preload("Versions", "active = ?", models.StatusActive).
-		Preload("Roles", "active = ?", models.StatusActive).
-		Preload("Configs", "active = ?", models.StatusActive).
+	// Fetch only active micro apps with their active versions, roles, and configs that the user has access to,
+	// optionally narrowed to a category and/or any of the given tags
+	now := time.Now()
+	query := h.db.Where("active = ? AND micro_app_id IN ?", models.StatusActive, authorizedAppIDs).
+		Where(models.LiveCondition, now, now)
+	if category := r.URL.Query().Get(queryParamCategory); category != "" {
+		query = query.Where("category_id = ?", category)
+	}
+	if tags := r.URL.Query()[queryParamTag]; len(tags) > 0 {
+		query = query.Where("micro_app_id IN (?)", h.db.Model(&models.MicroAppTag{}).Select("micro_app_id").Where("tag IN ?", tags))
+	}
+	if err := preloadActiveRelations(query).
+		Order("sort_weight DESC, name ASC").
 		Find(&apps).Error; err != nil {
 		slog.Error("Failed to fetch micro apps from database", "error", err)
 		http.Error(w, "failed to fetch micro apps", http.StatusInternalServerError)
@@ -62,10 +134,116 @@ func (h *MicroAppHandler) GetAll(w http.ResponseWriter, r *http.Request) {
 
 	var response []dto.MicroAppResponse
 	for _, app := range apps {
-		appResponse := h.convertToResponseFromPreloaded(app)
+		appResponse := h.convertToResponseFromPreloaded(app, scope)
 		response = append(response, appResponse)
 	}
 
+	library, err := buildLibrary(h.db, libraryRows, authorizedAppIDs)
+	if err != nil {
+		slog.Error("Failed to fetch micro app library", "error", err, "email", userInfo.Email)
+		http.Error(w, "failed to fetch micro apps", http.StatusInternalServerError)
+		return
+	}
+	attachLibrary(response, library)
+
+	if r.URL.Query().Get(queryParamGroupBy) == groupByCategory {
+		groups, err := h.groupByCategory(response)
+		if err != nil {
+			slog.Error("Failed to fetch categories from database", "error", err)
+			http.Error(w, "failed to fetch micro apps", http.StatusInternalServerError)
+			return
+		}
+		if err := writeJSON(w, http.StatusOK, groups); err != nil {
+			slog.Error("Failed to write JSON response", "error", err)
+			http.Error(w, "failed to write response", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	if err := writeJSON(w, http.StatusOK, response); err != nil {
+		slog.Error("Failed to write JSON response", "error", err)
+		http.Error(w, "failed to write response", http.StatusInternalServerError)
+	}
+}
+
+// MicroAppHandler to handle fetching the featured micro apps for the home screen carousel
+func (h *MicroAppHandler) GetFeatured(w http.ResponseWriter, r *http.Request) {
+	userInfo, ok := auth.GetUserInfo(r.Context())
+	if !ok {
+		http.Error(w, "user info not found in context", http.StatusUnauthorized)
+		return
+	}
+
+	authorizedAppIDs, err := h.getMicroAppIDsByGroups(userInfo.Groups)
+	if err != nil {
+		slog.Error("Failed to get authorized app IDs", "error", err, "groups", userInfo.Groups)
+		http.Error(w, "failed to fetch featured micro apps", http.StatusInternalServerError)
+		return
+	}
+
+	response := []dto.FeaturedMicroAppResponse{}
+	if len(authorizedAppIDs) == 0 {
+		if err := writeJSON(w, http.StatusOK, response); err != nil {
+			slog.Error("Failed to write JSON response", "error", err)
+			http.Error(w, "failed to write response", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	// Fetch promotions whose time window contains now, in carousel order
+	now := time.Now()
+	var promotions []models.MicroAppPromotion
+	if err := h.db.Where("active = ? AND start_at <= ? AND end_at > ? AND micro_app_id IN ?", models.StatusActive, now, now, authorizedAppIDs).
+		Order("display_order ASC, start_at DESC").
+		Find(&promotions).Error; err != nil {
+		slog.Error("Failed to fetch promotions from database", "error", err)
+		http.Error(w, "failed to fetch featured micro apps", http.StatusInternalServerError)
+		return
+	}
+
+	var apps []models.MicroApp
+	if err := preloadActiveRelations(h.db.Where("active = ? AND micro_app_id IN ?", models.StatusActive, authorizedAppIDs).
+		Where(models.LiveCondition, now, now)).
+		Find(&apps).Error; err != nil {
+		slog.Error("Failed to fetch micro apps from database", "error", err)
+		http.Error(w, "failed to fetch featured micro apps", http.StatusInternalServerError)
+		return
+	}
+	appsByID := make(map[string]models.MicroApp, len(apps))
+	for _, app := range apps {
+		appsByID[app.MicroAppID] = app
+	}
+
+	// A micro app is featured at most once, in the first slot it is targeted at this user
+	scope := h.configScopeFor(r, userInfo)
+	featured := make(map[string]bool)
+	for _, p := range promotions {
+		app, exists := appsByID[p.MicroAppID]
+		if !exists || featured[p.MicroAppID] || !isTargetedAt(p.TargetGroups, userInfo.Groups) {
+			continue
+		}
+		featured[p.MicroAppID] = true
+
+		promoText := p.PromoText
+		if promoText == nil {
+			promoText = app.PromoText
+		}
+		bannerImageURL := p.BannerImageURL
+		if bannerImageURL == nil {
+			bannerImageURL = app.BannerImageURL
+		}
+
+		response = append(response, dto.FeaturedMicroAppResponse{
+			PromotionID:    p.ID,
+			DisplayOrder:   p.DisplayOrder,
+			PromoText:      promoText,
+			BannerImageURL: bannerImageURL,
+			StartAt:        p.StartAt,
+			EndAt:          p.EndAt,
+			App:            h.convertToResponseFromPreloaded(app, scope),
+		})
+	}
+
 	if err := writeJSON(w, http.StatusOK, response); err != nil {
 		slog.Error("Failed to write JSON response", "error", err)
 		http.Error(w, "failed to write response", http.StatusInternalServerError)
@@ -105,10 +283,9 @@ func (h *MicroAppHandler) GetByID(w http.ResponseWriter, r *http.Request) {
 	}
 
 	var app models.MicroApp
-	if err := h.db.Where("micro_app_id = ? AND active = ?", id, models.StatusActive).
-		Preload("Versions", "active = ?", models.StatusActive).
-		Preload("Roles", "active = ?", models.StatusActive).
-		Preload("Configs", "active = ?", models.StatusActive).
+	now := time.Now()
+	if err := preloadActiveRelations(h.db.Where("micro_app_id = ? AND active = ?", id, models.StatusActive).
+		Where(models.LiveCondition, now, now)).
 		First(&app).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
 			http.Error(w, "micro app not found", http.StatusNotFound)
@@ -119,8 +296,27 @@ func (h *MicroAppHandler) GetByID(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	appResponse := h.convertToResponseFromPreloaded(app)
+	appResponse := h.convertToResponseFromPreloaded(app, h.configScopeFor(r, userInfo))
+
+	var libraryRow models.UserMicroApp
+	err = h.db.Where("user_email = ? AND micro_app_id = ?", userInfo.Email, id).First(&libraryRow).Error
+	switch {
+	case err == nil:
+		entry := toUserMicroAppResponse(id, &libraryRow, app.Mandatory == 1)
+		appResponse.Library = &entry
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		if app.Mandatory == 1 {
+			entry := toUserMicroAppResponse(id, nil, true)
+			appResponse.Library = &entry
+		}
+	default:
+		slog.Error("Failed to fetch micro app library entry", "error", err, "appID", id)
+		http.Error(w, "failed to fetch micro app", http.StatusInternalServerError)
+		return
+	}
 
+	// The row version lets admins make conditional writes with If-Match
+	w.Header().Set(headerETag, rowETag(app.RowVersion))
 	if err := writeJSON(w, http.StatusOK, appResponse); err != nil {
 		slog.Error("Failed to write JSON response", "error", err)
 		http.Error(w, "failed to write response", http.StatusInternalServerError)
@@ -153,134 +349,265 @@ func (h *MicroAppHandler) Upsert(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if !checkMicroAppRequest(w, h.db, &req, "failed to upsert micro app") {
+		return
+	}
+
+	// Live writes are disabled when catalog changes must go through review
+	if h.cfg.CatalogReviewRequired {
+		http.Error(w, catalogReviewRequiredMessage, http.StatusForbidden)
+		return
+	}
+
+	// With If-Match the write only applies to the version of the app the client last read
+	expected, ok := readIfMatch(w, r)
+	if !ok {
+		return
+	}
+
 	var app models.MicroApp
 
 	// Use transaction to ensure app and all versions are upserted atomically
+	err := catalogTransaction(h.db, func(tx *gorm.DB) error {
+		if _, err := lockMicroApp(tx, req.AppID, expected); err != nil {
+			return err
+		}
+		return upsertMicroApp(tx, &req, userEmail, false)
+	})
+
+	if err != nil {
+		writeUpsertError(w, err, "failed to upsert micro app")
+		slog.Error("Failed to upsert micro app", "error", err, "appID", req.AppID)
+		return
+	}
+
+	// Reload with preloaded relations for response
+	if err := preloadActiveRelations(h.db.Where("micro_app_id = ?", req.AppID)).
+		First(&app).Error; err != nil {
+		slog.Error("Failed to reload micro app with relations", "error", err, "appID", req.AppID)
+		http.Error(w, "failed to fetch micro app", http.StatusInternalServerError)
+		return
+	}
+
+	appResponse := h.convertToResponseFromPreloaded(app, h.configScopeFor(r, userInfo))
+
+	w.Header().Set(headerETag, rowETag(app.RowVersion))
+	if err := writeJSON(w, http.StatusCreated, appResponse); err != nil {
+		slog.Error("Failed to write JSON response", "error", err)
+		http.Error(w, "failed to write response", http.StatusInternalServerError)
+	}
+}
+
+// MicroAppHandler to handle deactivating a micro app
+func (h *MicroAppHandler) Deactivate(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "appID")
+	if id == "" {
+		http.Error(w, "missing micro_app_id", http.StatusBadRequest)
+		return
+	}
+
+	var app models.MicroApp
+	if err := h.db.Where("micro_app_id = ?", id).First(&app).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			http.Error(w, "micro app not found", http.StatusNotFound)
+		} else {
+			slog.Error("Failed to fetch micro app", "error", err, "appID", id)
+			http.Error(w, "failed to fetch micro app", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	// Use transaction to ensure app, versions, roles, configs, and config overrides are deactivated together.
+	// Active children are suspended rather than deactivated so that Reactivate can restore them.
 	err := h.db.Transaction(func(tx *gorm.DB) error {
-		// Upsert micro app
-		result := tx.Where("micro_app_id = ?", req.AppID).
-			Assign(models.MicroApp{
-				Name:        req.Name,
-				Description: req.Description,
-				IconURL:     req.IconURL,
-				Mandatory:   req.Mandatory,
-				Active:      models.StatusActive,
-				UpdatedBy:   &userEmail,
-			}).
-			Attrs(models.MicroApp{
-				MicroAppID: req.AppID,
-				CreatedBy:  userEmail,
-			}).FirstOrCreate(&app)
+		return catalog.Deactivate(tx, id)
+	})
+
+	if err != nil {
+		slog.Error("Failed to deactivate micro app", "error", err, "appID", id)
+		http.Error(w, "failed to deactivate micro app", http.StatusInternalServerError)
+		return
+	}
+
+	if err := writeJSON(w, http.StatusOK, map[string]string{"message": "Micro app deactivated successfully"}); err != nil {
+		slog.Error("Failed to write JSON response", "error", err)
+		http.Error(w, "failed to write response", http.StatusInternalServerError)
+	}
+}
+
+// MicroAppHandler to handle listing the active config overrides of a micro app
+func (h *MicroAppHandler) GetConfigOverrides(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "appID")
+	if id == "" {
+		http.Error(w, "missing micro_app_id", http.StatusBadRequest)
+		return
+	}
 
+	var overrides []models.MicroAppConfigOverride
+	if err := h.db.Where("micro_app_id = ? AND active = ?", id, models.StatusActive).
+		Order("config_key ASC, scope_type ASC, priority DESC, scope_value ASC").
+		Find(&overrides).Error; err != nil {
+		slog.Error("Failed to fetch config overrides", "error", err, "appID", id)
+		http.Error(w, "failed to fetch config overrides", http.StatusInternalServerError)
+		return
+	}
+
+	response := make([]dto.MicroAppConfigOverrideResponse, 0, len(overrides))
+	for _, o := range overrides {
+		response = append(response, dto.MicroAppConfigOverrideResponse{
+			ConfigKey:   o.ConfigKey,
+			ScopeType:   o.ScopeType,
+			ScopeValue:  o.ScopeValue,
+			ConfigValue: o.ConfigValue,
+			Priority:    o.Priority,
+			Active:      o.Active,
+		})
+	}
+
+	if err := writeJSON(w, http.StatusOK, response); err != nil {
+		slog.Error("Failed to write JSON response", "error", err)
+		http.Error(w, "failed to write response", http.StatusInternalServerError)
+	}
+}
+
+// MicroAppHandler to handle deactivating a config override, identified by the
+// configKey, scopeType and scopeValue query parameters
+func (h *MicroAppHandler) DeactivateConfigOverride(w http.ResponseWriter, r *http.Request) {
+	userInfo, ok := auth.GetUserInfo(r.Context())
+	if !ok {
+		http.Error(w, "user info not found in context", http.StatusUnauthorized)
+		return
+	}
+
+	id := chi.URLParam(r, "appID")
+	if id == "" {
+		http.Error(w, "missing micro_app_id", http.StatusBadRequest)
+		return
+	}
+
+	query := r.URL.Query()
+	configKey, scopeType, scopeValue := query.Get("configKey"), query.Get("scopeType"), query.Get("scopeValue")
+	if configKey == "" || scopeType == "" || scopeValue == "" {
+		http.Error(w, "configKey, scopeType and scopeValue query parameters are required", http.StatusBadRequest)
+		return
+	}
+
+	var rowsAffected int64
+	err := h.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&models.MicroAppConfigOverride{}).
+			Where("micro_app_id = ? AND config_key = ? AND scope_type = ? AND scope_value = ? AND active = ?",
+				id, configKey, scopeType, scopeValue, models.StatusActive).
+			Updates(map[string]any{
+				"active":     models.StatusInactive,
+				"updated_by": userInfo.Email,
+			})
 		if result.Error != nil {
 			return result.Error
 		}
-
-		// Upsert versions if provided
-		if len(req.Versions) > 0 {
-			for _, versionReq := range req.Versions {
-				version := models.MicroAppVersion{}
-				versionResult := tx.Where("micro_app_id = ? AND version = ? AND build = ?", req.AppID, versionReq.Version, versionReq.Build).
-					Assign(models.MicroAppVersion{
-						ReleaseNotes: versionReq.ReleaseNotes,
-						IconURL:      versionReq.IconURL,
-						DownloadURL:  versionReq.DownloadURL,
-						Active:       models.StatusActive,
-						UpdatedBy:    &userEmail,
-					}).
-					Attrs(models.MicroAppVersion{
-						MicroAppID: req.AppID,
-						Version:    versionReq.Version,
-						Build:      versionReq.Build,
-						CreatedBy:  userEmail,
-					}).FirstOrCreate(&version)
-
-				if versionResult.Error != nil {
-					return versionResult.Error
-				}
-			}
+		rowsAffected = result.RowsAffected
+		if rowsAffected == 0 {
+			return nil
 		}
 
-		// Upsert roles if provided
-		if len(req.Roles) > 0 {
-			for _, roleReq := range req.Roles {
-				role := models.MicroAppRole{}
-				roleResult := tx.Where("micro_app_id = ? AND role = ?", req.AppID, roleReq.Role).
-					Assign(models.MicroAppRole{
-						Active:    models.StatusActive,
-						UpdatedBy: &userEmail,
-					}).
-					Attrs(models.MicroAppRole{
-						MicroAppID: req.AppID,
-						Role:       roleReq.Role,
-						CreatedBy:  userEmail,
-					}).FirstOrCreate(&role)
-
-				if roleResult.Error != nil {
-					return roleResult.Error
-				}
-			}
+		_, err := catalog.Touch(tx, id)
+		return err
+	})
+
+	if err != nil {
+		slog.Error("Failed to deactivate config override", "error", err, "appID", id, "configKey", configKey)
+		http.Error(w, "failed to deactivate config override", http.StatusInternalServerError)
+		return
+	}
+	if rowsAffected == 0 {
+		http.Error(w, "config override not found", http.StatusNotFound)
+		return
+	}
+
+	if err := writeJSON(w, http.StatusOK, map[string]string{"message": "Config override deactivated successfully"}); err != nil {
+		slog.Error("Failed to write JSON response", "error", err)
+		http.Error(w, "failed to write response", http.StatusInternalServerError)
+	}
+}
+
+// MicroAppHandler to handle reactivating a deactivated micro app
+func (h *MicroAppHandler) Reactivate(w http.ResponseWriter, r *http.Request) {
+	userInfo, ok := auth.GetUserInfo(r.Context())
+	if !ok {
+		http.Error(w, "user info not found in context", http.StatusUnauthorized)
+		return
+	}
+	userEmail := userInfo.Email
+
+	id := chi.URLParam(r, "appID")
+	if id == "" {
+		http.Error(w, "missing micro_app_id", http.StatusBadRequest)
+		return
+	}
+
+	var app models.MicroApp
+	if err := h.db.Where("micro_app_id = ?", id).First(&app).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			http.Error(w, "micro app not found", http.StatusNotFound)
+		} else {
+			slog.Error("Failed to fetch micro app", "error", err, "appID", id)
+			http.Error(w, "failed to fetch micro app", http.StatusInternalServerError)
 		}
+		return
+	}
 
-		// Upsert configs if provided
-		if len(req.Configs) > 0 {
-			for _, configReq := range req.Configs {
-				config := models.MicroAppConfig{}
-				configResult := tx.Where("micro_app_id = ? AND config_key = ?", req.AppID, configReq.ConfigKey).
-					Assign(models.MicroAppConfig{
-						ConfigValue: configReq.ConfigValue,
-						Active:      models.StatusActive,
-						UpdatedBy:   &userEmail,
-					}).
-					Attrs(models.MicroAppConfig{
-						MicroAppID:  req.AppID,
-						ConfigKey:   configReq.ConfigKey,
-						ConfigValue: configReq.ConfigValue,
-						CreatedBy:   userEmail,
-					}).FirstOrCreate(&config)
-
-				if configResult.Error != nil {
-					return configResult.Error
-				}
+	// Restore the app together with the versions, roles, and configs that were suspended by Deactivate
+	err := h.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&app).Updates(map[string]any{
+			"active":     models.StatusActive,
+			"updated_by": userEmail,
+		}).Error; err != nil {
+			return err
+		}
+		if _, err := catalog.Touch(tx, id); err != nil {
+			return err
+		}
+		for _, child := range []any{&models.MicroAppVersion{}, &models.MicroAppRole{}, &models.MicroAppConfig{}, &models.MicroAppConfigOverride{}} {
+			if err := tx.Model(child).Where("micro_app_id = ? AND active = ?", id, models.StatusSuspended).
+				Update("active", models.StatusActive).Error; err != nil {
+				return err
 			}
 		}
-
 		return nil
 	})
 
 	if err != nil {
-		slog.Error("Failed to upsert micro app", "error", err, "appID", req.AppID)
-		http.Error(w, "failed to upsert micro app", http.StatusInternalServerError)
+		slog.Error("Failed to reactivate micro app", "error", err, "appID", id)
+		http.Error(w, "failed to reactivate micro app", http.StatusInternalServerError)
 		return
 	}
 
-	// Reload with preloaded relations for response
-	if err := h.db.Where("micro_app_id = ?", req.AppID).
-		Preload("Versions", "active = ?", models.StatusActive).
-		Preload("Roles", "active = ?", models.StatusActive).
-		Preload("Configs", "active = ?", models.StatusActive).
+	if err := preloadActiveRelations(h.db.Where("micro_app_id = ?", id)).
 		First(&app).Error; err != nil {
-		slog.Error("Failed to reload micro app with relations", "error", err, "appID", req.AppID)
+		slog.Error("Failed to reload micro app with relations", "error", err, "appID", id)
 		http.Error(w, "failed to fetch micro app", http.StatusInternalServerError)
 		return
 	}
 
-	appResponse := h.convertToResponseFromPreloaded(app)
-
-	if err := writeJSON(w, http.StatusCreated, appResponse); err != nil {
+	if err := writeJSON(w, http.StatusOK, h.convertToResponseFromPreloaded(app, h.configScopeFor(r, userInfo))); err != nil {
 		slog.Error("Failed to write JSON response", "error", err)
 		http.Error(w, "failed to write response", http.StatusInternalServerError)
 	}
 }
 
-// MicroAppHandler to handle deactivating a micro app
-func (h *MicroAppHandler) Deactivate(w http.ResponseWriter, r *http.Request) {
+// MicroAppHandler to handle permanently deleting a micro app.
+// The app must be deactivated first and the request must repeat the app ID in the confirm query parameter.
+func (h *MicroAppHandler) Delete(w http.ResponseWriter, r *http.Request) {
 	id := chi.URLParam(r, "appID")
 	if id == "" {
 		http.Error(w, "missing micro_app_id", http.StatusBadRequest)
 		return
 	}
 
+	if r.URL.Query().Get(queryParamConfirm) != id {
+		http.Error(w, "confirm query parameter must match the micro app ID", http.StatusBadRequest)
+		return
+	}
+
 	var app models.MicroApp
 	if err := h.db.Where("micro_app_id = ?", id).First(&app).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
@@ -292,30 +619,51 @@ func (h *MicroAppHandler) Deactivate(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Use transaction to ensure app, versions, roles, and configs are deactivated together
+	if app.Active == models.StatusActive {
+		http.Error(w, "micro app must be deactivated before it can be deleted", http.StatusConflict)
+		return
+	}
+
+	// Use transaction to ensure everything belonging to the app is deleted together: its configs and their overrides,
+	// roles, versions, promotions, tags, permissions, deep links, users' library entries and mutes, and its
+	// notification quota and templates. Analytics and notification history are kept for reporting.
+	var storedFiles []string
 	err := h.db.Transaction(func(tx *gorm.DB) error {
-		if err := tx.Model(&app).Update("active", models.StatusInactive).Error; err != nil {
+		if err := tx.Model(&models.MicroAppStoredFile{}).Where("micro_app_id = ?", id).
+			Pluck("file_name", &storedFiles).Error; err != nil {
 			return err
 		}
-		if err := tx.Model(&models.MicroAppVersion{}).Where("micro_app_id = ?", id).Update("active", models.StatusInactive).Error; err != nil {
+		// The tombstone records the app's roles, so it is written before they are deleted
+		if err := catalog.Tombstone(tx, id); err != nil {
 			return err
 		}
-		if err := tx.Model(&models.MicroAppRole{}).Where("micro_app_id = ?", id).Update("active", models.StatusInactive).Error; err != nil {
-			return err
+		for _, child := range []any{&models.MicroAppConfigOverride{}, &models.MicroAppConfigSchema{}, &models.MicroAppConfig{}, &models.MicroAppRole{}, &models.MicroAppVersion{}, &models.MicroAppPromotion{}, &models.MicroAppTag{}, &models.MicroAppPermission{}, &models.MicroAppDeepLink{}, &models.UserMicroApp{}, &models.NotificationMute{}, &models.MicroAppStoredFile{}} {
+			if err := tx.Where("micro_app_id = ?", id).Delete(child).Error; err != nil {
+				return err
+			}
 		}
-		if err := tx.Model(&models.MicroAppConfig{}).Where("micro_app_id = ?", id).Update("active", models.StatusInactive).Error; err != nil {
+		if err := tx.Where("template_id IN (?)", tx.Model(&models.NotificationTemplate{}).Select("id").Where("microapp_id = ?", id)).
+			Delete(&models.NotificationTemplateTranslation{}).Error; err != nil {
 			return err
 		}
-		return nil
+		for _, child := range []any{&models.NotificationTemplate{}, &models.NotificationQuota{}, &models.NotificationQuotaUsage{}, &models.NotificationRecipientUsage{}} {
+			if err := tx.Where("microapp_id = ?", id).Delete(child).Error; err != nil {
+				return err
+			}
+		}
+		return tx.Delete(&app).Error
 	})
 
 	if err != nil {
-		slog.Error("Failed to deactivate micro app", "error", err, "appID", id)
-		http.Error(w, "failed to deactivate micro app", http.StatusInternalServerError)
+		slog.Error("Failed to delete micro app", "error", err, "appID", id)
+		http.Error(w, "failed to delete micro app", http.StatusInternalServerError)
 		return
 	}
 
-	if err := writeJSON(w, http.StatusOK, map[string]string{"message": "Micro app deactivated successfully"}); err != nil {
+	// Stored files are removed after the rows are gone; a failure here leaves an orphaned file, not a broken app
+	h.deleteStoredFiles(id, storedFiles)
+
+	if err := writeJSON(w, http.StatusOK, map[string]string{"message": "Micro app deleted successfully"}); err != nil {
 		slog.Error("Failed to write JSON response", "error", err)
 		http.Error(w, "failed to write response", http.StatusInternalServerError)
 	}
@@ -323,15 +671,167 @@ func (h *MicroAppHandler) Deactivate(w http.ResponseWriter, r *http.Request) {
 
 // Helper Functions
 
+// Preloads the active versions, roles, configs, and config overrides, and the tags of the micro apps matched by the query
+func preloadActiveRelations(query *gorm.DB) *gorm.DB {
+	now := time.Now()
+	return query.
+		Preload("Versions", "active = ? AND "+models.LiveCondition, models.StatusActive, now, now).
+		Preload("Roles", "active = ?", models.StatusActive).
+		Preload("Configs", "active = ?", models.StatusActive).
+		Preload("ConfigOverrides", "active = ?", models.StatusActive).
+		Preload("Tags").
+		Preload("Permissions", "status = ?", models.PermissionApproved)
+}
+
+// Groups catalog entries into sections in category display order.
+// Apps without an active category are collected in a final section with a nil category.
+func (h *MicroAppHandler) groupByCategory(apps []dto.MicroAppResponse) ([]dto.MicroAppCategoryGroupResponse, error) {
+	var categories []models.MicroAppCategory
+	if err := h.db.Where("active = ?", models.StatusActive).
+		Order("display_order ASC, name ASC").
+		Find(&categories).Error; err != nil {
+		return nil, err
+	}
+
+	activeCategories := make(map[string]bool, len(categories))
+	for _, c := range categories {
+		activeCategories[c.CategoryID] = true
+	}
+
+	appsByCategory := make(map[string][]dto.MicroAppResponse)
+	var uncategorised []dto.MicroAppResponse
+	for _, app := range apps {
+		if app.CategoryID == nil || !activeCategories[*app.CategoryID] {
+			uncategorised = append(uncategorised, app)
+			continue
+		}
+		appsByCategory[*app.CategoryID] = append(appsByCategory[*app.CategoryID], app)
+	}
+
+	groups := []dto.MicroAppCategoryGroupResponse{}
+	for _, c := range categories {
+		categoryApps, exists := appsByCategory[c.CategoryID]
+		if !exists {
+			continue
+		}
+		category := toCategoryResponse(c)
+		groups = append(groups, dto.MicroAppCategoryGroupResponse{Category: &category, Apps: categoryApps})
+	}
+	if len(uncategorised) > 0 {
+		groups = append(groups, dto.MicroAppCategoryGroupResponse{Category: nil, Apps: uncategorised})
+	}
+
+	return groups, nil
+}
+
+// Reports whether a promotion targeted at the given groups should be shown to a user in userGroups.
+// An empty target list means the promotion is shown to everyone.
+func isTargetedAt(targetGroups, userGroups []string) bool {
+	if len(targetGroups) == 0 {
+		return true
+	}
+	for _, group := range userGroups {
+		if slices.Contains(targetGroups, group) {
+			return true
+		}
+	}
+	return false
+}
+
+// Removes the files the backend stored for a micro app (its bundles and icons) from the file
+// service. Version URLs are not used, since they may point at files of other apps or elsewhere.
+func (h *MicroAppHandler) deleteStoredFiles(appID string, fileNames []string) {
+	for _, fileName := range fileNames {
+		if err := h.fileService.DeleteFile(fileName); err != nil {
+			slog.Warn("Failed to delete micro app file", "error", err, "appID", appID, "fileName", fileName)
+		}
+	}
+}
+
+// Extracts the stored file name (the last path segment) from a file service download URL
+func fileNameFromURL(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return ""
+	}
+	name := path.Base(u.Path)
+	if name == "." || name == "/" {
+		return ""
+	}
+	return name
+}
+
+// recordUserGroups stores the groups a user belongs to according to their token. It only
+// writes when they differ from the stored ones or were last stamped more than
+// userGroupsRefreshInterval ago, so repeated catalog reads stay read-only. Failures are only
+// logged, since they must not fail the request that carried the token.
+func recordUserGroups(db *gorm.DB, email string, groups []string) {
+	groups = slices.Clone(groups)
+	slices.Sort(groups)
+	groups = slices.Compact(groups)
+
+	now := time.Now()
+	var stored []models.UserGroup
+	if err := db.Where("user_email = ?", email).Find(&stored).Error; err != nil {
+		slog.Warn("Failed to fetch user groups", "error", err, "email", email)
+		return
+	}
+	if userGroupsCurrent(stored, groups, now) {
+		return
+	}
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		stale := tx.Where("user_email = ?", email)
+		if len(groups) > 0 {
+			stale = stale.Where("group_name NOT IN ?", groups)
+		}
+		if err := stale.Delete(&models.UserGroup{}).Error; err != nil {
+			return err
+		}
+		if len(groups) == 0 {
+			return nil
+		}
+
+		rows := make([]models.UserGroup, 0, len(groups))
+		for _, g := range groups {
+			rows = append(rows, models.UserGroup{UserEmail: email, GroupName: g, LastSeenAt: now})
+		}
+		return tx.Clauses(clause.OnConflict{DoUpdates: clause.AssignmentColumns([]string{"last_seen_at"})}).
+			Create(&rows).Error
+	})
+	if err != nil {
+		slog.Warn("Failed to record user groups", "error", err, "email", email)
+	}
+}
+
+// userGroupsCurrent reports whether the stored groups of a user are exactly groups (sorted and
+// distinct) and were all stamped recently enough
+func userGroupsCurrent(stored []models.UserGroup, groups []string, now time.Time) bool {
+	if len(stored) != len(groups) {
+		return false
+	}
+	for _, g := range stored {
+		if _, found := slices.BinarySearch(groups, g.GroupName); !found || now.Sub(g.LastSeenAt) > userGroupsRefreshInterval {
+			return false
+		}
+	}
+	return true
+}
+
 // Fetches micro app IDs accessible by the given user groups
 func (h *MicroAppHandler) getMicroAppIDsByGroups(groups []string) ([]string, error) {
+	return authorizedMicroAppIDs(h.db, groups)
+}
+
+// authorizedMicroAppIDs returns the micro apps with an active role for any of the given groups
+func authorizedMicroAppIDs(db *gorm.DB, groups []string) ([]string, error) {
 	if len(groups) == 0 {
 		slog.Warn("No groups found for the user")
 		return []string{}, nil
 	}
 
 	var appIDs []string
-	if err := h.db.Model(&models.MicroAppRole{}).
+	if err := db.Model(&models.MicroAppRole{}).
 		Select("DISTINCT micro_app_id").
 		Where("active = ? AND role IN ?", models.StatusActive, groups).
 		Pluck("micro_app_id", &appIDs).Error; err != nil {
@@ -346,20 +846,11 @@ func (h *MicroAppHandler) getMicroAppIDsByGroups(groups []string) ([]string, err
 	return appIDs, nil
 }
 
-// Converts a MicroApp model with preloaded versions, roles, and configs to response DTO
-func (h *MicroAppHandler) convertToResponseFromPreloaded(app models.MicroApp) dto.MicroAppResponse {
+// Converts a MicroApp model with preloaded versions, roles, and configs to response DTO, resolving configs for the given scope
+func (h *MicroAppHandler) convertToResponseFromPreloaded(app models.MicroApp, scope configScope) dto.MicroAppResponse {
 	var versionResponses []dto.MicroAppVersionResponse
 	for _, v := range app.Versions {
-		versionResponses = append(versionResponses, dto.MicroAppVersionResponse{
-			ID:           v.ID,
-			MicroAppID:   v.MicroAppID,
-			Version:      v.Version,
-			Build:        v.Build,
-			ReleaseNotes: v.ReleaseNotes,
-			IconURL:      v.IconURL,
-			DownloadURL:  v.DownloadURL,
-			Active:       v.Active,
-		})
+		versionResponses = append(versionResponses, toVersionResponse(v))
 	}
 
 	var roleResponses []dto.MicroAppRoleResponse
@@ -372,29 +863,37 @@ func (h *MicroAppHandler) convertToResponseFromPreloaded(app models.MicroApp) dt
 		})
 	}
 
-	var configResponses []dto.MicroAppConfigResponse
-	for _, c := range app.Configs {
-		// Marshal JSONMap to json.RawMessage
-		configValueBytes, err := json.Marshal(c.ConfigValue)
-		if err != nil {
-			slog.Error("Failed to marshal config value", "configKey", c.ConfigKey, "error", err)
-			continue
-		}
-		configResponses = append(configResponses, dto.MicroAppConfigResponse{
-			ConfigKey:   c.ConfigKey,
-			ConfigValue: json.RawMessage(configValueBytes),
-		})
+	var tags []string
+	for _, t := range app.Tags {
+		tags = append(tags, t.Tag)
 	}
 
+	permissions := make([]string, 0, len(app.Permissions))
+	for _, p := range app.Permissions {
+		permissions = append(permissions, p.Capability)
+	}
+	slices.Sort(permissions)
+
+	// Each config key resolves to the most specific value for the caller
+	configResponses := resolveConfigs(app.Configs, app.ConfigOverrides, scope)
+
 	return dto.MicroAppResponse{
-		AppID:       app.MicroAppID,
-		Name:        app.Name,
-		Description: app.Description,
-		IconURL:     app.IconURL,
-		Active:      app.Active,
-		Mandatory:   app.Mandatory,
-		Versions:    versionResponses,
-		Roles:       roleResponses,
-		Configs:     configResponses,
+		AppID:          app.MicroAppID,
+		Name:           app.Name,
+		Description:    app.Description,
+		PromoText:      app.PromoText,
+		IconURL:        app.IconURL,
+		BannerImageURL: app.BannerImageURL,
+		Active:         app.Active,
+		Mandatory:      app.Mandatory,
+		CategoryID:     app.CategoryID,
+		Tags:           tags,
+		SortWeight:     app.SortWeight,
+		Versions:       versionResponses,
+		Roles:          roleResponses,
+		Configs:        configResponses,
+		Permissions:    permissions,
+		PublishAt:      app.PublishAt,
+		ExpireAt:       app.ExpireAt,
 	}
 }
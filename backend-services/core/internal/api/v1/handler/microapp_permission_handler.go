@@ -0,0 +1,205 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"slices"
+	"time"
+
+	"go-backend/internal/api/v1/dto"
+	"go-backend/internal/auth"
+	"go-backend/internal/catalog"
+	"go-backend/internal/models"
+
+	"github.com/go-chi/chi/v5"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type MicroAppPermissionHandler struct {
+	db *gorm.DB
+}
+
+func NewMicroAppPermissionHandler(db *gorm.DB) *MicroAppPermissionHandler {
+	return &MicroAppPermissionHandler{db: db}
+}
+
+// GetAll handles listing the capabilities a micro app requested and their review status
+func (h *MicroAppPermissionHandler) GetAll(w http.ResponseWriter, r *http.Request) {
+	appID := chi.URLParam(r, "appID")
+	if appID == "" {
+		http.Error(w, "missing micro_app_id", http.StatusBadRequest)
+		return
+	}
+
+	var permissions []models.MicroAppPermission
+	if err := h.db.Where("micro_app_id = ?", appID).Order("capability ASC").Find(&permissions).Error; err != nil {
+		slog.Error("Failed to fetch permissions", "error", err, "appID", appID)
+		http.Error(w, "failed to fetch permissions", http.StatusInternalServerError)
+		return
+	}
+
+	response := make([]dto.MicroAppPermissionResponse, 0, len(permissions))
+	for _, p := range permissions {
+		response = append(response, dto.MicroAppPermissionResponse{
+			Capability: p.Capability,
+			Status:     p.Status,
+			ReviewedBy: p.ReviewedBy,
+			ReviewedAt: p.ReviewedAt,
+		})
+	}
+
+	if err := writeJSON(w, http.StatusOK, response); err != nil {
+		slog.Error("Failed to write JSON response", "error", err)
+		http.Error(w, "failed to write response", http.StatusInternalServerError)
+	}
+}
+
+// Review handles approving or rejecting a capability for a micro app. Admins may also
+// grant a capability the app has not requested yet.
+func (h *MicroAppPermissionHandler) Review(w http.ResponseWriter, r *http.Request) {
+	userInfo, ok := auth.GetUserInfo(r.Context())
+	if !ok {
+		http.Error(w, "user info not found in context", http.StatusUnauthorized)
+		return
+	}
+	userEmail := userInfo.Email
+
+	appID := chi.URLParam(r, "appID")
+	capability := chi.URLParam(r, "capability")
+	if appID == "" || capability == "" {
+		http.Error(w, "missing micro_app_id or capability", http.StatusBadRequest)
+		return
+	}
+	if !models.KnownCapabilities[capability] {
+		http.Error(w, "unknown capability", http.StatusBadRequest)
+		return
+	}
+
+	if !validateContentType(w, r) {
+		return
+	}
+
+	limitRequestBody(w, r, 0) // 1MB default limit
+	var req dto.ReviewMicroAppPermissionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if !validateStruct(w, &req) {
+		return
+	}
+
+	if err := h.db.Where("micro_app_id = ?", appID).First(&models.MicroApp{}).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			http.Error(w, "micro app not found", http.StatusNotFound)
+			return
+		}
+		slog.Error("Failed to fetch micro app", "error", err, "appID", appID)
+		http.Error(w, "failed to review permission", http.StatusInternalServerError)
+		return
+	}
+
+	// The approved list ships with the catalog, so reviews bump the catalog revision
+	permission := models.MicroAppPermission{}
+	now := time.Now()
+	err := h.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Where("micro_app_id = ? AND capability = ?", appID, capability).
+			Assign(map[string]any{
+				"status":      req.Status,
+				"reviewed_by": userEmail,
+				"reviewed_at": now,
+			}).
+			Attrs(models.MicroAppPermission{
+				MicroAppID: appID,
+				Capability: capability,
+				CreatedBy:  userEmail,
+			}).FirstOrCreate(&permission)
+		if result.Error != nil {
+			return result.Error
+		}
+
+		_, err := catalog.Touch(tx, appID)
+		return err
+	})
+
+	if err != nil {
+		slog.Error("Failed to review permission", "error", err, "appID", appID, "capability", capability)
+		http.Error(w, "failed to review permission", http.StatusInternalServerError)
+		return
+	}
+
+	if err := writeJSON(w, http.StatusOK, dto.MicroAppPermissionResponse{
+		Capability: permission.Capability,
+		Status:     permission.Status,
+		ReviewedBy: permission.ReviewedBy,
+		ReviewedAt: permission.ReviewedAt,
+	}); err != nil {
+		slog.Error("Failed to write JSON response", "error", err)
+		http.Error(w, "failed to write response", http.StatusInternalServerError)
+	}
+}
+
+// requestPermissions records capabilities declared by a build as requested. Capabilities
+// that were already reviewed keep their decision.
+func requestPermissions(tx *gorm.DB, appID string, capabilities []string, userEmail string) error {
+	for _, capability := range capabilities {
+		if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&models.MicroAppPermission{
+			MicroAppID: appID,
+			Capability: capability,
+			Status:     models.PermissionRequested,
+			CreatedBy:  userEmail,
+		}).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// diffVersions compares the permissions of two builds. from may be nil for a first build.
+func diffVersions(db *gorm.DB, appID string, from *models.MicroAppVersion, to models.MicroAppVersion) (dto.MicroAppVersionDiffResponse, error) {
+	diff := dto.MicroAppVersionDiffResponse{
+		To:                 dto.MicroAppVersionRefResponse{Version: to.Version, Build: to.Build},
+		AddedPermissions:   []string{},
+		RemovedPermissions: []string{},
+		PendingPermissions: []string{},
+	}
+
+	var previous []string
+	if from != nil {
+		diff.From = &dto.MicroAppVersionRefResponse{Version: from.Version, Build: from.Build}
+		previous = from.RequiredPermissions
+	}
+	for _, p := range to.RequiredPermissions {
+		if !slices.Contains(previous, p) {
+			diff.AddedPermissions = append(diff.AddedPermissions, p)
+		}
+	}
+	for _, p := range previous {
+		if !slices.Contains(to.RequiredPermissions, p) {
+			diff.RemovedPermissions = append(diff.RemovedPermissions, p)
+		}
+	}
+
+	if len(to.RequiredPermissions) > 0 {
+		var approved []string
+		if err := db.Model(&models.MicroAppPermission{}).
+			Where("micro_app_id = ? AND capability IN ? AND status = ?", appID, []string(to.RequiredPermissions), models.PermissionApproved).
+			Pluck("capability", &approved).Error; err != nil {
+			return diff, err
+		}
+		for _, p := range to.RequiredPermissions {
+			if !slices.Contains(approved, p) {
+				diff.PendingPermissions = append(diff.PendingPermissions, p)
+			}
+		}
+	}
+
+	slices.Sort(diff.AddedPermissions)
+	slices.Sort(diff.RemovedPermissions)
+	slices.Sort(diff.PendingPermissions)
+	return diff, nil
+}
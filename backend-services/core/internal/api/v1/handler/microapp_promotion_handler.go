@@ -0,0 +1,218 @@
+package handler
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"go-backend/internal/api/v1/dto"
+	"go-backend/internal/auth"
+	"go-backend/internal/models"
+
+	"github.com/go-chi/chi/v5"
+	"gorm.io/gorm"
+)
+
+type MicroAppPromotionHandler struct {
+	db *gorm.DB
+}
+
+func NewMicroAppPromotionHandler(db *gorm.DB) *MicroAppPromotionHandler {
+	return &MicroAppPromotionHandler{db: db}
+}
+
+// GetAll handles listing all active promotion slots, including scheduled and expired ones
+func (h *MicroAppPromotionHandler) GetAll(w http.ResponseWriter, r *http.Request) {
+	var promotions []models.MicroAppPromotion
+	if err := h.db.Where("active = ?", models.StatusActive).
+		Order("display_order ASC, start_at DESC").
+		Find(&promotions).Error; err != nil {
+		slog.Error("Failed to fetch promotions from database", "error", err)
+		http.Error(w, "failed to fetch promotions", http.StatusInternalServerError)
+		return
+	}
+
+	response := make([]dto.MicroAppPromotionResponse, 0, len(promotions))
+	for _, p := range promotions {
+		response = append(response, toPromotionResponse(p))
+	}
+
+	if err := writeJSON(w, http.StatusOK, response); err != nil {
+		slog.Error("Failed to write JSON response", "error", err)
+		http.Error(w, "failed to write response", http.StatusInternalServerError)
+	}
+}
+
+// Create handles creating a new promotion slot for a micro app
+func (h *MicroAppPromotionHandler) Create(w http.ResponseWriter, r *http.Request) {
+	userInfo, ok := auth.GetUserInfo(r.Context())
+	if !ok {
+		http.Error(w, "user info not found in context", http.StatusUnauthorized)
+		return
+	}
+
+	req, ok := h.decodeRequest(w, r)
+	if !ok {
+		return
+	}
+
+	promotion := models.MicroAppPromotion{
+		MicroAppID:     req.AppID,
+		PromoText:      req.PromoText,
+		BannerImageURL: req.BannerImageURL,
+		StartAt:        req.StartAt,
+		EndAt:          req.EndAt,
+		DisplayOrder:   req.DisplayOrder,
+		TargetGroups:   req.TargetGroups,
+		CreatedBy:      userInfo.Email,
+		Active:         models.StatusActive,
+	}
+	if err := h.db.Create(&promotion).Error; err != nil {
+		slog.Error("Failed to create promotion", "error", err, "appID", req.AppID)
+		http.Error(w, "failed to create promotion", http.StatusInternalServerError)
+		return
+	}
+
+	if err := writeJSON(w, http.StatusCreated, toPromotionResponse(promotion)); err != nil {
+		slog.Error("Failed to write JSON response", "error", err)
+		http.Error(w, "failed to write response", http.StatusInternalServerError)
+	}
+}
+
+// Update handles replacing an existing promotion slot
+func (h *MicroAppPromotionHandler) Update(w http.ResponseWriter, r *http.Request) {
+	userInfo, ok := auth.GetUserInfo(r.Context())
+	if !ok {
+		http.Error(w, "user info not found in context", http.StatusUnauthorized)
+		return
+	}
+	userEmail := userInfo.Email
+
+	promotion, ok := h.findPromotion(w, r)
+	if !ok {
+		return
+	}
+
+	req, ok := h.decodeRequest(w, r)
+	if !ok {
+		return
+	}
+
+	promotion.MicroAppID = req.AppID
+	promotion.PromoText = req.PromoText
+	promotion.BannerImageURL = req.BannerImageURL
+	promotion.StartAt = req.StartAt
+	promotion.EndAt = req.EndAt
+	promotion.DisplayOrder = req.DisplayOrder
+	promotion.TargetGroups = req.TargetGroups
+	promotion.UpdatedBy = &userEmail
+	if err := h.db.Save(&promotion).Error; err != nil {
+		slog.Error("Failed to update promotion", "error", err, "promotionID", promotion.ID)
+		http.Error(w, "failed to update promotion", http.StatusInternalServerError)
+		return
+	}
+
+	if err := writeJSON(w, http.StatusOK, toPromotionResponse(promotion)); err != nil {
+		slog.Error("Failed to write JSON response", "error", err)
+		http.Error(w, "failed to write response", http.StatusInternalServerError)
+	}
+}
+
+// Deactivate handles removing a promotion slot from the carousel
+func (h *MicroAppPromotionHandler) Deactivate(w http.ResponseWriter, r *http.Request) {
+	userInfo, ok := auth.GetUserInfo(r.Context())
+	if !ok {
+		http.Error(w, "user info not found in context", http.StatusUnauthorized)
+		return
+	}
+
+	promotion, ok := h.findPromotion(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.db.Model(&promotion).Updates(map[string]any{
+		"active":     models.StatusInactive,
+		"updated_by": userInfo.Email,
+	}).Error; err != nil {
+		slog.Error("Failed to deactivate promotion", "error", err, "promotionID", promotion.ID)
+		http.Error(w, "failed to deactivate promotion", http.StatusInternalServerError)
+		return
+	}
+
+	if err := writeJSON(w, http.StatusOK, map[string]string{"message": "Promotion deactivated successfully"}); err != nil {
+		slog.Error("Failed to write JSON response", "error", err)
+		http.Error(w, "failed to write response", http.StatusInternalServerError)
+	}
+}
+
+// Helper functions
+
+// Decodes and validates a promotion request body and checks that the micro app exists
+func (h *MicroAppPromotionHandler) decodeRequest(w http.ResponseWriter, r *http.Request) (*dto.UpsertMicroAppPromotionRequest, bool) {
+	if !validateContentType(w, r) {
+		return nil, false
+	}
+
+	limitRequestBody(w, r, 0) // 1MB default limit
+	var req dto.UpsertMicroAppPromotionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid request body", http.StatusBadRequest)
+		return nil, false
+	}
+
+	if !validateStruct(w, &req) {
+		return nil, false
+	}
+
+	var microApp models.MicroApp
+	if err := h.db.Where("micro_app_id = ?", req.AppID).First(&microApp).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			http.Error(w, "micro app not found", http.StatusNotFound)
+		} else {
+			slog.Error("Failed to fetch micro app", "error", err, "appID", req.AppID)
+			http.Error(w, "failed to fetch micro app", http.StatusInternalServerError)
+		}
+		return nil, false
+	}
+
+	return &req, true
+}
+
+// Loads the active promotion identified by the promotionID URL parameter
+func (h *MicroAppPromotionHandler) findPromotion(w http.ResponseWriter, r *http.Request) (models.MicroAppPromotion, bool) {
+	var promotion models.MicroAppPromotion
+
+	promotionID, err := strconv.Atoi(chi.URLParam(r, "promotionID"))
+	if err != nil {
+		http.Error(w, "invalid promotion ID", http.StatusBadRequest)
+		return promotion, false
+	}
+
+	if err := h.db.Where("id = ? AND active = ?", promotionID, models.StatusActive).First(&promotion).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			http.Error(w, "promotion not found", http.StatusNotFound)
+		} else {
+			slog.Error("Failed to fetch promotion", "error", err, "promotionID", promotionID)
+			http.Error(w, "failed to fetch promotion", http.StatusInternalServerError)
+		}
+		return promotion, false
+	}
+
+	return promotion, true
+}
+
+func toPromotionResponse(p models.MicroAppPromotion) dto.MicroAppPromotionResponse {
+	return dto.MicroAppPromotionResponse{
+		ID:             p.ID,
+		MicroAppID:     p.MicroAppID,
+		PromoText:      p.PromoText,
+		BannerImageURL: p.BannerImageURL,
+		StartAt:        p.StartAt,
+		EndAt:          p.EndAt,
+		DisplayOrder:   p.DisplayOrder,
+		TargetGroups:   p.TargetGroups,
+		Active:         p.Active,
+	}
+}
@@ -0,0 +1,310 @@
+package handler
+
+import (
+	"fmt"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"go-backend/internal/api/v1/dto"
+	"go-backend/internal/catalog"
+	"go-backend/internal/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// requestError rejects a request with a client error. Field errors are set when config
+// values failed their schema.
+type requestError struct {
+	status      int
+	message     string
+	fieldErrors []dto.ConfigFieldErrorResponse
+}
+
+func (e *requestError) Error() string {
+	return e.message
+}
+
+func writeRequestError(w http.ResponseWriter, e *requestError) {
+	if len(e.fieldErrors) > 0 {
+		writeConfigValidationErrors(w, e.status, e.message, e.fieldErrors)
+		return
+	}
+	http.Error(w, e.message, e.status)
+}
+
+// validateMicroAppRequest runs the checks a micro app upsert needs beyond struct validation:
+// the category must exist and config values must match their schemas. Drafts are checked
+// when saved and again when published.
+func validateMicroAppRequest(db *gorm.DB, req *dto.CreateMicroAppRequest) (*requestError, error) {
+	if !validSchedule(req.PublishAt, req.ExpireAt) {
+		return &requestError{status: http.StatusBadRequest, message: "expireAt must be after publishAt"}, nil
+	}
+	for _, v := range req.Versions {
+		if !validSchedule(v.PublishAt, v.ExpireAt) {
+			return &requestError{status: http.StatusBadRequest, message: fmt.Sprintf("expireAt must be after publishAt for version %s", v.Version)}, nil
+		}
+	}
+
+	if req.CategoryID != nil {
+		var count int64
+		if err := db.Model(&models.MicroAppCategory{}).Where("category_id = ? AND active = ?", *req.CategoryID, models.StatusActive).Count(&count).Error; err != nil {
+			return nil, err
+		}
+		if count == 0 {
+			return &requestError{status: http.StatusBadRequest, message: "unknown category"}, nil
+		}
+	}
+
+	fieldErrors, err := validateConfigRequests(db, req.AppID, req.Configs)
+	if err != nil {
+		return nil, err
+	}
+	if len(fieldErrors) > 0 {
+		return &requestError{
+			status:      http.StatusBadRequest,
+			message:     "config values do not match their schema",
+			fieldErrors: fieldErrors,
+		}, nil
+	}
+
+	return nil, nil
+}
+
+// validSchedule reports whether a schedule window is non-empty
+func validSchedule(publishAt, expireAt *time.Time) bool {
+	return publishAt == nil || expireAt == nil || expireAt.After(*publishAt)
+}
+
+// checkMicroAppRequest runs validateMicroAppRequest and writes the error response if it fails
+func checkMicroAppRequest(w http.ResponseWriter, db *gorm.DB, req *dto.CreateMicroAppRequest, failMessage string) bool {
+	reqErr, err := validateMicroAppRequest(db, req)
+	if err != nil {
+		slog.Error("Failed to validate micro app request", "error", err, "appID", req.AppID)
+		http.Error(w, failMessage, http.StatusInternalServerError)
+		return false
+	}
+	if reqErr != nil {
+		writeRequestError(w, reqErr)
+		return false
+	}
+	return true
+}
+
+// upsertMicroApp writes the micro app and the versions, roles, tags and configs in req to the
+// live catalog. It must run inside a transaction. With replace set, active versions, roles and
+// configs missing from req are deactivated, so the app ends up exactly as described (used when
+// restoring a published snapshot). Rows are written with INSERT ... ON DUPLICATE KEY UPDATE on
+// their unique keys, so concurrent upserts of the same app cannot create duplicates.
+func upsertMicroApp(tx *gorm.DB, req *dto.CreateMicroAppRequest, userEmail string, replace bool) error {
+	if _, err := lockMicroApp(tx, req.AppID, nil); err != nil {
+		return err
+	}
+
+	if replace {
+		for _, child := range []any{&models.MicroAppVersion{}, &models.MicroAppRole{}, &models.MicroAppConfig{}, &models.MicroAppConfigOverride{}} {
+			if err := tx.Model(child).
+				Where("micro_app_id = ? AND active = ?", req.AppID, models.StatusActive).
+				Updates(map[string]any{"active": models.StatusInactive, "updated_by": userEmail}).Error; err != nil {
+				return err
+			}
+		}
+		if req.Tags == nil {
+			req.Tags = []string{}
+		}
+	}
+
+	// Upsert micro app. Like the other optional fields, zero values keep the stored value.
+	app := models.MicroApp{
+		MicroAppID:     req.AppID,
+		Name:           req.Name,
+		Description:    req.Description,
+		PromoText:      req.PromoText,
+		IconURL:        req.IconURL,
+		BannerImageURL: req.BannerImageURL,
+		Mandatory:      req.Mandatory,
+		CategoryID:     req.CategoryID,
+		SortWeight:     req.SortWeight,
+		Active:         models.StatusActive,
+		CreatedBy:      userEmail,
+		UpdatedBy:      &userEmail,
+	}
+	columns := []string{"name", "active", "updated_by", "updated_at"}
+	for _, optional := range []struct {
+		column string
+		set    bool
+	}{
+		{"description", req.Description != nil},
+		{"promo_text", req.PromoText != nil},
+		{"icon_url", req.IconURL != nil},
+		{"banner_image_url", req.BannerImageURL != nil},
+		{"mandatory", req.Mandatory != 0},
+		{"category_id", req.CategoryID != nil},
+		{"sort_weight", req.SortWeight != 0},
+	} {
+		if optional.set {
+			columns = append(columns, optional.column)
+		}
+	}
+	if err := tx.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "micro_app_id"}},
+		DoUpdates: clause.AssignmentColumns(columns),
+	}).Create(&app).Error; err != nil {
+		return err
+	}
+
+	now := time.Now()
+	if schedule := models.ScheduleUpdates(req.PublishAt, req.ExpireAt, req.NotifyOnPublish, replace, now); len(schedule) > 0 {
+		if err := tx.Model(&models.MicroApp{}).Where("micro_app_id = ?", req.AppID).
+			Updates(schedule).Error; err != nil {
+			return err
+		}
+	}
+
+	if _, err := catalog.Touch(tx, req.AppID); err != nil {
+		return err
+	}
+
+	// Upsert versions if provided
+	for _, versionReq := range req.Versions {
+		if err := upsertVersion(tx, req.AppID, versionReq, userEmail, replace, now); err != nil {
+			return err
+		}
+	}
+
+	// Upsert roles if provided
+	if len(req.Roles) > 0 {
+		roles := make([]models.MicroAppRole, 0, len(req.Roles))
+		for _, roleReq := range req.Roles {
+			roles = append(roles, models.MicroAppRole{
+				MicroAppID: req.AppID,
+				Role:       roleReq.Role,
+				Active:     models.StatusActive,
+				CreatedBy:  userEmail,
+				UpdatedBy:  &userEmail,
+			})
+		}
+		if err := tx.Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "micro_app_id"}, {Name: "role"}},
+			DoUpdates: clause.AssignmentColumns([]string{"active", "updated_by", "updated_at"}),
+		}).Create(&roles).Error; err != nil {
+			return err
+		}
+	}
+
+	// Replace tags if provided; an empty list clears them
+	if req.Tags != nil {
+		if err := tx.Where("micro_app_id = ?", req.AppID).Delete(&models.MicroAppTag{}).Error; err != nil {
+			return err
+		}
+		seen := make(map[string]bool, len(req.Tags))
+		for _, tag := range req.Tags {
+			if seen[tag] {
+				continue
+			}
+			seen[tag] = true
+			if err := tx.Create(&models.MicroAppTag{
+				MicroAppID: req.AppID,
+				Tag:        tag,
+				CreatedBy:  userEmail,
+			}).Error; err != nil {
+				return err
+			}
+		}
+	}
+
+	// Upsert configs and their scoped overrides if provided
+	for _, configReq := range req.Configs {
+		config := models.MicroAppConfig{
+			MicroAppID:  req.AppID,
+			ConfigKey:   configReq.ConfigKey,
+			ConfigValue: configReq.ConfigValue,
+			Active:      models.StatusActive,
+			CreatedBy:   userEmail,
+			UpdatedBy:   &userEmail,
+		}
+		if err := tx.Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "micro_app_id"}, {Name: "config_key"}},
+			DoUpdates: clause.AssignmentColumns([]string{"config_value", "active", "updated_by"}),
+		}).Create(&config).Error; err != nil {
+			return err
+		}
+
+		for _, overrideReq := range configReq.Overrides {
+			override := models.MicroAppConfigOverride{
+				MicroAppID:  req.AppID,
+				ConfigKey:   configReq.ConfigKey,
+				ScopeType:   overrideReq.ScopeType,
+				ScopeValue:  overrideReq.ScopeValue,
+				ConfigValue: overrideReq.ConfigValue,
+				Priority:    overrideReq.Priority,
+				Active:      models.StatusActive,
+				CreatedBy:   userEmail,
+				UpdatedBy:   &userEmail,
+			}
+			if err := tx.Clauses(clause.OnConflict{
+				Columns:   []clause.Column{{Name: "micro_app_id"}, {Name: "config_key"}, {Name: "scope_type"}, {Name: "scope_value"}},
+				DoUpdates: clause.AssignmentColumns([]string{"config_value", "priority", "active", "updated_by", "updated_at"}),
+			}).Create(&override).Error; err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// upsertVersion writes one version of a micro app and records the permissions it requests. A
+// build number belongs to a single version string; reusing it for another one is a conflict.
+// Omitted release notes, icon and permissions keep their stored values, as does an omitted
+// schedule unless replace is set.
+func upsertVersion(tx *gorm.DB, appID string, req dto.CreateMicroAppVersionRequest, userEmail string, replace bool, now time.Time) error {
+	var clashes int64
+	if err := tx.Model(&models.MicroAppVersion{}).
+		Where("micro_app_id = ? AND build = ? AND version <> ?", appID, req.Build, req.Version).
+		Count(&clashes).Error; err != nil {
+		return err
+	}
+	if clashes > 0 {
+		return &requestError{status: http.StatusConflict, message: fmt.Sprintf("build %d is already used by another version", req.Build)}
+	}
+
+	version := models.MicroAppVersion{
+		MicroAppID:          appID,
+		Version:             req.Version,
+		Build:               req.Build,
+		ReleaseNotes:        req.ReleaseNotes,
+		IconURL:             req.IconURL,
+		DownloadURL:         req.DownloadURL,
+		RequiredPermissions: models.StringList(req.RequiredPermissions),
+		Active:              models.StatusActive,
+		CreatedBy:           userEmail,
+		UpdatedBy:           &userEmail,
+	}
+	columns := []string{"download_url", "active", "updated_by", "updated_at"}
+	if req.ReleaseNotes != nil {
+		columns = append(columns, "release_notes")
+	}
+	if req.IconURL != nil {
+		columns = append(columns, "icon_url")
+	}
+	if req.RequiredPermissions != nil {
+		columns = append(columns, "required_permissions")
+	}
+	if err := tx.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "micro_app_id"}, {Name: "version"}, {Name: "build"}},
+		DoUpdates: clause.AssignmentColumns(columns),
+	}).Create(&version).Error; err != nil {
+		return err
+	}
+
+	if schedule := models.ScheduleUpdates(req.PublishAt, req.ExpireAt, req.NotifyOnPublish, replace, now); len(schedule) > 0 {
+		if err := tx.Model(&models.MicroAppVersion{}).
+			Where("micro_app_id = ? AND version = ? AND build = ?", appID, req.Version, req.Build).
+			Updates(schedule).Error; err != nil {
+			return err
+		}
+	}
+
+	return requestPermissions(tx, appID, req.RequiredPermissions, userEmail)
+}
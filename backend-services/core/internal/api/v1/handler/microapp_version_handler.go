@@ -1,24 +1,45 @@
 package handler
 
 import (
+	"crypto/rand"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
 	"log/slog"
 	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
 
 	"go-backend/internal/api/v1/dto"
 	"go-backend/internal/auth"
+	"go-backend/internal/bundle"
+	"go-backend/internal/catalog"
+	"go-backend/internal/config"
 	"go-backend/internal/models"
 
+	fileservice "go-backend/plugins/file-service"
+
 	"github.com/go-chi/chi/v5"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	// Largest bundle accepted by the publish endpoint
+	maxBundleSize = 50 << 20 // 50MB
 )
 
 type MicroAppVersionHandler struct {
-	db *gorm.DB
+	db          *gorm.DB
+	cfg         *config.Config
+	fileService fileservice.FileService
 }
 
-func NewMicroAppVersionHandler(db *gorm.DB) *MicroAppVersionHandler {
-	return &MicroAppVersionHandler{db: db}
+func NewMicroAppVersionHandler(db *gorm.DB, cfg *config.Config, fileService fileservice.FileService) *MicroAppVersionHandler {
+	return &MicroAppVersionHandler{db: db, cfg: cfg, fileService: fileService}
 }
 
 // UpsertVersion handles creating or updating a version for a micro app
@@ -64,39 +85,451 @@ func (h *MicroAppVersionHandler) UpsertVersion(w http.ResponseWriter, r *http.Re
 		return
 	}
 
+	if !validSchedule(req.PublishAt, req.ExpireAt) {
+		http.Error(w, "expireAt must be after publishAt", http.StatusBadRequest)
+		return
+	}
+
+	if h.cfg.CatalogReviewRequired {
+		http.Error(w, catalogReviewRequiredMessage, http.StatusForbidden)
+		return
+	}
+
+	// With If-Match the version is only written if the app is unchanged since the client read it
+	expected, ok := readIfMatch(w, r)
+	if !ok {
+		return
+	}
+
 	version := models.MicroAppVersion{}
-	result := h.db.Where("micro_app_id = ? AND version = ? AND build = ?", appID, req.Version, req.Build).
-		Assign(models.MicroAppVersion{
-			ReleaseNotes: req.ReleaseNotes,
-			IconURL:      req.IconURL,
-			DownloadURL:  req.DownloadURL,
-			Active:       models.StatusActive,
-			UpdatedBy:    &userEmail,
-		}).
-		Attrs(models.MicroAppVersion{
-			MicroAppID: appID,
-			Version:    req.Version,
-			Build:      req.Build,
-			CreatedBy:  userEmail,
-		}).FirstOrCreate(&version)
-
-	if result.Error != nil {
-		slog.Error("Failed to upsert version", "error", result.Error, "appID", appID, "version", req.Version, "build", req.Build)
-		http.Error(w, "failed to upsert version", http.StatusInternalServerError)
-		return
-	}
-
-	if err := writeJSON(w, http.StatusCreated, dto.MicroAppVersionResponse{
-		ID:           version.ID,
-		MicroAppID:   version.MicroAppID,
-		Version:      version.Version,
-		Build:        version.Build,
-		ReleaseNotes: version.ReleaseNotes,
-		IconURL:      version.IconURL,
-		DownloadURL:  version.DownloadURL,
-		Active:       version.Active,
-	}); err != nil {
+	err := h.db.Transaction(func(tx *gorm.DB) error {
+		app, err := lockMicroApp(tx, appID, expected)
+		if err != nil {
+			return err
+		}
+		if app == nil {
+			return gorm.ErrRecordNotFound
+		}
+
+		if err := upsertVersion(tx, appID, req, userEmail, false, time.Now()); err != nil {
+			return err
+		}
+
+		if _, err := catalog.Touch(tx, appID); err != nil {
+			return err
+		}
+
+		if err := tx.Where("micro_app_id = ? AND version = ? AND build = ?", appID, req.Version, req.Build).
+			First(&version).Error; err != nil {
+			return err
+		}
+		return tx.Where("micro_app_id = ?", appID).First(&microApp).Error
+	})
+
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			http.Error(w, "micro app not found", http.StatusNotFound)
+			return
+		}
+		writeUpsertError(w, err, "failed to upsert version")
+		slog.Error("Failed to upsert version", "error", err, "appID", appID, "version", req.Version, "build", req.Build)
+		return
+	}
+
+	w.Header().Set(headerETag, rowETag(microApp.RowVersion))
+	if err := writeJSON(w, http.StatusCreated, toVersionResponse(version)); err != nil {
+		slog.Error("Failed to write JSON response", "error", err)
+		http.Error(w, "failed to write response", http.StatusInternalServerError)
+	}
+}
+
+// PublishVersion handles publishing a micro app bundle in one step. The request body is the zip
+// itself; its manifest.json supplies the version, build, icon and required permissions. The bundle
+// and icon are stored through the FileService and the version row is created atomically.
+// When catalog review is required the version is staged as a draft catalog change instead.
+func (h *MicroAppVersionHandler) PublishVersion(w http.ResponseWriter, r *http.Request) {
+	userInfo, ok := auth.GetUserInfo(r.Context())
+	if !ok {
+		http.Error(w, "user info not found in context", http.StatusUnauthorized)
+		return
+	}
+	userEmail := userInfo.Email
+
+	appID := chi.URLParam(r, "appID")
+	if appID == "" {
+		http.Error(w, "missing micro_app_id", http.StatusBadRequest)
+		return
+	}
+
+	var microApp models.MicroApp
+	if err := h.db.Where("micro_app_id = ?", appID).First(&microApp).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			http.Error(w, "micro app not found", http.StatusNotFound)
+		} else {
+			slog.Error("Failed to fetch micro app", "error", err, "appID", appID)
+			http.Error(w, "failed to fetch micro app", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	contentType := r.Header.Get("Content-Type")
+	if contentType != "application/zip" && contentType != "application/octet-stream" {
+		http.Error(w, "Content-Type must be application/zip", http.StatusUnsupportedMediaType)
+		return
+	}
+
+	limitRequestBody(w, r, maxBundleSize)
+	content, err := io.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, "bundle is missing or too large", http.StatusBadRequest)
+		return
+	}
+
+	pkg, err := bundle.Parse(content)
+	if err != nil {
+		if errors.Is(err, bundle.ErrInvalidBundle) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		slog.Error("Failed to parse bundle", "error", err, "appID", appID)
+		http.Error(w, "failed to publish version", http.StatusInternalServerError)
+		return
+	}
+	manifest := pkg.Manifest
+
+	for _, p := range manifest.Permissions {
+		if !models.KnownCapabilities[p] {
+			http.Error(w, fmt.Sprintf("manifest requests unknown permission %q", p), http.StatusBadRequest)
+			return
+		}
+	}
+
+	if manifest.ID != appID {
+		http.Error(w, fmt.Sprintf("manifest id %q does not match micro app %q", manifest.ID, appID), http.StatusConflict)
+		return
+	}
+
+	// Fail fast before uploading anything; the check is repeated inside the transaction
+	if conflict, err := versionConflict(h.db, appID, manifest); err != nil {
+		slog.Error("Failed to check existing versions", "error", err, "appID", appID)
+		http.Error(w, "failed to publish version", http.StatusInternalServerError)
+		return
+	} else if conflict != "" {
+		http.Error(w, conflict, http.StatusConflict)
+		return
+	}
+
+	// Under review the build must also not be waiting in another open change
+	if h.cfg.CatalogReviewRequired {
+		if conflict, err := pendingVersionConflict(h.db, appID, manifest); err != nil {
+			slog.Error("Failed to check pending catalog changes", "error", err, "appID", appID)
+			http.Error(w, "failed to publish version", http.StatusInternalServerError)
+			return
+		} else if conflict != "" {
+			http.Error(w, conflict, http.StatusConflict)
+			return
+		}
+	}
+
+	// The random suffix keeps concurrent or repeated uploads of the same build from overwriting
+	// (and, when they fail, deleting) each other's files
+	baseName := fmt.Sprintf("%s-%s-%d-%s", appID, manifest.Version, manifest.Build, strings.ToLower(rand.Text()))
+	bundleName := baseName + ".zip"
+	iconName := baseName + "-icon" + pkg.IconExt
+
+	downloadURL, err := h.fileService.UploadFile(bundleName, content)
+	if err != nil {
+		slog.Error("Failed to upload bundle", "error", err, "appID", appID, "fileName", bundleName)
+		http.Error(w, "failed to publish version", http.StatusInternalServerError)
+		return
+	}
+	iconURL, err := h.fileService.UploadFile(iconName, pkg.Icon)
+	if err != nil {
+		slog.Error("Failed to upload icon", "error", err, "appID", appID, "fileName", iconName)
+		h.discardFiles(appID, bundleName)
+		http.Error(w, "failed to publish version", http.StatusInternalServerError)
+		return
+	}
+	// Recorded so deleting the app removes exactly the files stored for it
+	if err := h.db.Create(&[]models.MicroAppStoredFile{
+		{FileName: bundleName, MicroAppID: appID},
+		{FileName: iconName, MicroAppID: appID},
+	}).Error; err != nil {
+		slog.Error("Failed to record stored files", "error", err, "appID", appID)
+		h.discardFiles(appID, bundleName, iconName)
+		http.Error(w, "failed to publish version", http.StatusInternalServerError)
+		return
+	}
+
+	version := models.MicroAppVersion{
+		MicroAppID:          appID,
+		Version:             manifest.Version,
+		Build:               manifest.Build,
+		ReleaseNotes:        manifest.ReleaseNotes,
+		IconURL:             &iconURL,
+		DownloadURL:         downloadURL,
+		RequiredPermissions: models.StringList(manifest.Permissions),
+		Active:              models.StatusActive,
+		CreatedBy:           userEmail,
+	}
+
+	// Under review the bundle is staged as a draft change on top of the live app state
+	if h.cfg.CatalogReviewRequired {
+		h.draftVersion(w, appID, version, bundleName, iconName)
+		return
+	}
+
+	var conflict string
+	err = h.db.Transaction(func(tx *gorm.DB) error {
+		// Lock the app row so concurrent publishes of the same app are serialised
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("micro_app_id = ?", appID).First(&models.MicroApp{}).Error; err != nil {
+			return err
+		}
+
+		c, err := versionConflict(tx, appID, manifest)
+		if err != nil {
+			return err
+		}
+		if c != "" {
+			conflict = c
+			return errVersionConflict
+		}
+
+		if err := tx.Create(&version).Error; err != nil {
+			return err
+		}
+
+		if err := requestPermissions(tx, appID, manifest.Permissions, userEmail); err != nil {
+			return err
+		}
+
+		_, err = catalog.Touch(tx, appID)
+		return err
+	})
+
+	if err != nil {
+		h.discardFiles(appID, bundleName, iconName)
+		if errors.Is(err, errVersionConflict) {
+			http.Error(w, conflict, http.StatusConflict)
+			return
+		}
+		slog.Error("Failed to publish version", "error", err, "appID", appID, "version", manifest.Version, "build", manifest.Build)
+		http.Error(w, "failed to publish version", http.StatusInternalServerError)
+		return
+	}
+
+	// The version is published at this point; a failed diff only degrades the response
+	response := dto.PublishMicroAppVersionResponse{Version: toVersionResponse(version)}
+	previous, err := previousVersion(h.db, appID, version.Build)
+	if err == nil {
+		response.Diff, err = diffVersions(h.db, appID, previous, version)
+	}
+	if err != nil {
+		slog.Error("Failed to diff published version", "error", err, "appID", appID, "build", version.Build)
+	}
+
+	if err := writeJSON(w, http.StatusCreated, response); err != nil {
 		slog.Error("Failed to write JSON response", "error", err)
 		http.Error(w, "failed to write response", http.StatusInternalServerError)
 	}
 }
+
+// DiffVersions handles comparing two builds of a micro app. Query parameters:
+// to (build, defaults to the latest active build) and from (build, defaults to the build before to).
+func (h *MicroAppVersionHandler) DiffVersions(w http.ResponseWriter, r *http.Request) {
+	appID := chi.URLParam(r, "appID")
+	if appID == "" {
+		http.Error(w, "missing micro_app_id", http.StatusBadRequest)
+		return
+	}
+
+	query := r.URL.Query()
+	var to models.MicroAppVersion
+	toQuery := h.db.Where("micro_app_id = ? AND active = ?", appID, models.StatusActive)
+	if raw := query.Get("to"); raw != "" {
+		build, err := strconv.Atoi(raw)
+		if err != nil {
+			http.Error(w, "invalid to build", http.StatusBadRequest)
+			return
+		}
+		toQuery = toQuery.Where("build = ?", build)
+	}
+	if err := toQuery.Order("build DESC").First(&to).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			http.Error(w, "version not found", http.StatusNotFound)
+			return
+		}
+		slog.Error("Failed to fetch version", "error", err, "appID", appID)
+		http.Error(w, "failed to diff versions", http.StatusInternalServerError)
+		return
+	}
+
+	var from *models.MicroAppVersion
+	if raw := query.Get("from"); raw != "" {
+		build, err := strconv.Atoi(raw)
+		if err != nil {
+			http.Error(w, "invalid from build", http.StatusBadRequest)
+			return
+		}
+		var v models.MicroAppVersion
+		if err := h.db.Where("micro_app_id = ? AND build = ?", appID, build).First(&v).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				http.Error(w, "version not found", http.StatusNotFound)
+				return
+			}
+			slog.Error("Failed to fetch version", "error", err, "appID", appID)
+			http.Error(w, "failed to diff versions", http.StatusInternalServerError)
+			return
+		}
+		from = &v
+	} else {
+		var err error
+		if from, err = previousVersion(h.db, appID, to.Build); err != nil {
+			slog.Error("Failed to fetch previous version", "error", err, "appID", appID)
+			http.Error(w, "failed to diff versions", http.StatusInternalServerError)
+			return
+		}
+	}
+
+	diff, err := diffVersions(h.db, appID, from, to)
+	if err != nil {
+		slog.Error("Failed to diff versions", "error", err, "appID", appID)
+		http.Error(w, "failed to diff versions", http.StatusInternalServerError)
+		return
+	}
+
+	if err := writeJSON(w, http.StatusOK, diff); err != nil {
+		slog.Error("Failed to write JSON response", "error", err)
+		http.Error(w, "failed to write response", http.StatusInternalServerError)
+	}
+}
+
+// previousVersion returns the active build published before the given one, or nil if there is none
+func previousVersion(db *gorm.DB, appID string, build int) (*models.MicroAppVersion, error) {
+	var v models.MicroAppVersion
+	err := db.Where("micro_app_id = ? AND build < ? AND active = ?", appID, build, models.StatusActive).
+		Order("build DESC").First(&v).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &v, nil
+}
+
+// draftVersion opens a catalog change that adds the uploaded version to the app
+func (h *MicroAppVersionHandler) draftVersion(w http.ResponseWriter, appID string, version models.MicroAppVersion, fileNames ...string) {
+	// Read before the app, so an edit in between makes publishing the change fail rather than be lost
+	base, err := liveRowVersion(h.db, appID)
+	if err != nil {
+		slog.Error("Failed to fetch micro app", "error", err, "appID", appID)
+		h.discardFiles(appID, fileNames...)
+		http.Error(w, "failed to publish version", http.StatusInternalServerError)
+		return
+	}
+
+	req, err := loadMicroAppRequest(h.db, appID)
+	if err != nil {
+		slog.Error("Failed to load micro app for draft", "error", err, "appID", appID)
+		h.discardFiles(appID, fileNames...)
+		http.Error(w, "failed to publish version", http.StatusInternalServerError)
+		return
+	}
+
+	req.Versions = append(req.Versions, dto.CreateMicroAppVersionRequest{
+		Version:             version.Version,
+		Build:               version.Build,
+		ReleaseNotes:        version.ReleaseNotes,
+		IconURL:             version.IconURL,
+		DownloadURL:         version.DownloadURL,
+		RequiredPermissions: version.RequiredPermissions,
+	})
+
+	comment := fmt.Sprintf("bundle upload of version %s (build %d)", version.Version, version.Build)
+	change, err := createDraftChange(h.db, &req, base, version.CreatedBy, false, nil, &comment, fileNames...)
+	if err != nil {
+		slog.Error("Failed to create catalog change", "error", err, "appID", appID, "build", version.Build)
+		h.discardFiles(appID, fileNames...)
+		http.Error(w, "failed to publish version", http.StatusInternalServerError)
+		return
+	}
+
+	if err := writeJSON(w, http.StatusAccepted, toCatalogChangeResponse(change)); err != nil {
+		slog.Error("Failed to write JSON response", "error", err)
+		http.Error(w, "failed to write response", http.StatusInternalServerError)
+	}
+}
+
+var errVersionConflict = errors.New("version conflict")
+
+// versionConflict reports why the manifest cannot be published next to the app's existing versions,
+// or "" if it can. Builds are unique per app, and a version string cannot be republished.
+func versionConflict(db *gorm.DB, appID string, manifest bundle.Manifest) (string, error) {
+	var existing []models.MicroAppVersion
+	if err := db.Where("micro_app_id = ? AND (build = ? OR version = ?)", appID, manifest.Build, manifest.Version).
+		Find(&existing).Error; err != nil {
+		return "", err
+	}
+	for _, v := range existing {
+		if v.Build == manifest.Build {
+			return fmt.Sprintf("build %d already exists for this micro app (version %s)", v.Build, v.Version), nil
+		}
+	}
+	if len(existing) > 0 {
+		return fmt.Sprintf("version %s already exists for this micro app", manifest.Version), nil
+	}
+	return "", nil
+}
+
+// pendingVersionConflict reports why the manifest cannot be staged next to the open catalog
+// changes of the app, or "" if it can. Publishing either change would otherwise overwrite the
+// other's build.
+func pendingVersionConflict(db *gorm.DB, appID string, manifest bundle.Manifest) (string, error) {
+	var changes []models.CatalogChange
+	if err := db.Where("micro_app_id = ? AND status IN ?", appID, openCatalogChangeStatuses).
+		Find(&changes).Error; err != nil {
+		return "", err
+	}
+	for _, change := range changes {
+		var req dto.CreateMicroAppRequest
+		if err := json.Unmarshal(change.Payload, &req); err != nil {
+			return "", err
+		}
+		if slices.ContainsFunc(req.Versions, func(v dto.CreateMicroAppVersionRequest) bool {
+			return v.Build == manifest.Build || v.Version == manifest.Version
+		}) {
+			return fmt.Sprintf("version %s (build %d) conflicts with catalog change %d", manifest.Version, manifest.Build, change.ID), nil
+		}
+	}
+	return "", nil
+}
+
+// Best-effort removal of files uploaded for a publish that did not go through
+func (h *MicroAppVersionHandler) discardFiles(appID string, fileNames ...string) {
+	if err := h.db.Where("file_name IN ?", fileNames).Delete(&models.MicroAppStoredFile{}).Error; err != nil {
+		slog.Warn("Failed to delete stored file records", "error", err, "appID", appID)
+	}
+	for _, fileName := range fileNames {
+		if err := h.fileService.DeleteFile(fileName); err != nil {
+			slog.Warn("Failed to delete uploaded file", "error", err, "appID", appID, "fileName", fileName)
+		}
+	}
+}
+
+func toVersionResponse(v models.MicroAppVersion) dto.MicroAppVersionResponse {
+	return dto.MicroAppVersionResponse{
+		ID:                  v.ID,
+		MicroAppID:          v.MicroAppID,
+		Version:             v.Version,
+		Build:               v.Build,
+		ReleaseNotes:        v.ReleaseNotes,
+		IconURL:             v.IconURL,
+		DownloadURL:         v.DownloadURL,
+		RequiredPermissions: v.RequiredPermissions,
+		Active:              v.Active,
+		PublishAt:           v.PublishAt,
+		ExpireAt:            v.ExpireAt,
+	}
+}
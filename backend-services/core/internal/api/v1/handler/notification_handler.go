@@ -5,22 +5,29 @@ import (
 	"errors"
 	"log/slog"
 	"net/http"
+	"strconv"
+	"time"
 
 	"go-backend/internal/api/v1/dto"
 	"go-backend/internal/auth"
+	"go-backend/internal/config"
 	"go-backend/internal/models"
-	"go-backend/internal/services"
+	"go-backend/internal/notification"
+	notificationservice "go-backend/plugins/notification-service"
 
+	"github.com/go-chi/chi/v5"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 const (
-	// Notification Status
-	statusSent           = "sent"
-	statusPartialFailure = "partial_failure"
+	// Layout of a scheduled send time given without an offset
+	localTimeLayout = "2006-01-02T15:04:05"
 
 	// Data Keys
-	dataKeyMicroappID = "microappId"
+	dataKeyDeepLink     = "deepLink"
+	dataKeyDeepLinkApp  = "deepLinkAppId"
+	dataKeyDeepLinkPath = "deepLinkPath"
 
 	// FCM Config
 	fcmSoundDefault   = "default"
@@ -29,14 +36,17 @@ const (
 )
 
 type NotificationHandler struct {
-	db         *gorm.DB
-	fcmService services.NotificationService
+	db                  *gorm.DB
+	notificationService notificationservice.NotificationService
+	// Send limits of micro apps without overrides
+	quotas notification.Limits
 }
 
-func NewNotificationHandler(db *gorm.DB, fcmService services.NotificationService) *NotificationHandler {
+func NewNotificationHandler(db *gorm.DB, cfg *config.Config, notificationService notificationservice.NotificationService) *NotificationHandler {
 	return &NotificationHandler{
-		db:         db,
-		fcmService: fcmService,
+		db:                  db,
+		notificationService: notificationService,
+		quotas:              notification.DefaultLimits(cfg),
 	}
 }
 
@@ -64,30 +74,86 @@ func (h *NotificationHandler) RegisterDeviceToken(w http.ResponseWriter, r *http
 		return
 	}
 
+	deviceID := req.DeviceID
+	if deviceID == "" {
+		deviceID = models.LegacyDeviceID(req.Platform)
+	}
+
 	deviceToken := models.DeviceToken{
 		UserEmail:   req.Email,
+		DeviceID:    deviceID,
 		DeviceToken: req.Token,
 		Platform:    req.Platform,
+		DeviceName:  req.DeviceName,
+		AppVersion:  req.AppVersion,
+		LastSeenAt:  time.Now(),
 		IsActive:    true,
 	}
-	result := h.db.Where("user_email = ? AND platform = ?", req.Email, req.Platform).
-		Assign(models.DeviceToken{
-			DeviceToken: req.Token,
-			IsActive:    true,
-		}).
-		FirstOrCreate(&deviceToken)
+	err := h.db.Transaction(func(tx *gorm.DB) error {
+		// One token per user and device; re-registering replaces it
+		if err := tx.Clauses(clause.OnConflict{
+			Columns: []clause.Column{{Name: "user_email"}, {Name: "device_id"}},
+			DoUpdates: clause.AssignmentColumns([]string{
+				"device_token", "platform", "device_name", "app_version", "last_seen_at", "is_active", "updated_at",
+			}),
+		}).Create(&deviceToken).Error; err != nil {
+			return err
+		}
 
-	if result.Error != nil {
-		slog.Error("Failed to register device token", "error", result.Error, "email", req.Email)
+		// A token belongs to one app install, so once another user signs in on it the
+		// previous user must stop receiving its pushes
+		return tx.Model(&models.DeviceToken{}).
+			Where("device_token = ? AND is_active = ?", req.Token, true).
+			Where("NOT (user_email = ? AND device_id = ?)", req.Email, deviceID).
+			Update("is_active", false).Error
+	})
+
+	if err != nil {
+		slog.Error("Failed to register device token", "error", err, "email", req.Email)
 		http.Error(w, "failed to register device token", http.StatusInternalServerError)
 		return
 	}
-	slog.Info("Device token registered successfully", "email", req.Email, "platform", req.Platform)
+	// Keep the user reachable by group notifications even if they rarely open the catalog
+	recordUserGroups(h.db, userInfo.Email, userInfo.Groups)
+
+	slog.Info("Device token registered successfully", "email", req.Email, "platform", req.Platform, "deviceID", deviceID)
 	w.WriteHeader(http.StatusCreated)
 }
 
+// UnregisterDeviceToken handles removing the logged-in user's token for one device, e.g. at
+// logout. The user's other devices keep receiving notifications.
+func (h *NotificationHandler) UnregisterDeviceToken(w http.ResponseWriter, r *http.Request) {
+	userInfo, ok := auth.GetUserInfo(r.Context())
+	if !ok {
+		http.Error(w, "user info not found in context", http.StatusUnauthorized)
+		return
+	}
+
+	deviceID := chi.URLParam(r, "deviceID")
+	if deviceID == "" {
+		http.Error(w, "missing device ID", http.StatusBadRequest)
+		return
+	}
+
+	result := h.db.Where("user_email = ? AND device_id = ?", userInfo.Email, deviceID).Delete(&models.DeviceToken{})
+	if result.Error != nil {
+		slog.Error("Failed to unregister device token", "error", result.Error, "email", userInfo.Email, "deviceID", deviceID)
+		http.Error(w, "failed to unregister device token", http.StatusInternalServerError)
+		return
+	}
+	if result.RowsAffected == 0 {
+		http.Error(w, "device token not found", http.StatusNotFound)
+		return
+	}
+
+	slog.Info("Device token unregistered", "email", userInfo.Email, "deviceID", deviceID)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// SendNotification handles queuing a notification to specific users. It answers 202 with the
+// job, which the notification worker sends in the background right away or at sendAt.
 func (h *NotificationHandler) SendNotification(w http.ResponseWriter, r *http.Request) {
-	if h.fcmService == nil {
+	if h.notificationService == nil {
 		http.Error(w, "notification service not available", http.StatusServiceUnavailable)
 		return
 	}
@@ -106,52 +172,261 @@ func (h *NotificationHandler) SendNotification(w http.ResponseWriter, r *http.Re
 	}
 
 	// in this context client id is the microapp id
-	microappID, err := h.getClientID(r)
+	microappID, err := getClientID(r)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusUnauthorized)
 		return
 	}
 
-	var deviceTokens []models.DeviceToken
-	if err := h.db.Where("user_email IN ? AND is_active = ?", req.UserEmails, true).Find(&deviceTokens).Error; err != nil {
-		slog.Error("Failed to fetch device tokens", "error", err)
-		http.Error(w, "failed to fetch device tokens", http.StatusInternalServerError)
+	sendAt, err := parseSendAt(req.SendAt, req.TimeZone, time.Now())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	data, ok := h.applyDeepLink(w, req.DeepLink, req.Data)
+	if !ok {
 		return
 	}
 
-	if len(deviceTokens) == 0 {
-		slog.Warn("No active device tokens found for users", "users", req.UserEmails)
-		writeJSON(w, http.StatusOK, dto.NotificationResponse{Success: 0, Failed: 0, Message: "No active device tokens found"})
+	payload := models.NotificationJobPayload{
+		UserEmails: req.UserEmails,
+		Title:      req.Title,
+		Body:       req.Body,
+		Data:       data,
+		Critical:   req.Critical,
+	}
+	if !h.applyTemplate(w, microappID, req.Template, &payload) {
+		return
+	}
+
+	h.enqueue(w, microappID, sendAt, req.TimeZone, payload)
+}
+
+// SendToGroups handles queuing a notification to every user in the given groups, except those
+// in an excluded group. Users are resolved from the groups recorded when they last fetched the
+// catalog or registered a device, so membership changes at the identity provider only show once
+// the user signs in again or notification.GroupMembershipTTL passes. Each user is notified once
+// however many of the groups they are in.
+func (h *NotificationHandler) SendToGroups(w http.ResponseWriter, r *http.Request) {
+	if h.notificationService == nil {
+		http.Error(w, "notification service not available", http.StatusServiceUnavailable)
 		return
 	}
+	if !validateContentType(w, r) {
+		return
+	}
+	limitRequestBody(w, r, 0)
 
-	tokens := make([]string, len(deviceTokens))
-	for i, dt := range deviceTokens {
-		tokens[i] = dt.DeviceToken
+	var req dto.SendToGroupsRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+	if !validateStruct(w, &req) {
+		return
 	}
-	dataStr := h.prepareFCMData(req.Data, microappID)
 
-	successCount, failureCount, err := h.fcmService.SendNotificationToMultiple(r.Context(), tokens, req.Title, req.Body, dataStr)
+	// in this context client id is the microapp id
+	microappID, err := getClientID(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
 
+	sendAt, err := parseSendAt(req.SendAt, req.TimeZone, time.Now())
 	if err != nil {
-		slog.Error("Failed to send notifications", "error", err)
-		http.Error(w, "failed to send notifications", http.StatusInternalServerError)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	data, ok := h.applyDeepLink(w, req.DeepLink, req.Data)
+	if !ok {
 		return
 	}
-	status := statusSent
 
-	if failureCount > 0 {
-		status = statusPartialFailure
+	payload := models.NotificationJobPayload{
+		Groups:        req.Groups,
+		ExcludeGroups: req.ExcludeGroups,
+		Title:         req.Title,
+		Body:          req.Body,
+		Data:          data,
+		Critical:      req.Critical,
+	}
+	if !h.applyTemplate(w, microappID, req.Template, &payload) {
+		return
 	}
-	h.logNotifications(req.UserEmails, req.Title, req.Body, microappID, status, req.Data)
-	slog.Info("Notifications sent", "success", successCount, "failed", failureCount, "microapp_id", microappID)
-	response := dto.NotificationResponse{Success: successCount, Failed: failureCount, Message: "Notifications sent successfully"}
-	writeJSON(w, http.StatusOK, response)
+
+	h.enqueue(w, microappID, sendAt, req.TimeZone, payload)
+}
+
+// GetJob handles reporting the status of a notification job queued by the calling micro app
+func (h *NotificationHandler) GetJob(w http.ResponseWriter, r *http.Request) {
+	microappID, err := getClientID(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+
+	jobID, err := strconv.ParseInt(chi.URLParam(r, "jobID"), 10, 64)
+	if err != nil {
+		http.Error(w, "invalid job ID", http.StatusBadRequest)
+		return
+	}
+
+	var job models.NotificationJob
+	if err := h.db.Where("id = ? AND microapp_id = ?", jobID, microappID).First(&job).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			http.Error(w, "notification job not found", http.StatusNotFound)
+			return
+		}
+		slog.Error("Failed to fetch notification job", "error", err, "jobID", jobID)
+		http.Error(w, "failed to fetch notification job", http.StatusInternalServerError)
+		return
+	}
+
+	if err := writeJSON(w, http.StatusOK, toNotificationJobResponse(job)); err != nil {
+		slog.Error("Failed to write JSON response", "error", err)
+		http.Error(w, "failed to write response", http.StatusInternalServerError)
+	}
+}
+
+// GetScheduled handles listing the calling micro app's scheduled notifications that are not sent yet
+func (h *NotificationHandler) GetScheduled(w http.ResponseWriter, r *http.Request) {
+	microappID, err := getClientID(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+
+	var jobs []models.NotificationJob
+	if err := h.db.Where("microapp_id = ? AND status = ? AND send_at IS NOT NULL", microappID, models.NotificationJobQueued).
+		Order("send_at ASC, id ASC").
+		Find(&jobs).Error; err != nil {
+		slog.Error("Failed to fetch scheduled notifications", "error", err, "microapp_id", microappID)
+		http.Error(w, "failed to fetch scheduled notifications", http.StatusInternalServerError)
+		return
+	}
+
+	response := make([]dto.NotificationJobResponse, 0, len(jobs))
+	for _, job := range jobs {
+		response = append(response, toNotificationJobResponse(job))
+	}
+
+	if err := writeJSON(w, http.StatusOK, response); err != nil {
+		slog.Error("Failed to write JSON response", "error", err)
+		http.Error(w, "failed to write response", http.StatusInternalServerError)
+	}
+}
+
+// CancelScheduled handles cancelling a scheduled notification before it is sent
+func (h *NotificationHandler) CancelScheduled(w http.ResponseWriter, r *http.Request) {
+	microappID, err := getClientID(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+
+	jobID, err := strconv.ParseInt(chi.URLParam(r, "jobID"), 10, 64)
+	if err != nil {
+		http.Error(w, "invalid job ID", http.StatusBadRequest)
+		return
+	}
+
+	// The worker locks a job while claiming it, so a job is either cancelled or sent, never both
+	result := h.db.Model(&models.NotificationJob{}).
+		Where("id = ? AND microapp_id = ? AND status = ? AND send_at IS NOT NULL", jobID, microappID, models.NotificationJobQueued).
+		Updates(map[string]any{
+			"status":       models.NotificationJobCancelled,
+			"completed_at": time.Now(),
+		})
+	if result.Error != nil {
+		slog.Error("Failed to cancel scheduled notification", "error", result.Error, "jobID", jobID)
+		http.Error(w, "failed to cancel scheduled notification", http.StatusInternalServerError)
+		return
+	}
+
+	var job models.NotificationJob
+	if err := h.db.Where("id = ? AND microapp_id = ? AND send_at IS NOT NULL", jobID, microappID).First(&job).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			http.Error(w, "scheduled notification not found", http.StatusNotFound)
+			return
+		}
+		slog.Error("Failed to fetch notification job", "error", err, "jobID", jobID)
+		http.Error(w, "failed to cancel scheduled notification", http.StatusInternalServerError)
+		return
+	}
+	if result.RowsAffected == 0 {
+		http.Error(w, "scheduled notification is already "+job.Status, http.StatusConflict)
+		return
+	}
+	slog.Info("Scheduled notification cancelled", "jobID", jobID, "microapp_id", microappID)
+
+	if err := writeJSON(w, http.StatusOK, toNotificationJobResponse(job)); err != nil {
+		slog.Error("Failed to write JSON response", "error", err)
+		http.Error(w, "failed to write response", http.StatusInternalServerError)
+	}
+}
+
+// applyDeepLink resolves the optional deep link of a send request and adds it to the data.
+// It writes the error response and returns false when the link matches no registered pattern.
+func (h *NotificationHandler) applyDeepLink(w http.ResponseWriter, link string, data map[string]interface{}) (map[string]interface{}, bool) {
+	if link == "" {
+		return data, true
+	}
+	match, err := resolveDeepLink(h.db, link)
+	if err != nil && !errors.Is(err, errInvalidLink) {
+		slog.Error("Failed to resolve deep link", "error", err, "deepLink", link)
+		http.Error(w, "failed to resolve deep link", http.StatusInternalServerError)
+		return nil, false
+	}
+	if match == nil {
+		http.Error(w, "deep link does not match any registered pattern", http.StatusBadRequest)
+		return nil, false
+	}
+	return withDeepLink(data, link, match), true
+}
+
+// applyTemplate renders the optional template of a send request into the payload, in every
+// locale the template has, so later edits to the template do not change queued messages. It
+// writes the error response and returns false when the template is unknown or params are missing.
+func (h *NotificationHandler) applyTemplate(w http.ResponseWriter, microappID string, ref *dto.NotificationTemplateRef, payload *models.NotificationJobPayload) bool {
+	if ref == nil {
+		return true
+	}
+
+	var template models.NotificationTemplate
+	if err := h.db.Preload("Translations").
+		Where("microapp_id = ? AND template_key = ?", microappID, ref.Key).
+		First(&template).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			http.Error(w, "notification template not found", http.StatusBadRequest)
+			return false
+		}
+		slog.Error("Failed to fetch notification template", "error", err, "microapp_id", microappID, "template", ref.Key)
+		http.Error(w, "failed to render notification template", http.StatusInternalServerError)
+		return false
+	}
+
+	messages, err := notification.Render(template, ref.Params)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return false
+	}
+
+	payload.Template = template.TemplateKey
+	payload.DefaultLocale = template.DefaultLocale
+	payload.Translations = messages
+	payload.Title = messages[template.DefaultLocale].Title
+	payload.Body = messages[template.DefaultLocale].Body
+	return true
 }
 
 // helper functions
 
-func (h *NotificationHandler) getClientID(r *http.Request) (string, error) {
+// getClientID returns the client ID of a service-authenticated request, which for notification
+// endpoints is the calling micro app's ID
+func getClientID(r *http.Request) (string, error) {
 	serviceInfo, ok := auth.GetServiceInfo(r.Context())
 	if !ok {
 		return "", errors.New("service info not found in context")
@@ -164,38 +439,105 @@ func (h *NotificationHandler) getClientID(r *http.Request) (string, error) {
 	return serviceInfo.ClientID, nil
 }
 
-func (h *NotificationHandler) prepareFCMData(data map[string]interface{}, microappID string) map[string]string {
-	// Converts the given data map to a map of string to string, marshalling non-string values to JSON strings.
-	// Also adds the microappID to the data if it's not empty.
-	dataStr := make(map[string]string)
+// withDeepLink adds the link and its resolved target to the notification data, so the host app
+// can open the right micro app screen without resolving the link itself
+func withDeepLink(data map[string]interface{}, link string, match *deepLinkMatch) map[string]interface{} {
+	if data == nil {
+		data = make(map[string]interface{})
+	}
+	data[dataKeyDeepLink] = link
+	data[dataKeyDeepLinkApp] = match.AppID
+	data[dataKeyDeepLinkPath] = match.Path
+	return data
+}
 
-	for k, v := range data {
-		if str, ok := v.(string); ok {
-			dataStr[k] = str
-		} else {
-			if bytes, err := json.Marshal(v); err == nil {
-				dataStr[k] = string(bytes)
-			}
+// parseSendAt reads the optional send time of a notification. A time without an offset is read
+// in timeZone. It returns nil when the notification is to be sent right away.
+func parseSendAt(sendAt *string, timeZone string, now time.Time) (*time.Time, error) {
+	if sendAt == nil || *sendAt == "" {
+		if timeZone != "" {
+			return nil, errors.New("timeZone requires sendAt")
 		}
+		return nil, nil
 	}
-	if microappID != "" {
-		dataStr[dataKeyMicroappID] = microappID
+
+	var at time.Time
+	if timeZone == "" {
+		t, err := time.Parse(time.RFC3339, *sendAt)
+		if err != nil {
+			return nil, errors.New("sendAt must be an RFC 3339 timestamp, or a local time with timeZone")
+		}
+		at = t
+	} else {
+		loc, err := time.LoadLocation(timeZone)
+		if err != nil {
+			return nil, errors.New("invalid timeZone")
+		}
+		t, err := time.ParseInLocation(localTimeLayout, *sendAt, loc)
+		if err != nil {
+			return nil, errors.New("sendAt must be a local time such as 2026-10-20T09:00:00 when timeZone is set")
+		}
+		at = t
 	}
-	return dataStr
+
+	if !at.After(now) {
+		return nil, errors.New("sendAt must be in the future")
+	}
+	at = at.UTC()
+	return &at, nil
 }
 
-func (h *NotificationHandler) logNotifications(userEmails []string, title, body, microappID, status string, data map[string]interface{}) {
-	for _, email := range userEmails {
-		log := models.NotificationLog{
-			UserEmail:  email,
-			Title:      &title,
-			Body:       &body,
-			Data:       data,
-			Status:     &status,
-			MicroappID: &microappID,
-		}
-		if err := h.db.Create(&log).Error; err != nil {
-			slog.Error("Failed to log notification", "error", err, "email", email)
+// enqueue counts a notification against the micro app's quotas and queues it for the
+// notification worker, to be sent at sendAt or right away. It answers with the job, which the
+// caller can poll for the outcome, or with 429 when the send would go over a quota.
+func (h *NotificationHandler) enqueue(w http.ResponseWriter, microappID string, sendAt *time.Time, timeZone string, payload models.NotificationJobPayload) {
+	job, err := notification.Enqueue(h.db, microappID, payload, sendAt, timeZone, h.quotas)
+	if err != nil {
+		var exceeded *notification.QuotaExceededError
+		if errors.As(err, &exceeded) {
+			slog.Warn("Notification quota exceeded", "microapp_id", microappID, "limit", exceeded.Limit, "max", exceeded.Max)
+			writeQuotaExceeded(w, exceeded)
+			return
 		}
+		slog.Error("Failed to queue notification", "error", err, "microapp_id", microappID)
+		http.Error(w, "failed to queue notification", http.StatusInternalServerError)
+		return
+	}
+	slog.Info("Notification queued", "jobID", job.ID, "microapp_id", microappID, "sendAt", sendAt)
+
+	if err := writeJSON(w, http.StatusAccepted, toNotificationJobResponse(job)); err != nil {
+		slog.Error("Failed to write JSON response", "error", err)
+		http.Error(w, "failed to write response", http.StatusInternalServerError)
+	}
+}
+
+func toNotificationJobResponse(job models.NotificationJob) dto.NotificationJobResponse {
+	// Only the title is shown; the payload is kept as the caller sent it
+	var payload models.NotificationJobPayload
+	if err := json.Unmarshal(job.Payload, &payload); err != nil {
+		slog.Warn("Failed to decode notification job payload", "error", err, "jobID", job.ID)
+	}
+
+	response := dto.NotificationJobResponse{
+		JobID:       job.ID,
+		Status:      job.Status,
+		Title:       payload.Title,
+		Template:    payload.Template,
+		Attempts:    job.Attempts,
+		SendAt:      job.SendAt,
+		TimeZone:    job.TimeZone,
+		Recipients:  job.Recipients,
+		Success:     job.SuccessCount,
+		Failed:      job.FailureCount,
+		Deactivated: job.DeactivatedCount,
+		Suppressed:  job.SuppressedCount,
+		Deferred:    job.DeferredCount,
+		LastError:   job.LastError,
+		CreatedAt:   job.CreatedAt,
+		CompletedAt: job.CompletedAt,
+	}
+	if job.Status == models.NotificationJobRetrying {
+		response.NextAttemptAt = &job.NextAttemptAt
 	}
+	return response
 }
@@ -0,0 +1,170 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"go-backend/internal/auth"
+	"go-backend/internal/config"
+	"go-backend/internal/models"
+	"go-backend/internal/testdb"
+
+	"github.com/go-chi/chi/v5"
+	"gorm.io/gorm"
+)
+
+func TestParseSendAt(t *testing.T) {
+	now := time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)
+	strp := func(s string) *string { return &s }
+
+	tests := []struct {
+		name      string
+		sendAt    *string
+		timeZone  string
+		want      *time.Time
+		wantError bool
+	}{
+		{name: "send now", sendAt: nil},
+		{name: "empty send time", sendAt: strp("")},
+		{name: "time zone without send time", timeZone: "Europe/Paris", wantError: true},
+		{
+			name:   "RFC 3339 with offset",
+			sendAt: strp("2026-10-18T15:00:00+02:00"),
+			want:   ptrTime(time.Date(2026, 10, 18, 13, 0, 0, 0, time.UTC)),
+		},
+		{
+			name:     "local time in time zone",
+			sendAt:   strp("2026-10-19T09:00:00"),
+			timeZone: "Asia/Colombo",
+			want:     ptrTime(time.Date(2026, 10, 19, 3, 30, 0, 0, time.UTC)),
+		},
+		{name: "local time without time zone", sendAt: strp("2026-10-19T09:00:00"), wantError: true},
+		{name: "offset with time zone", sendAt: strp("2026-10-19T09:00:00Z"), timeZone: "Asia/Colombo", wantError: true},
+		{name: "unknown time zone", sendAt: strp("2026-10-19T09:00:00"), timeZone: "Mars/Olympus", wantError: true},
+		{name: "in the past", sendAt: strp("2026-10-18T11:00:00Z"), wantError: true},
+		{name: "right now", sendAt: strp("2026-10-18T12:00:00Z"), wantError: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseSendAt(tt.sendAt, tt.timeZone, now)
+			if tt.wantError {
+				if err == nil {
+					t.Fatalf("got %v, want an error", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parseSendAt: %v", err)
+			}
+			switch {
+			case tt.want == nil && got != nil:
+				t.Errorf("got %v, want nil", got)
+			case tt.want != nil && (got == nil || !got.Equal(*tt.want) || got.Location() != time.UTC):
+				t.Errorf("got %v, want %v in UTC", got, tt.want)
+			}
+		})
+	}
+}
+
+func ptrTime(t time.Time) *time.Time {
+	return &t
+}
+
+// registerDevice registers a device token as the given user and returns the response status
+func registerDevice(h *NotificationHandler, email, body string) int {
+	r := httptest.NewRequest(http.MethodPost, "/device-tokens", strings.NewReader(body))
+	r.Header.Set("Content-Type", "application/json")
+	r = auth.SetUserInfo(r, &auth.CustomJwtPayload{Email: email})
+	w := httptest.NewRecorder()
+	h.RegisterDeviceToken(w, r)
+	return w.Code
+}
+
+func unregisterDevice(h *NotificationHandler, email, deviceID string) int {
+	routeCtx := chi.NewRouteContext()
+	routeCtx.URLParams.Add("deviceID", deviceID)
+	r := httptest.NewRequest(http.MethodDelete, "/device-tokens/"+deviceID, nil)
+	r = r.WithContext(context.WithValue(r.Context(), chi.RouteCtxKey, routeCtx))
+	r = auth.SetUserInfo(r, &auth.CustomJwtPayload{Email: email})
+	w := httptest.NewRecorder()
+	h.UnregisterDeviceToken(w, r)
+	return w.Code
+}
+
+// activeTokens returns the active tokens of a user by device ID
+func activeTokens(t *testing.T, db *gorm.DB, email string) map[string]string {
+	t.Helper()
+	var devices []models.DeviceToken
+	if err := db.Where("user_email = ? AND is_active = ?", email, true).Find(&devices).Error; err != nil {
+		t.Fatal(err)
+	}
+	tokens := make(map[string]string, len(devices))
+	for _, d := range devices {
+		tokens[d.DeviceID] = d.DeviceToken
+	}
+	return tokens
+}
+
+func TestDeviceTokensPerDevice(t *testing.T) {
+	db := testdb.Open(t)
+	h := NewNotificationHandler(db, &config.Config{}, nil)
+
+	for _, body := range []string{
+		`{"email":"a@example.com","token":"phone-1","platform":"android","deviceId":"phone"}`,
+		`{"email":"a@example.com","token":"tablet-1","platform":"android","deviceId":"tablet"}`,
+		// Re-registering a device replaces its token
+		`{"email":"a@example.com","token":"phone-2","platform":"android","deviceId":"phone"}`,
+		// A client without device IDs keeps one token per platform
+		`{"email":"a@example.com","token":"legacy-1","platform":"ios"}`,
+	} {
+		if code := registerDevice(h, "a@example.com", body); code != http.StatusCreated {
+			t.Fatalf("register %s = %d, want 201", body, code)
+		}
+	}
+	tokens := activeTokens(t, db, "a@example.com")
+	if len(tokens) != 3 || tokens["phone"] != "phone-2" || tokens["tablet"] != "tablet-1" || tokens[models.LegacyDeviceID("ios")] != "legacy-1" {
+		t.Fatalf("tokens = %v, want phone-2, tablet-1 and the legacy iOS token", tokens)
+	}
+
+	if code := registerDevice(h, "a@example.com", `{"email":"b@example.com","token":"x","platform":"ios"}`); code != http.StatusForbidden {
+		t.Errorf("register for another user = %d, want 403", code)
+	}
+
+	// Another user signing in on the tablet takes its token over
+	if code := registerDevice(h, "b@example.com", `{"email":"b@example.com","token":"tablet-1","platform":"android","deviceId":"tablet-b"}`); code != http.StatusCreated {
+		t.Fatalf("register for b = %d, want 201", code)
+	}
+	if _, ok := activeTokens(t, db, "a@example.com")["tablet"]; ok {
+		t.Error("the tablet token still reaches its previous user")
+	}
+}
+
+func TestUnregisterDevice(t *testing.T) {
+	db := testdb.Open(t)
+	h := NewNotificationHandler(db, &config.Config{}, nil)
+	for _, device := range []string{"phone", "tablet"} {
+		body := `{"email":"a@example.com","token":"` + device + `-1","platform":"android","deviceId":"` + device + `"}`
+		if code := registerDevice(h, "a@example.com", body); code != http.StatusCreated {
+			t.Fatalf("register %s = %d, want 201", device, code)
+		}
+	}
+
+	if code := unregisterDevice(h, "b@example.com", "phone"); code != http.StatusNotFound {
+		t.Errorf("unregister another user's device = %d, want 404", code)
+	}
+	if code := unregisterDevice(h, "a@example.com", "phone"); code != http.StatusNoContent {
+		t.Fatalf("unregister = %d, want 204", code)
+	}
+	if code := unregisterDevice(h, "a@example.com", "phone"); code != http.StatusNotFound {
+		t.Errorf("unregister twice = %d, want 404", code)
+	}
+
+	// Logging out on one device leaves the others registered
+	if tokens := activeTokens(t, db, "a@example.com"); len(tokens) != 1 || tokens["tablet"] != "tablet-1" {
+		t.Errorf("tokens = %v, want only the tablet", tokens)
+	}
+}
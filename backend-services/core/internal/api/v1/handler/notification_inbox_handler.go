@@ -0,0 +1,264 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"go-backend/internal/api/v1/dto"
+	"go-backend/internal/auth"
+	"go-backend/internal/models"
+
+	"github.com/go-chi/chi/v5"
+	"gorm.io/gorm"
+)
+
+const (
+	queryParamLimit  = "limit"
+	queryParamBefore = "before"
+	queryParamUnread = "unread"
+
+	inboxDefaultLimit = 20
+	inboxMaxLimit     = 100
+)
+
+// NotificationInboxHandler serves the logged-in user's notification centre, built on the
+// notification log written for every recipient of a push
+type NotificationInboxHandler struct {
+	db *gorm.DB
+}
+
+func NewNotificationInboxHandler(db *gorm.DB) *NotificationInboxHandler {
+	return &NotificationInboxHandler{db: db}
+}
+
+// GetAll handles listing the user's notifications, newest first. It supports ?limit=,
+// ?before=<cursor> for the next page, ?appId= and ?unread=true.
+func (h *NotificationInboxHandler) GetAll(w http.ResponseWriter, r *http.Request) {
+	userInfo, ok := auth.GetUserInfo(r.Context())
+	if !ok {
+		http.Error(w, "user info not found in context", http.StatusUnauthorized)
+		return
+	}
+
+	query := r.URL.Query()
+	limit := inboxDefaultLimit
+	if value := query.Get(queryParamLimit); value != "" {
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 1 || n > inboxMaxLimit {
+			http.Error(w, "limit must be between 1 and 100", http.StatusBadRequest)
+			return
+		}
+		limit = n
+	}
+
+	q := h.inbox(userInfo.Email, query.Get(queryParamAppID))
+	if value := query.Get(queryParamBefore); value != "" {
+		before, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			http.Error(w, "invalid cursor", http.StatusBadRequest)
+			return
+		}
+		q = q.Where("id < ?", before)
+	}
+	if query.Get(queryParamUnread) == "true" {
+		q = q.Where("read_at IS NULL")
+	}
+
+	// One extra row tells whether there is a next page
+	var logs []models.NotificationLog
+	if err := q.Order("id DESC").Limit(limit + 1).Find(&logs).Error; err != nil {
+		slog.Error("Failed to fetch notifications", "error", err, "email", userInfo.Email)
+		http.Error(w, "failed to fetch notifications", http.StatusInternalServerError)
+		return
+	}
+
+	var unread int64
+	if err := h.inbox(userInfo.Email, query.Get(queryParamAppID)).Where("read_at IS NULL").Count(&unread).Error; err != nil {
+		slog.Error("Failed to count unread notifications", "error", err, "email", userInfo.Email)
+		http.Error(w, "failed to fetch notifications", http.StatusInternalServerError)
+		return
+	}
+
+	response := dto.NotificationInboxResponse{
+		Notifications: make([]dto.InboxNotificationResponse, 0, min(len(logs), limit)),
+		UnreadCount:   unread,
+	}
+	if len(logs) > limit {
+		logs = logs[:limit]
+		response.NextCursor = &logs[limit-1].ID
+	}
+	for _, l := range logs {
+		response.Notifications = append(response.Notifications, toInboxNotificationResponse(l))
+	}
+
+	if err := writeJSON(w, http.StatusOK, response); err != nil {
+		slog.Error("Failed to write JSON response", "error", err)
+		http.Error(w, "failed to write response", http.StatusInternalServerError)
+	}
+}
+
+// GetUnreadCount handles counting the user's unread notifications, optionally for one ?appId=
+func (h *NotificationInboxHandler) GetUnreadCount(w http.ResponseWriter, r *http.Request) {
+	userInfo, ok := auth.GetUserInfo(r.Context())
+	if !ok {
+		http.Error(w, "user info not found in context", http.StatusUnauthorized)
+		return
+	}
+
+	var unread int64
+	if err := h.inbox(userInfo.Email, r.URL.Query().Get(queryParamAppID)).Where("read_at IS NULL").Count(&unread).Error; err != nil {
+		slog.Error("Failed to count unread notifications", "error", err, "email", userInfo.Email)
+		http.Error(w, "failed to count unread notifications", http.StatusInternalServerError)
+		return
+	}
+
+	if err := writeJSON(w, http.StatusOK, dto.UnreadCountResponse{UnreadCount: unread}); err != nil {
+		slog.Error("Failed to write JSON response", "error", err)
+		http.Error(w, "failed to write response", http.StatusInternalServerError)
+	}
+}
+
+// Update handles marking one of the user's notifications as read or unread
+func (h *NotificationInboxHandler) Update(w http.ResponseWriter, r *http.Request) {
+	userInfo, ok := auth.GetUserInfo(r.Context())
+	if !ok {
+		http.Error(w, "user info not found in context", http.StatusUnauthorized)
+		return
+	}
+
+	id, err := strconv.ParseInt(chi.URLParam(r, "notificationID"), 10, 64)
+	if err != nil {
+		http.Error(w, "invalid notification ID", http.StatusBadRequest)
+		return
+	}
+
+	if !validateContentType(w, r) {
+		return
+	}
+
+	limitRequestBody(w, r, 0) // 1MB default limit
+	var req dto.UpdateInboxNotificationRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if !validateStruct(w, &req) {
+		return
+	}
+
+	var log models.NotificationLog
+	err = h.db.Transaction(func(tx *gorm.DB) error {
+		if err := h.inboxTx(tx, userInfo.Email).Where("id = ?", id).First(&log).Error; err != nil {
+			return err
+		}
+		// Marking an already read notification as read keeps the original read time
+		switch {
+		case *req.Read && log.ReadAt == nil:
+			now := time.Now()
+			log.ReadAt = &now
+		case !*req.Read:
+			log.ReadAt = nil
+		default:
+			return nil
+		}
+		return tx.Model(&log).Update("read_at", log.ReadAt).Error
+	})
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			http.Error(w, "notification not found", http.StatusNotFound)
+			return
+		}
+		slog.Error("Failed to update notification", "error", err, "email", userInfo.Email, "id", id)
+		http.Error(w, "failed to update notification", http.StatusInternalServerError)
+		return
+	}
+
+	if err := writeJSON(w, http.StatusOK, toInboxNotificationResponse(log)); err != nil {
+		slog.Error("Failed to write JSON response", "error", err)
+		http.Error(w, "failed to write response", http.StatusInternalServerError)
+	}
+}
+
+// MarkAllRead handles marking every unread notification of the user as read, optionally only
+// those of one ?appId=
+func (h *NotificationInboxHandler) MarkAllRead(w http.ResponseWriter, r *http.Request) {
+	userInfo, ok := auth.GetUserInfo(r.Context())
+	if !ok {
+		http.Error(w, "user info not found in context", http.StatusUnauthorized)
+		return
+	}
+
+	result := h.inbox(userInfo.Email, r.URL.Query().Get(queryParamAppID)).
+		Where("read_at IS NULL").
+		Update("read_at", time.Now())
+	if result.Error != nil {
+		slog.Error("Failed to mark notifications as read", "error", result.Error, "email", userInfo.Email)
+		http.Error(w, "failed to mark notifications as read", http.StatusInternalServerError)
+		return
+	}
+
+	if err := writeJSON(w, http.StatusOK, dto.MarkAllReadResponse{Updated: result.RowsAffected}); err != nil {
+		slog.Error("Failed to write JSON response", "error", err)
+		http.Error(w, "failed to write response", http.StatusInternalServerError)
+	}
+}
+
+// Delete handles removing a notification from the user's inbox. The log row is kept for
+// delivery reporting and only hidden from the inbox.
+func (h *NotificationInboxHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	userInfo, ok := auth.GetUserInfo(r.Context())
+	if !ok {
+		http.Error(w, "user info not found in context", http.StatusUnauthorized)
+		return
+	}
+
+	id, err := strconv.ParseInt(chi.URLParam(r, "notificationID"), 10, 64)
+	if err != nil {
+		http.Error(w, "invalid notification ID", http.StatusBadRequest)
+		return
+	}
+
+	result := h.inbox(userInfo.Email, "").Where("id = ?", id).Update("deleted_at", time.Now())
+	if result.Error != nil {
+		slog.Error("Failed to delete notification", "error", result.Error, "email", userInfo.Email, "id", id)
+		http.Error(w, "failed to delete notification", http.StatusInternalServerError)
+		return
+	}
+	if result.RowsAffected == 0 {
+		http.Error(w, "notification not found", http.StatusNotFound)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// inbox scopes a query to the notifications in the user's inbox, optionally of one micro app
+func (h *NotificationInboxHandler) inbox(email, appID string) *gorm.DB {
+	q := h.inboxTx(h.db, email)
+	if appID != "" {
+		q = q.Where("microapp_id = ?", appID)
+	}
+	return q
+}
+
+func (h *NotificationInboxHandler) inboxTx(tx *gorm.DB, email string) *gorm.DB {
+	return tx.Model(&models.NotificationLog{}).Where("user_email = ? AND deleted_at IS NULL", email)
+}
+
+func toInboxNotificationResponse(l models.NotificationLog) dto.InboxNotificationResponse {
+	return dto.InboxNotificationResponse{
+		ID:     l.ID,
+		AppID:  l.MicroappID,
+		Title:  l.Title,
+		Body:   l.Body,
+		Data:   l.Data,
+		SentAt: l.SentAt,
+		Read:   l.ReadAt != nil,
+		ReadAt: l.ReadAt,
+	}
+}
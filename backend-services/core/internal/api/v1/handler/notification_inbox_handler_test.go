@@ -0,0 +1,151 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"go-backend/internal/api/v1/dto"
+	"go-backend/internal/auth"
+	"go-backend/internal/models"
+	"go-backend/internal/testdb"
+
+	"github.com/go-chi/chi/v5"
+	"gorm.io/gorm"
+)
+
+// inboxRequest runs an inbox action as the given user, with the notification ID as the route
+// parameter when it is not 0, and returns the recorded response
+func inboxRequest(action http.HandlerFunc, method, target, email string, id int64, body string) *httptest.ResponseRecorder {
+	var reader io.Reader
+	if body != "" {
+		reader = strings.NewReader(body)
+	}
+	r := httptest.NewRequest(method, target, reader)
+	if body != "" {
+		r.Header.Set("Content-Type", "application/json")
+	}
+	if id != 0 {
+		routeCtx := chi.NewRouteContext()
+		routeCtx.URLParams.Add("notificationID", strconv.FormatInt(id, 10))
+		r = r.WithContext(context.WithValue(r.Context(), chi.RouteCtxKey, routeCtx))
+	}
+	r = auth.SetUserInfo(r, &auth.CustomJwtPayload{Email: email})
+
+	w := httptest.NewRecorder()
+	action(w, r)
+	return w
+}
+
+// seedInbox logs one notification per title for the user, oldest first, and returns their IDs
+func seedInbox(t *testing.T, db *gorm.DB, email string, titles ...string) []int64 {
+	t.Helper()
+	appID := "inbox-test"
+	var ids []int64
+	for _, title := range titles {
+		log := models.NotificationLog{UserEmail: email, Title: &title, MicroappID: &appID}
+		if err := db.Create(&log).Error; err != nil {
+			t.Fatal(err)
+		}
+		ids = append(ids, log.ID)
+	}
+	return ids
+}
+
+func getInbox(t *testing.T, h *NotificationInboxHandler, target, email string) dto.NotificationInboxResponse {
+	t.Helper()
+	w := inboxRequest(h.GetAll, http.MethodGet, target, email, 0, "")
+	if w.Code != http.StatusOK {
+		t.Fatalf("GET %s = %d %s", target, w.Code, w.Body)
+	}
+	var response dto.NotificationInboxResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+		t.Fatal(err)
+	}
+	return response
+}
+
+func inboxTitles(response dto.NotificationInboxResponse) []string {
+	var titles []string
+	for _, n := range response.Notifications {
+		titles = append(titles, *n.Title)
+	}
+	return titles
+}
+
+func TestInboxPaging(t *testing.T) {
+	db := testdb.Open(t)
+	h := NewNotificationInboxHandler(db)
+	seedInbox(t, db, "a@example.com", "first", "second", "third")
+	seedInbox(t, db, "b@example.com", "other user")
+
+	page := getInbox(t, h, "/notifications?appId=inbox-test&limit=2", "a@example.com")
+	if got := inboxTitles(page); strings.Join(got, ",") != "third,second" || page.NextCursor == nil || page.UnreadCount != 3 {
+		t.Fatalf("first page = %v cursor %v unread %d, want third,second with a cursor and 3 unread", got, page.NextCursor, page.UnreadCount)
+	}
+
+	page = getInbox(t, h, "/notifications?appId=inbox-test&limit=2&before="+strconv.FormatInt(*page.NextCursor, 10), "a@example.com")
+	if got := inboxTitles(page); strings.Join(got, ",") != "first" || page.NextCursor != nil {
+		t.Errorf("last page = %v cursor %v, want only first and no cursor", got, page.NextCursor)
+	}
+
+	if w := inboxRequest(h.GetAll, http.MethodGet, "/notifications?limit=500", "a@example.com", 0, ""); w.Code != http.StatusBadRequest {
+		t.Errorf("limit above the maximum = %d, want 400", w.Code)
+	}
+}
+
+func TestInboxReadState(t *testing.T) {
+	db := testdb.Open(t)
+	h := NewNotificationInboxHandler(db)
+	ids := seedInbox(t, db, "a@example.com", "first", "second", "third")
+	other := seedInbox(t, db, "b@example.com", "other user")
+
+	if w := inboxRequest(h.Update, http.MethodPut, "/notifications/x", "a@example.com", ids[0], `{"read":true}`); w.Code != http.StatusOK {
+		t.Fatalf("mark read = %d %s", w.Code, w.Body)
+	}
+	unread := getInbox(t, h, "/notifications?appId=inbox-test&unread=true", "a@example.com")
+	if got := inboxTitles(unread); strings.Join(got, ",") != "third,second" || unread.UnreadCount != 2 {
+		t.Errorf("unread = %v (%d), want third,second", got, unread.UnreadCount)
+	}
+
+	// Another user's notification is not found rather than updated
+	if w := inboxRequest(h.Update, http.MethodPut, "/notifications/x", "a@example.com", other[0], `{"read":true}`); w.Code != http.StatusNotFound {
+		t.Errorf("mark another user's notification = %d, want 404", w.Code)
+	}
+
+	w := inboxRequest(h.MarkAllRead, http.MethodPost, "/notifications/read-all?appId=inbox-test", "a@example.com", 0, "")
+	var marked dto.MarkAllReadResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &marked); err != nil || marked.Updated != 2 {
+		t.Errorf("read-all = %s, want 2 updated", w.Body)
+	}
+	if page := getInbox(t, h, "/notifications?appId=inbox-test", "a@example.com"); page.UnreadCount != 0 {
+		t.Errorf("unread after read-all = %d, want 0", page.UnreadCount)
+	}
+}
+
+func TestInboxDelete(t *testing.T) {
+	db := testdb.Open(t)
+	h := NewNotificationInboxHandler(db)
+	ids := seedInbox(t, db, "a@example.com", "first", "second")
+
+	if w := inboxRequest(h.Delete, http.MethodDelete, "/notifications/x", "b@example.com", ids[0], ""); w.Code != http.StatusNotFound {
+		t.Fatalf("delete by another user = %d, want 404", w.Code)
+	}
+	if w := inboxRequest(h.Delete, http.MethodDelete, "/notifications/x", "a@example.com", ids[0], ""); w.Code != http.StatusNoContent {
+		t.Fatalf("delete = %d %s, want 204", w.Code, w.Body)
+	}
+	if got := inboxTitles(getInbox(t, h, "/notifications?appId=inbox-test", "a@example.com")); strings.Join(got, ",") != "second" {
+		t.Errorf("inbox after delete = %v, want only second", got)
+	}
+
+	// The log row is kept for delivery reporting
+	var log models.NotificationLog
+	if err := db.First(&log, ids[0]).Error; err != nil || log.DeletedAt == nil {
+		t.Errorf("deleted log = %+v (%v), want it kept with deleted_at set", log, err)
+	}
+}
@@ -0,0 +1,230 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"go-backend/internal/api/v1/dto"
+	"go-backend/internal/auth"
+	"go-backend/internal/models"
+	"go-backend/internal/notification"
+
+	"github.com/go-chi/chi/v5"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// NotificationPreferenceHandler serves the logged-in user's push settings, which the
+// notification worker applies to every message: mutes and critical-only mode suppress pushes,
+// quiet hours defer them
+type NotificationPreferenceHandler struct {
+	db *gorm.DB
+}
+
+func NewNotificationPreferenceHandler(db *gorm.DB) *NotificationPreferenceHandler {
+	return &NotificationPreferenceHandler{db: db}
+}
+
+// Get handles returning the user's push settings and the micro apps they muted
+func (h *NotificationPreferenceHandler) Get(w http.ResponseWriter, r *http.Request) {
+	userInfo, ok := auth.GetUserInfo(r.Context())
+	if !ok {
+		http.Error(w, "user info not found in context", http.StatusUnauthorized)
+		return
+	}
+
+	response, err := h.preferencesFor(userInfo.Email)
+	if err != nil {
+		slog.Error("Failed to fetch notification preferences", "error", err, "email", userInfo.Email)
+		http.Error(w, "failed to fetch notification preferences", http.StatusInternalServerError)
+		return
+	}
+
+	if err := writeJSON(w, http.StatusOK, response); err != nil {
+		slog.Error("Failed to write JSON response", "error", err)
+		http.Error(w, "failed to write response", http.StatusInternalServerError)
+	}
+}
+
+// Update handles replacing the user's quiet hours, time zone, critical-only mode and language
+func (h *NotificationPreferenceHandler) Update(w http.ResponseWriter, r *http.Request) {
+	userInfo, ok := auth.GetUserInfo(r.Context())
+	if !ok {
+		http.Error(w, "user info not found in context", http.StatusUnauthorized)
+		return
+	}
+
+	if !validateContentType(w, r) {
+		return
+	}
+
+	limitRequestBody(w, r, 0) // 1MB default limit
+	var req dto.UpdateNotificationPreferencesRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if !validateStruct(w, &req) {
+		return
+	}
+
+	if req.QuietHoursStart != nil && *req.QuietHoursStart == *req.QuietHoursEnd {
+		http.Error(w, "quietHoursStart and quietHoursEnd must differ", http.StatusBadRequest)
+		return
+	}
+
+	pref := models.NotificationPreference{
+		UserEmail:       userInfo.Email,
+		QuietHoursStart: req.QuietHoursStart,
+		QuietHoursEnd:   req.QuietHoursEnd,
+		TimeZone:        req.TimeZone,
+		CriticalOnly:    req.CriticalOnly,
+	}
+	if req.Locale != nil {
+		locale, err := notification.CanonicalLocale(*req.Locale)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		pref.Locale = &locale
+	}
+	if err := h.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "user_email"}},
+		DoUpdates: clause.AssignmentColumns([]string{"quiet_hours_start", "quiet_hours_end", "time_zone", "critical_only", "locale", "updated_at"}),
+	}).Create(&pref).Error; err != nil {
+		slog.Error("Failed to update notification preferences", "error", err, "email", userInfo.Email)
+		http.Error(w, "failed to update notification preferences", http.StatusInternalServerError)
+		return
+	}
+
+	response, err := h.preferencesFor(userInfo.Email)
+	if err != nil {
+		slog.Error("Failed to fetch notification preferences", "error", err, "email", userInfo.Email)
+		http.Error(w, "failed to fetch notification preferences", http.StatusInternalServerError)
+		return
+	}
+
+	if err := writeJSON(w, http.StatusOK, response); err != nil {
+		slog.Error("Failed to write JSON response", "error", err)
+		http.Error(w, "failed to write response", http.StatusInternalServerError)
+	}
+}
+
+// Mute handles muting a micro app's pushes for the user, for good or until a given time
+func (h *NotificationPreferenceHandler) Mute(w http.ResponseWriter, r *http.Request) {
+	userInfo, ok := auth.GetUserInfo(r.Context())
+	if !ok {
+		http.Error(w, "user info not found in context", http.StatusUnauthorized)
+		return
+	}
+
+	appID := chi.URLParam(r, "appID")
+	if appID == "" {
+		http.Error(w, "missing micro_app_id", http.StatusBadRequest)
+		return
+	}
+
+	if !validateContentType(w, r) {
+		return
+	}
+
+	limitRequestBody(w, r, 0) // 1MB default limit
+	var req dto.MuteMicroAppRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if req.Until != nil && !req.Until.After(time.Now()) {
+		http.Error(w, "until must be in the future", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.db.Where("micro_app_id = ?", appID).First(&models.MicroApp{}).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			http.Error(w, "micro app not found", http.StatusNotFound)
+			return
+		}
+		slog.Error("Failed to fetch micro app", "error", err, "appID", appID)
+		http.Error(w, "failed to mute micro app", http.StatusInternalServerError)
+		return
+	}
+
+	mute := models.NotificationMute{UserEmail: userInfo.Email, MicroAppID: appID, MutedUntil: req.Until}
+	if err := h.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "user_email"}, {Name: "micro_app_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"muted_until"}),
+	}).Create(&mute).Error; err != nil {
+		slog.Error("Failed to mute micro app", "error", err, "email", userInfo.Email, "appID", appID)
+		http.Error(w, "failed to mute micro app", http.StatusInternalServerError)
+		return
+	}
+
+	response := dto.NotificationMuteResponse{AppID: appID, MutedUntil: req.Until}
+	if err := writeJSON(w, http.StatusOK, response); err != nil {
+		slog.Error("Failed to write JSON response", "error", err)
+		http.Error(w, "failed to write response", http.StatusInternalServerError)
+	}
+}
+
+// Unmute handles resuming a micro app's pushes for the user
+func (h *NotificationPreferenceHandler) Unmute(w http.ResponseWriter, r *http.Request) {
+	userInfo, ok := auth.GetUserInfo(r.Context())
+	if !ok {
+		http.Error(w, "user info not found in context", http.StatusUnauthorized)
+		return
+	}
+
+	appID := chi.URLParam(r, "appID")
+	if appID == "" {
+		http.Error(w, "missing micro_app_id", http.StatusBadRequest)
+		return
+	}
+
+	result := h.db.Where("user_email = ? AND micro_app_id = ?", userInfo.Email, appID).Delete(&models.NotificationMute{})
+	if result.Error != nil {
+		slog.Error("Failed to unmute micro app", "error", result.Error, "email", userInfo.Email, "appID", appID)
+		http.Error(w, "failed to unmute micro app", http.StatusInternalServerError)
+		return
+	}
+	if result.RowsAffected == 0 {
+		http.Error(w, "micro app is not muted", http.StatusNotFound)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *NotificationPreferenceHandler) preferencesFor(email string) (*dto.NotificationPreferencesResponse, error) {
+	response := &dto.NotificationPreferencesResponse{Mutes: []dto.NotificationMuteResponse{}}
+
+	var pref models.NotificationPreference
+	err := h.db.Where("user_email = ?", email).First(&pref).Error
+	switch {
+	case err == nil:
+		response.QuietHoursStart = pref.QuietHoursStart
+		response.QuietHoursEnd = pref.QuietHoursEnd
+		response.TimeZone = pref.TimeZone
+		response.CriticalOnly = pref.CriticalOnly
+		response.Locale = pref.Locale
+	case !errors.Is(err, gorm.ErrRecordNotFound):
+		return nil, err
+	}
+
+	// Expired mutes no longer apply, so they are left out
+	var mutes []models.NotificationMute
+	if err := h.db.Where("user_email = ?", email).
+		Where(models.ActiveMuteCondition, time.Now()).
+		Order("micro_app_id ASC").
+		Find(&mutes).Error; err != nil {
+		return nil, err
+	}
+	for _, m := range mutes {
+		response.Mutes = append(response.Mutes, dto.NotificationMuteResponse{AppID: m.MicroAppID, MutedUntil: m.MutedUntil})
+	}
+	return response, nil
+}
@@ -0,0 +1,270 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"go-backend/internal/api/v1/dto"
+	"go-backend/internal/auth"
+	"go-backend/internal/config"
+	"go-backend/internal/models"
+	"go-backend/internal/notification"
+
+	"github.com/go-chi/chi/v5"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const headerRetryAfter = "Retry-After"
+
+// NotificationQuotaHandler lets admins view the notification usage of micro apps and override
+// their send limits. The limits are enforced when a micro app queues a notification.
+type NotificationQuotaHandler struct {
+	db       *gorm.DB
+	defaults notification.Limits
+}
+
+func NewNotificationQuotaHandler(db *gorm.DB, cfg *config.Config) *NotificationQuotaHandler {
+	return &NotificationQuotaHandler{db: db, defaults: notification.DefaultLimits(cfg)}
+}
+
+// GetAll handles listing the limits and usage of every micro app that has overrides or has
+// sent notifications
+func (h *NotificationQuotaHandler) GetAll(w http.ResponseWriter, r *http.Request) {
+	var quotas []models.NotificationQuota
+	if err := h.db.Find(&quotas).Error; err != nil {
+		slog.Error("Failed to fetch notification quotas", "error", err)
+		http.Error(w, "failed to fetch notification quotas", http.StatusInternalServerError)
+		return
+	}
+
+	var senders []string
+	if err := h.db.Model(&models.NotificationQuotaUsage{}).Distinct("microapp_id").Pluck("microapp_id", &senders).Error; err != nil {
+		slog.Error("Failed to fetch notification quota usage", "error", err)
+		http.Error(w, "failed to fetch notification quotas", http.StatusInternalServerError)
+		return
+	}
+
+	byApp := make(map[string]*models.NotificationQuota, len(quotas))
+	appIDs := make([]string, 0, len(quotas)+len(senders))
+	for i := range quotas {
+		byApp[quotas[i].MicroappID] = &quotas[i]
+		appIDs = append(appIDs, quotas[i].MicroappID)
+	}
+	for _, appID := range senders {
+		if _, ok := byApp[appID]; !ok {
+			appIDs = append(appIDs, appID)
+		}
+	}
+
+	now := time.Now()
+	response := make([]dto.NotificationQuotaResponse, 0, len(appIDs))
+	for _, appID := range appIDs {
+		quota, err := h.quotaResponse(appID, byApp[appID], now)
+		if err != nil {
+			slog.Error("Failed to fetch notification quota usage", "error", err, "microapp_id", appID)
+			http.Error(w, "failed to fetch notification quotas", http.StatusInternalServerError)
+			return
+		}
+		response = append(response, *quota)
+	}
+
+	if err := writeJSON(w, http.StatusOK, response); err != nil {
+		slog.Error("Failed to write JSON response", "error", err)
+		http.Error(w, "failed to write response", http.StatusInternalServerError)
+	}
+}
+
+// GetByID handles returning the limits and usage of one micro app
+func (h *NotificationQuotaHandler) GetByID(w http.ResponseWriter, r *http.Request) {
+	appID := chi.URLParam(r, "appID")
+	if appID == "" {
+		http.Error(w, "missing micro_app_id", http.StatusBadRequest)
+		return
+	}
+
+	quota, err := h.findQuota(appID)
+	if err != nil {
+		slog.Error("Failed to fetch notification quota", "error", err, "microapp_id", appID)
+		http.Error(w, "failed to fetch notification quota", http.StatusInternalServerError)
+		return
+	}
+
+	response, err := h.quotaResponse(appID, quota, time.Now())
+	if err != nil {
+		slog.Error("Failed to fetch notification quota usage", "error", err, "microapp_id", appID)
+		http.Error(w, "failed to fetch notification quota", http.StatusInternalServerError)
+		return
+	}
+
+	if err := writeJSON(w, http.StatusOK, response); err != nil {
+		slog.Error("Failed to write JSON response", "error", err)
+		http.Error(w, "failed to write response", http.StatusInternalServerError)
+	}
+}
+
+// Update handles replacing a micro app's limit overrides. Omitted limits go back to the defaults.
+func (h *NotificationQuotaHandler) Update(w http.ResponseWriter, r *http.Request) {
+	userInfo, ok := auth.GetUserInfo(r.Context())
+	if !ok {
+		http.Error(w, "user info not found in context", http.StatusUnauthorized)
+		return
+	}
+
+	appID := chi.URLParam(r, "appID")
+	if appID == "" {
+		http.Error(w, "missing micro_app_id", http.StatusBadRequest)
+		return
+	}
+
+	if !validateContentType(w, r) {
+		return
+	}
+
+	limitRequestBody(w, r, 0) // 1MB default limit
+	var req dto.UpdateNotificationQuotaRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if !validateStruct(w, &req) {
+		return
+	}
+
+	if err := h.db.Where("micro_app_id = ?", appID).First(&models.MicroApp{}).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			http.Error(w, "micro app not found", http.StatusNotFound)
+			return
+		}
+		slog.Error("Failed to fetch micro app", "error", err, "appID", appID)
+		http.Error(w, "failed to update notification quota", http.StatusInternalServerError)
+		return
+	}
+
+	quota := models.NotificationQuota{
+		MicroappID:         appID,
+		PerMinute:          req.PerMinute,
+		PerDay:             req.PerDay,
+		PerRecipientPerDay: req.PerRecipientPerDay,
+		UpdatedBy:          userInfo.Email,
+	}
+	if err := h.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "microapp_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"per_minute", "per_day", "per_recipient_per_day", "updated_by", "updated_at"}),
+	}).Create(&quota).Error; err != nil {
+		slog.Error("Failed to update notification quota", "error", err, "microapp_id", appID)
+		http.Error(w, "failed to update notification quota", http.StatusInternalServerError)
+		return
+	}
+	slog.Info("Notification quota updated", "microapp_id", appID, "updated_by", userInfo.Email)
+
+	response, err := h.quotaResponse(appID, &quota, time.Now())
+	if err != nil {
+		slog.Error("Failed to fetch notification quota usage", "error", err, "microapp_id", appID)
+		http.Error(w, "failed to fetch notification quota", http.StatusInternalServerError)
+		return
+	}
+
+	if err := writeJSON(w, http.StatusOK, response); err != nil {
+		slog.Error("Failed to write JSON response", "error", err)
+		http.Error(w, "failed to write response", http.StatusInternalServerError)
+	}
+}
+
+// Delete handles removing a micro app's overrides, which puts it back on the default limits
+func (h *NotificationQuotaHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	userInfo, ok := auth.GetUserInfo(r.Context())
+	if !ok {
+		http.Error(w, "user info not found in context", http.StatusUnauthorized)
+		return
+	}
+
+	appID := chi.URLParam(r, "appID")
+	if appID == "" {
+		http.Error(w, "missing micro_app_id", http.StatusBadRequest)
+		return
+	}
+
+	result := h.db.Where("microapp_id = ?", appID).Delete(&models.NotificationQuota{})
+	if result.Error != nil {
+		slog.Error("Failed to delete notification quota", "error", result.Error, "microapp_id", appID)
+		http.Error(w, "failed to delete notification quota", http.StatusInternalServerError)
+		return
+	}
+	if result.RowsAffected == 0 {
+		http.Error(w, "notification quota not found", http.StatusNotFound)
+		return
+	}
+
+	slog.Info("Notification quota reset to defaults", "microapp_id", appID, "deleted_by", userInfo.Email)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// findQuota returns the overrides of a micro app, or nil if it uses the defaults
+func (h *NotificationQuotaHandler) findQuota(appID string) (*models.NotificationQuota, error) {
+	var quota models.NotificationQuota
+	err := h.db.Where("microapp_id = ?", appID).First(&quota).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &quota, nil
+}
+
+func (h *NotificationQuotaHandler) quotaResponse(appID string, quota *models.NotificationQuota, now time.Time) (*dto.NotificationQuotaResponse, error) {
+	usage, err := notification.CurrentUsage(h.db, appID, now)
+	if err != nil {
+		return nil, err
+	}
+
+	limits := h.defaults
+	response := &dto.NotificationQuotaResponse{
+		AppID: appID,
+		Usage: dto.NotificationQuotaUsageResponse{
+			Minute:          usage.Minute,
+			Day:             usage.Day,
+			MaxPerRecipient: usage.MaxPerRecipient,
+		},
+	}
+	if quota != nil {
+		limits = notification.ApplyOverrides(h.defaults, *quota)
+		response.Overrides = dto.UpdateNotificationQuotaRequest{
+			PerMinute:          quota.PerMinute,
+			PerDay:             quota.PerDay,
+			PerRecipientPerDay: quota.PerRecipientPerDay,
+		}
+		response.UpdatedBy = &quota.UpdatedBy
+		response.UpdatedAt = &quota.UpdatedAt
+	}
+	response.Limits = dto.NotificationQuotaLimits{
+		PerMinute:          limits.PerMinute,
+		PerDay:             limits.PerDay,
+		PerRecipientPerDay: limits.PerRecipientPerDay,
+	}
+	return response, nil
+}
+
+// writeQuotaExceeded answers a send that would go over a quota with 429, telling the caller
+// in Retry-After how long to wait for the quota window to reset
+func writeQuotaExceeded(w http.ResponseWriter, e *notification.QuotaExceededError) {
+	retryAfter := max(int(math.Ceil(e.RetryAfter.Seconds())), 1)
+	w.Header().Set(headerRetryAfter, strconv.Itoa(retryAfter))
+	if err := writeJSON(w, http.StatusTooManyRequests, dto.QuotaExceededResponse{
+		Message:           e.Error(),
+		Limit:             e.Limit,
+		Max:               e.Max,
+		RetryAfterSeconds: retryAfter,
+		Recipients:        e.Recipients,
+	}); err != nil {
+		slog.Error("Failed to write JSON response", "error", err)
+		http.Error(w, "failed to write response", http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,299 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"go-backend/internal/api/v1/dto"
+	"go-backend/internal/auth"
+	"go-backend/internal/models"
+	"go-backend/internal/notification"
+
+	"github.com/go-chi/chi/v5"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Longest template key, matching the template_key column
+const maxTemplateKeyLength = 100
+
+// templateScope returns the micro app whose templates a request manages, and who is making
+// the change
+type templateScope func(r *http.Request) (appID, actor string, err error)
+
+// NotificationTemplateHandler manages the localised notification templates of micro apps,
+// which send requests can render from instead of passing a title and body. Admins manage the
+// templates of any micro app; a micro app backend manages its own.
+type NotificationTemplateHandler struct {
+	db    *gorm.DB
+	scope templateScope
+}
+
+// NewNotificationTemplateHandler creates the handler for admins, for routes under
+// /micro-apps/{appID}
+func NewNotificationTemplateHandler(db *gorm.DB) *NotificationTemplateHandler {
+	return &NotificationTemplateHandler{db: db, scope: adminTemplateScope}
+}
+
+// NewServiceNotificationTemplateHandler creates the handler for micro app backends, which
+// manage the templates of the micro app they authenticate as
+func NewServiceNotificationTemplateHandler(db *gorm.DB) *NotificationTemplateHandler {
+	return &NotificationTemplateHandler{db: db, scope: serviceTemplateScope}
+}
+
+// GetAll handles listing the templates of a micro app
+func (h *NotificationTemplateHandler) GetAll(w http.ResponseWriter, r *http.Request) {
+	appID, _, err := h.scope(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+
+	var templates []models.NotificationTemplate
+	if err := h.db.Preload("Translations", func(db *gorm.DB) *gorm.DB {
+		return db.Order("locale ASC")
+	}).Where("microapp_id = ?", appID).Order("template_key ASC").Find(&templates).Error; err != nil {
+		slog.Error("Failed to fetch notification templates", "error", err, "microapp_id", appID)
+		http.Error(w, "failed to fetch notification templates", http.StatusInternalServerError)
+		return
+	}
+
+	response := make([]dto.NotificationTemplateResponse, 0, len(templates))
+	for _, t := range templates {
+		response = append(response, toNotificationTemplateResponse(t))
+	}
+
+	if err := writeJSON(w, http.StatusOK, response); err != nil {
+		slog.Error("Failed to write JSON response", "error", err)
+		http.Error(w, "failed to write response", http.StatusInternalServerError)
+	}
+}
+
+// GetByKey handles returning one template of a micro app
+func (h *NotificationTemplateHandler) GetByKey(w http.ResponseWriter, r *http.Request) {
+	appID, _, err := h.scope(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+
+	key := chi.URLParam(r, "templateKey")
+	template, err := h.find(appID, key)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			http.Error(w, "notification template not found", http.StatusNotFound)
+			return
+		}
+		slog.Error("Failed to fetch notification template", "error", err, "microapp_id", appID, "template", key)
+		http.Error(w, "failed to fetch notification template", http.StatusInternalServerError)
+		return
+	}
+
+	if err := writeJSON(w, http.StatusOK, toNotificationTemplateResponse(*template)); err != nil {
+		slog.Error("Failed to write JSON response", "error", err)
+		http.Error(w, "failed to write response", http.StatusInternalServerError)
+	}
+}
+
+// Upsert handles creating a template or replacing its default locale and translations
+func (h *NotificationTemplateHandler) Upsert(w http.ResponseWriter, r *http.Request) {
+	appID, actor, err := h.scope(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+
+	key := chi.URLParam(r, "templateKey")
+	if key == "" || len(key) > maxTemplateKeyLength {
+		http.Error(w, "template key must be 1 to 100 characters", http.StatusBadRequest)
+		return
+	}
+
+	if !validateContentType(w, r) {
+		return
+	}
+
+	limitRequestBody(w, r, 0) // 1MB default limit
+	var req dto.UpsertNotificationTemplateRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if !validateStruct(w, &req) {
+		return
+	}
+
+	defaultLocale, err := notification.CanonicalLocale(req.DefaultLocale)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	translations := make([]models.NotificationTemplateTranslation, 0, len(req.Translations))
+	seen := make(map[string]bool, len(req.Translations))
+	for _, t := range req.Translations {
+		locale, err := notification.CanonicalLocale(t.Locale)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if seen[locale] {
+			http.Error(w, "duplicate translation for locale "+locale, http.StatusBadRequest)
+			return
+		}
+		seen[locale] = true
+		translations = append(translations, models.NotificationTemplateTranslation{Locale: locale, Title: t.Title, Body: t.Body})
+	}
+	if !seen[defaultLocale] {
+		http.Error(w, "translations must include the default locale "+defaultLocale, http.StatusBadRequest)
+		return
+	}
+
+	if err := h.db.Where("micro_app_id = ?", appID).First(&models.MicroApp{}).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			http.Error(w, "micro app not found", http.StatusNotFound)
+			return
+		}
+		slog.Error("Failed to fetch micro app", "error", err, "appID", appID)
+		http.Error(w, "failed to save notification template", http.StatusInternalServerError)
+		return
+	}
+
+	err = h.db.Transaction(func(tx *gorm.DB) error {
+		template := models.NotificationTemplate{
+			MicroappID:    appID,
+			TemplateKey:   key,
+			DefaultLocale: defaultLocale,
+			CreatedBy:     actor,
+			UpdatedBy:     &actor,
+		}
+		if err := tx.Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "microapp_id"}, {Name: "template_key"}},
+			DoUpdates: clause.AssignmentColumns([]string{"default_locale", "updated_by", "updated_at"}),
+		}).Create(&template).Error; err != nil {
+			return err
+		}
+		// The upsert does not report the ID of an existing row
+		if err := tx.Where("microapp_id = ? AND template_key = ?", appID, key).First(&template).Error; err != nil {
+			return err
+		}
+
+		if err := tx.Where("template_id = ?", template.ID).Delete(&models.NotificationTemplateTranslation{}).Error; err != nil {
+			return err
+		}
+		for i := range translations {
+			translations[i].TemplateID = template.ID
+		}
+		return tx.Create(&translations).Error
+	})
+	if err != nil {
+		slog.Error("Failed to save notification template", "error", err, "microapp_id", appID, "template", key)
+		http.Error(w, "failed to save notification template", http.StatusInternalServerError)
+		return
+	}
+	slog.Info("Notification template saved", "microapp_id", appID, "template", key, "locales", len(translations), "updated_by", actor)
+
+	template, err := h.find(appID, key)
+	if err != nil {
+		slog.Error("Failed to fetch notification template", "error", err, "microapp_id", appID, "template", key)
+		http.Error(w, "failed to fetch notification template", http.StatusInternalServerError)
+		return
+	}
+
+	if err := writeJSON(w, http.StatusOK, toNotificationTemplateResponse(*template)); err != nil {
+		slog.Error("Failed to write JSON response", "error", err)
+		http.Error(w, "failed to write response", http.StatusInternalServerError)
+	}
+}
+
+// Delete handles removing a template. Notifications already queued from it keep their copy.
+func (h *NotificationTemplateHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	appID, actor, err := h.scope(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+
+	key := chi.URLParam(r, "templateKey")
+	deleted := false
+	err = h.db.Transaction(func(tx *gorm.DB) error {
+		var template models.NotificationTemplate
+		if err := tx.Where("microapp_id = ? AND template_key = ?", appID, key).First(&template).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("template_id = ?", template.ID).Delete(&models.NotificationTemplateTranslation{}).Error; err != nil {
+			return err
+		}
+		result := tx.Delete(&template)
+		deleted = result.RowsAffected > 0
+		return result.Error
+	})
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		slog.Error("Failed to delete notification template", "error", err, "microapp_id", appID, "template", key)
+		http.Error(w, "failed to delete notification template", http.StatusInternalServerError)
+		return
+	}
+	if !deleted {
+		http.Error(w, "notification template not found", http.StatusNotFound)
+		return
+	}
+
+	slog.Info("Notification template deleted", "microapp_id", appID, "template", key, "deleted_by", actor)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *NotificationTemplateHandler) find(appID, key string) (*models.NotificationTemplate, error) {
+	var template models.NotificationTemplate
+	if err := h.db.Preload("Translations", func(db *gorm.DB) *gorm.DB {
+		return db.Order("locale ASC")
+	}).Where("microapp_id = ? AND template_key = ?", appID, key).First(&template).Error; err != nil {
+		return nil, err
+	}
+	return &template, nil
+}
+
+// adminTemplateScope manages the templates of the micro app in the URL on behalf of the
+// logged-in admin
+func adminTemplateScope(r *http.Request) (string, string, error) {
+	userInfo, ok := auth.GetUserInfo(r.Context())
+	if !ok {
+		return "", "", errors.New("user info not found in context")
+	}
+	return chi.URLParam(r, "appID"), userInfo.Email, nil
+}
+
+// serviceTemplateScope manages the templates of the calling micro app
+func serviceTemplateScope(r *http.Request) (string, string, error) {
+	clientID, err := getClientID(r)
+	if err != nil {
+		return "", "", err
+	}
+	return clientID, clientID, nil
+}
+
+func toNotificationTemplateResponse(t models.NotificationTemplate) dto.NotificationTemplateResponse {
+	response := dto.NotificationTemplateResponse{
+		AppID:         t.MicroappID,
+		Key:           t.TemplateKey,
+		DefaultLocale: t.DefaultLocale,
+		Placeholders:  notification.Placeholders(t.Translations),
+		Translations:  make([]dto.NotificationTemplateTranslationResponse, 0, len(t.Translations)),
+		CreatedBy:     t.CreatedBy,
+		UpdatedBy:     t.UpdatedBy,
+		CreatedAt:     t.CreatedAt,
+		UpdatedAt:     t.UpdatedAt,
+	}
+	if response.Placeholders == nil {
+		response.Placeholders = []string{}
+	}
+	for _, tr := range t.Translations {
+		response.Translations = append(response.Translations, dto.NotificationTemplateTranslationResponse{
+			Locale: tr.Locale,
+			Title:  tr.Title,
+			Body:   tr.Body,
+		})
+	}
+	return response
+}
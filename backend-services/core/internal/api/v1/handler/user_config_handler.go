@@ -2,6 +2,7 @@ package handler
 
 import (
 	"encoding/json"
+	"errors"
 	"log/slog"
 	"net/http"
 
@@ -10,6 +11,7 @@ import (
 	"go-backend/internal/models"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type UserConfigHandler struct {
@@ -43,6 +45,7 @@ func (h *UserConfigHandler) GetAppConfigs(w http.ResponseWriter, r *http.Request
 			ConfigKey:   config.ConfigKey,
 			ConfigValue: config.ConfigValue,
 			Active:      config.Active,
+			Version:     config.Version,
 		})
 	}
 
@@ -81,26 +84,56 @@ func (h *UserConfigHandler) UpsertAppConfig(w http.ResponseWriter, r *http.Reque
 		req.Active = 1
 	}
 
-	config := models.UserConfig{}
-	result := h.db.Where("email = ? AND config_key = ?", userInfo.Email, req.ConfigKey).
-		Assign(models.UserConfig{
-			ConfigValue: req.ConfigValue,
-			Active:      req.Active,
-			UpdatedBy:   userInfo.Email,
-		}).
-		Attrs(models.UserConfig{
-			Email:       userInfo.Email,
-			ConfigKey:   req.ConfigKey,
-			ConfigValue: req.ConfigValue,
-			CreatedBy:   userInfo.Email,
-		}).FirstOrCreate(&config)
-
-	if result.Error != nil {
-		slog.Error("Failed to upsert user config", "error", result.Error, "email", userInfo.Email, "configKey", req.ConfigKey)
-		http.Error(w, "failed to upsert user configuration", http.StatusInternalServerError)
+	// With If-Match the config is only written if it is unchanged since the client read it
+	expected, ok := readIfMatch(w, r)
+	if !ok {
+		return
+	}
+
+	config := models.UserConfig{
+		Email:       userInfo.Email,
+		ConfigKey:   req.ConfigKey,
+		ConfigValue: req.ConfigValue,
+		Active:      req.Active,
+		CreatedBy:   userInfo.Email,
+		UpdatedBy:   userInfo.Email,
+	}
+	err := h.db.Transaction(func(tx *gorm.DB) error {
+		if expected != nil {
+			var current models.UserConfig
+			err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+				Where("email = ? AND config_key = ?", userInfo.Email, req.ConfigKey).
+				First(&current).Error
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return &versionConflictError{}
+			}
+			if err != nil {
+				return err
+			}
+			if current.Version != *expected {
+				return &versionConflictError{current: current.Version}
+			}
+		}
+
+		// The unique (email, config_key) key makes concurrent first writes update instead of failing
+		if err := tx.Clauses(clause.OnConflict{
+			Columns: []clause.Column{{Name: "email"}, {Name: "config_key"}},
+			DoUpdates: append(clause.AssignmentColumns([]string{"config_value", "active", "updated_by", "updated_at"}),
+				clause.Assignment{Column: clause.Column{Name: "version"}, Value: gorm.Expr("version + 1")}),
+		}).Create(&config).Error; err != nil {
+			return err
+		}
+
+		return tx.Where("email = ? AND config_key = ?", userInfo.Email, req.ConfigKey).First(&config).Error
+	})
+
+	if err != nil {
+		writeUpsertError(w, err, "failed to upsert user configuration")
+		slog.Error("Failed to upsert user config", "error", err, "email", userInfo.Email, "configKey", req.ConfigKey)
 		return
 	}
 
+	w.Header().Set(headerETag, rowETag(config.Version))
 	if err := writeJSON(w, http.StatusCreated, map[string]string{"message": "Configuration updated successfully"}); err != nil {
 		slog.Error("Failed to write JSON response", "error", err)
 		http.Error(w, "failed to write response", http.StatusInternalServerError)
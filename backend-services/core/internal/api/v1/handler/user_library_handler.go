@@ -0,0 +1,356 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"go-backend/internal/api/v1/dto"
+	"go-backend/internal/auth"
+	"go-backend/internal/models"
+
+	"github.com/go-chi/chi/v5"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var errMandatoryUninstall = errors.New("mandatory micro apps cannot be uninstalled")
+
+type UserLibraryHandler struct {
+	db *gorm.DB
+}
+
+func NewUserLibraryHandler(db *gorm.DB) *UserLibraryHandler {
+	return &UserLibraryHandler{db: db}
+}
+
+// GetAll returns the logged-in user's micro app library, including the mandatory micro apps
+// they have access to
+func (h *UserLibraryHandler) GetAll(w http.ResponseWriter, r *http.Request) {
+	userInfo, ok := auth.GetUserInfo(r.Context())
+	if !ok {
+		http.Error(w, "user info not found in context", http.StatusUnauthorized)
+		return
+	}
+
+	library, err := h.libraryFor(userInfo.Email, userInfo.Groups)
+	if err != nil {
+		slog.Error("Failed to fetch micro app library", "error", err, "email", userInfo.Email)
+		http.Error(w, "failed to fetch micro app library", http.StatusInternalServerError)
+		return
+	}
+
+	if err := writeJSON(w, http.StatusOK, library); err != nil {
+		slog.Error("Failed to write JSON response", "error", err)
+		http.Error(w, "failed to write response", http.StatusInternalServerError)
+	}
+}
+
+// Update installs, uninstalls, pins, hides or moves a micro app in the user's library
+func (h *UserLibraryHandler) Update(w http.ResponseWriter, r *http.Request) {
+	appID := chi.URLParam(r, "appID")
+	if appID == "" {
+		http.Error(w, "missing micro_app_id", http.StatusBadRequest)
+		return
+	}
+
+	userInfo, ok := auth.GetUserInfo(r.Context())
+	if !ok {
+		http.Error(w, "user info not found in context", http.StatusUnauthorized)
+		return
+	}
+
+	if !validateContentType(w, r) {
+		return
+	}
+
+	limitRequestBody(w, r, 0) // 1MB default limit
+	var req dto.UpdateUserMicroAppRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if !validateStruct(w, &req) {
+		return
+	}
+
+	apps, err := h.availableApps(userInfo.Groups, []string{appID})
+	if err != nil {
+		slog.Error("Failed to fetch micro app", "error", err, "appID", appID)
+		http.Error(w, "failed to update micro app library", http.StatusInternalServerError)
+		return
+	}
+	if len(apps) == 0 {
+		http.Error(w, "micro app not found", http.StatusNotFound)
+		return
+	}
+	mandatory := apps[0].Mandatory == 1
+
+	var entry models.UserMicroApp
+	err = h.db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("user_email = ? AND micro_app_id = ?", userInfo.Email, appID).
+			First(&entry).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			entry = models.UserMicroApp{UserEmail: userInfo.Email, MicroAppID: appID, Installed: mandatory}
+		} else if err != nil {
+			return err
+		}
+
+		if req.Installed != nil {
+			if mandatory && !*req.Installed {
+				return errMandatoryUninstall
+			}
+			entry.Installed = *req.Installed
+		}
+		if req.Pinned != nil {
+			entry.Pinned = *req.Pinned
+		}
+		if req.Hidden != nil {
+			entry.Hidden = *req.Hidden
+		}
+		if req.Order != nil {
+			entry.SortOrder = *req.Order
+		}
+		return tx.Save(&entry).Error
+	})
+	if errors.Is(err, errMandatoryUninstall) {
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	}
+	if err != nil {
+		slog.Error("Failed to update micro app library", "error", err, "email", userInfo.Email, "appID", appID)
+		http.Error(w, "failed to update micro app library", http.StatusInternalServerError)
+		return
+	}
+
+	if err := writeJSON(w, http.StatusOK, toUserMicroAppResponse(appID, &entry, mandatory)); err != nil {
+		slog.Error("Failed to write JSON response", "error", err)
+		http.Error(w, "failed to write response", http.StatusInternalServerError)
+	}
+}
+
+// Reorder sets the library order from a list of micro app IDs, typically after the user rearranged
+// their home screen. Apps not in the list keep their current position.
+func (h *UserLibraryHandler) Reorder(w http.ResponseWriter, r *http.Request) {
+	userInfo, ok := auth.GetUserInfo(r.Context())
+	if !ok {
+		http.Error(w, "user info not found in context", http.StatusUnauthorized)
+		return
+	}
+
+	if !validateContentType(w, r) {
+		return
+	}
+
+	limitRequestBody(w, r, 0) // 1MB default limit
+	var req dto.ReorderUserMicroAppsRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if !validateStruct(w, &req) {
+		return
+	}
+
+	apps, err := h.availableApps(userInfo.Groups, req.AppIDs)
+	if err != nil {
+		slog.Error("Failed to fetch micro apps", "error", err)
+		http.Error(w, "failed to reorder micro app library", http.StatusInternalServerError)
+		return
+	}
+	mandatory := make(map[string]bool, len(apps))
+	for _, app := range apps {
+		mandatory[app.MicroAppID] = app.Mandatory == 1
+	}
+	var missing []string
+	for _, id := range req.AppIDs {
+		if _, found := mandatory[id]; !found {
+			missing = append(missing, id)
+		}
+	}
+	if len(missing) > 0 {
+		http.Error(w, fmt.Sprintf("micro apps not found: %s", strings.Join(missing, ", ")), http.StatusNotFound)
+		return
+	}
+
+	entries := make([]models.UserMicroApp, 0, len(req.AppIDs))
+	for i, id := range req.AppIDs {
+		entries = append(entries, models.UserMicroApp{
+			UserEmail:  userInfo.Email,
+			MicroAppID: id,
+			Installed:  mandatory[id],
+			SortOrder:  i,
+		})
+	}
+	if err := h.db.Clauses(clause.OnConflict{DoUpdates: clause.AssignmentColumns([]string{"sort_order", "updated_at"})}).
+		Create(&entries).Error; err != nil {
+		slog.Error("Failed to reorder micro app library", "error", err, "email", userInfo.Email)
+		http.Error(w, "failed to reorder micro app library", http.StatusInternalServerError)
+		return
+	}
+
+	library, err := h.libraryFor(userInfo.Email, userInfo.Groups)
+	if err != nil {
+		slog.Error("Failed to fetch micro app library", "error", err, "email", userInfo.Email)
+		http.Error(w, "failed to fetch micro app library", http.StatusInternalServerError)
+		return
+	}
+
+	if err := writeJSON(w, http.StatusOK, library); err != nil {
+		slog.Error("Failed to write JSON response", "error", err)
+		http.Error(w, "failed to write response", http.StatusInternalServerError)
+	}
+}
+
+// Remove drops a micro app from the user's library. Mandatory micro apps stay installed but
+// lose their pin, hidden flag and position.
+func (h *UserLibraryHandler) Remove(w http.ResponseWriter, r *http.Request) {
+	appID := chi.URLParam(r, "appID")
+	if appID == "" {
+		http.Error(w, "missing micro_app_id", http.StatusBadRequest)
+		return
+	}
+
+	userInfo, ok := auth.GetUserInfo(r.Context())
+	if !ok {
+		http.Error(w, "user info not found in context", http.StatusUnauthorized)
+		return
+	}
+
+	if err := h.db.Where("user_email = ? AND micro_app_id = ?", userInfo.Email, appID).
+		Delete(&models.UserMicroApp{}).Error; err != nil {
+		slog.Error("Failed to remove micro app from library", "error", err, "email", userInfo.Email, "appID", appID)
+		http.Error(w, "failed to update micro app library", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *UserLibraryHandler) libraryFor(email string, groups []string) ([]dto.UserMicroAppResponse, error) {
+	rows, err := loadLibraryRows(h.db, email)
+	if err != nil {
+		return nil, err
+	}
+	authorizedAppIDs, err := authorizedMicroAppIDs(h.db, groups)
+	if err != nil {
+		return nil, err
+	}
+	return buildLibrary(h.db, rows, authorizedAppIDs)
+}
+
+// availableApps returns those of the given micro apps that are live and offered to the groups
+func (h *UserLibraryHandler) availableApps(groups []string, appIDs []string) ([]models.MicroApp, error) {
+	authorizedAppIDs, err := authorizedMicroAppIDs(h.db, groups)
+	if err != nil {
+		return nil, err
+	}
+	var ids []string
+	for _, id := range appIDs {
+		if slices.Contains(authorizedAppIDs, id) {
+			ids = append(ids, id)
+		}
+	}
+	if len(ids) == 0 {
+		return nil, nil
+	}
+
+	var apps []models.MicroApp
+	now := time.Now()
+	err = h.db.Select("micro_app_id", "mandatory").
+		Where("active = ? AND micro_app_id IN ?", models.StatusActive, ids).
+		Where(models.LiveCondition, now, now).
+		Find(&apps).Error
+	return apps, err
+}
+
+func loadLibraryRows(db *gorm.DB, email string) ([]models.UserMicroApp, error) {
+	var rows []models.UserMicroApp
+	err := db.Where("user_email = ?", email).Find(&rows).Error
+	return rows, err
+}
+
+// libraryStamp summarises a user's library rows for the catalog ETag, so a library change
+// invalidates cached catalog responses without forcing a full delta sync
+func libraryStamp(rows []models.UserMicroApp) string {
+	var b strings.Builder
+	for _, row := range rows {
+		fmt.Fprintf(&b, "%s|%t|%t|%t|%d;", row.MicroAppID, row.Installed, row.Pinned, row.Hidden, row.SortOrder)
+	}
+	return shortHash(b.String())
+}
+
+// buildLibrary resolves the library entries of the live micro apps the user can access: their
+// own entries plus an installed entry for every mandatory app. Entries are in home screen order,
+// pinned apps first.
+func buildLibrary(db *gorm.DB, rows []models.UserMicroApp, authorizedAppIDs []string) ([]dto.UserMicroAppResponse, error) {
+	library := []dto.UserMicroAppResponse{}
+	if len(authorizedAppIDs) == 0 {
+		return library, nil
+	}
+
+	var apps []models.MicroApp
+	now := time.Now()
+	if err := db.Select("micro_app_id", "mandatory").
+		Where("active = ? AND micro_app_id IN ?", models.StatusActive, authorizedAppIDs).
+		Where(models.LiveCondition, now, now).
+		Find(&apps).Error; err != nil {
+		return nil, err
+	}
+
+	rowsByApp := make(map[string]*models.UserMicroApp, len(rows))
+	for i := range rows {
+		rowsByApp[rows[i].MicroAppID] = &rows[i]
+	}
+	for _, app := range apps {
+		row := rowsByApp[app.MicroAppID]
+		if row == nil && app.Mandatory != 1 {
+			continue
+		}
+		library = append(library, toUserMicroAppResponse(app.MicroAppID, row, app.Mandatory == 1))
+	}
+
+	sort.SliceStable(library, func(i, j int) bool {
+		a, b := library[i], library[j]
+		if a.Pinned != b.Pinned {
+			return a.Pinned
+		}
+		if a.Order != b.Order {
+			return a.Order < b.Order
+		}
+		return a.AppID < b.AppID
+	})
+	return library, nil
+}
+
+// attachLibrary sets the library entry of each catalog app the user has one for
+func attachLibrary(apps []dto.MicroAppResponse, library []dto.UserMicroAppResponse) {
+	byApp := make(map[string]*dto.UserMicroAppResponse, len(library))
+	for i := range library {
+		byApp[library[i].AppID] = &library[i]
+	}
+	for i := range apps {
+		apps[i].Library = byApp[apps[i].AppID]
+	}
+}
+
+func toUserMicroAppResponse(appID string, row *models.UserMicroApp, mandatory bool) dto.UserMicroAppResponse {
+	entry := dto.UserMicroAppResponse{AppID: appID, Installed: mandatory, Mandatory: mandatory}
+	if row != nil {
+		entry.Installed = row.Installed || mandatory
+		entry.Pinned = row.Pinned
+		entry.Hidden = row.Hidden
+		entry.Order = row.SortOrder
+		entry.UpdatedAt = &row.UpdatedAt
+	}
+	return entry
+}
@@ -0,0 +1,121 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"go-backend/internal/api/v1/dto"
+	"go-backend/internal/auth"
+	"go-backend/internal/models"
+	"go-backend/internal/testdb"
+
+	"github.com/go-chi/chi/v5"
+	"gorm.io/gorm"
+)
+
+func TestToUserMicroAppResponse(t *testing.T) {
+	if entry := toUserMicroAppResponse("app", nil, true); !entry.Installed || !entry.Mandatory {
+		t.Errorf("mandatory app without an entry = %+v, want installed", entry)
+	}
+	// A mandatory app stays installed whatever its entry says
+	row := &models.UserMicroApp{MicroAppID: "app", Installed: false, Pinned: true, SortOrder: 3}
+	if entry := toUserMicroAppResponse("app", row, true); !entry.Installed || !entry.Pinned || entry.Order != 3 {
+		t.Errorf("mandatory app = %+v, want installed, pinned at 3", entry)
+	}
+	if entry := toUserMicroAppResponse("app", row, false); entry.Installed {
+		t.Errorf("optional app = %+v, want not installed", entry)
+	}
+}
+
+func TestLibraryStamp(t *testing.T) {
+	rows := []models.UserMicroApp{{MicroAppID: "a", Installed: true}, {MicroAppID: "b", SortOrder: 1}}
+	stamp := libraryStamp(rows)
+	rows[1].SortOrder = 2
+	if libraryStamp(rows) == stamp {
+		t.Error("library stamp ignores a reorder")
+	}
+}
+
+// createLibraryApp creates a live micro app offered to the hr group
+func createLibraryApp(t *testing.T, db *gorm.DB, appID string, mandatory bool) {
+	t.Helper()
+	app := models.MicroApp{MicroAppID: appID, Name: appID, CreatedBy: "admin@example.com"}
+	if mandatory {
+		app.Mandatory = 1
+	}
+	if err := db.Create(&app).Error; err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Create(&models.MicroAppRole{MicroAppID: appID, Role: "hr", CreatedBy: "admin@example.com"}).Error; err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestBuildLibrary(t *testing.T) {
+	db := testdb.Open(t)
+	for appID, mandatory := range map[string]bool{
+		"lib-mandatory": true, "lib-pinned": false, "lib-ordered": false, "lib-inactive": false, "lib-other": false,
+	} {
+		createLibraryApp(t, db, appID, mandatory)
+	}
+	if err := db.Model(&models.MicroApp{}).Where("micro_app_id = ?", "lib-inactive").
+		Update("active", models.StatusInactive).Error; err != nil {
+		t.Fatal(err)
+	}
+	rows := []models.UserMicroApp{
+		{MicroAppID: "lib-pinned", Installed: true, Pinned: true, SortOrder: 5},
+		{MicroAppID: "lib-ordered", Installed: true, SortOrder: 1},
+		{MicroAppID: "lib-inactive", Installed: true},
+	}
+
+	library, err := buildLibrary(db, rows, []string{"lib-mandatory", "lib-pinned", "lib-ordered", "lib-inactive", "lib-other"})
+	if err != nil {
+		t.Fatalf("buildLibrary: %v", err)
+	}
+	var got []string
+	for _, entry := range library {
+		got = append(got, entry.AppID)
+	}
+	// Pinned first, then by order; apps without an entry only when mandatory, and only live ones
+	if want := "lib-pinned,lib-mandatory,lib-ordered"; strings.Join(got, ",") != want {
+		t.Errorf("library = %v, want %s", got, want)
+	}
+}
+
+func TestUpdateLibraryMandatory(t *testing.T) {
+	db := testdb.Open(t)
+	createLibraryApp(t, db, "lib-mandatory", true)
+	h := NewUserLibraryHandler(db)
+
+	update := func(body string) *httptest.ResponseRecorder {
+		routeCtx := chi.NewRouteContext()
+		routeCtx.URLParams.Add("appID", "lib-mandatory")
+		r := httptest.NewRequest(http.MethodPut, "/user-micro-apps/lib-mandatory", strings.NewReader(body))
+		r.Header.Set("Content-Type", "application/json")
+		r = r.WithContext(context.WithValue(r.Context(), chi.RouteCtxKey, routeCtx))
+		r = auth.SetUserInfo(r, &auth.CustomJwtPayload{Email: "a@example.com", Groups: []string{"hr"}})
+		w := httptest.NewRecorder()
+		h.Update(w, r)
+		return w
+	}
+
+	if w := update(`{"installed":false}`); w.Code != http.StatusConflict {
+		t.Fatalf("uninstall a mandatory app = %d %s, want 409", w.Code, w.Body)
+	}
+	if w := update(`{"pinned":true,"order":2}`); w.Code != http.StatusOK {
+		t.Fatalf("pin = %d %s, want 200", w.Code, w.Body)
+	}
+
+	library, err := h.libraryFor("a@example.com", []string{"hr"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := dto.UserMicroAppResponse{AppID: "lib-mandatory", Installed: true, Pinned: true, Order: 2, Mandatory: true}
+	if len(library) != 1 || library[0].AppID != want.AppID || library[0].Installed != want.Installed ||
+		library[0].Pinned != want.Pinned || library[0].Order != want.Order || library[0].Mandatory != want.Mandatory {
+		t.Errorf("library = %+v, want only %+v", library, want)
+	}
+}
@@ -5,6 +5,8 @@ import (
 	"fmt"
 	"net/http"
 
+	"go-backend/internal/models"
+
 	"github.com/go-playground/validator/v10"
 )
 
@@ -12,7 +14,16 @@ const (
 	defaultMaxRequestBodySize = 1 << 20 // 1MB
 )
 
-var validate = validator.New()
+var validate = newValidator()
+
+func newValidator() *validator.Validate {
+	v := validator.New()
+	// capability: a bridge capability micro apps may declare
+	_ = v.RegisterValidation("capability", func(fl validator.FieldLevel) bool {
+		return models.KnownCapabilities[fl.Field().String()]
+	})
+	return v
+}
 
 // Writes the given data as JSON to the HTTP response with the specified status code.
 func writeJSON(w http.ResponseWriter, status int, data any) error {
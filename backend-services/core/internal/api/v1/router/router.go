@@ -6,6 +6,7 @@ import (
 	"go-backend/internal/api/v1/handler"
 	"go-backend/internal/config"
 	"go-backend/internal/services"
+	notificationservice "go-backend/plugins/notification-service"
 
 	fileservice "go-backend/plugins/file-service"
 	userservice "go-backend/plugins/user-service"
@@ -15,13 +16,19 @@ import (
 )
 
 // NewUserRouter returns the http.Handler for user-authenticated routes (Asgardeo).
-func NewUserRouter(db *gorm.DB, fcmService services.NotificationService, fileService fileservice.FileService, userService userservice.UserService, cfg *config.Config) http.Handler {
+func NewUserRouter(db *gorm.DB, notificationService notificationservice.NotificationService, fileService fileservice.FileService, userService userservice.UserService, cfg *config.Config) http.Handler {
 	r := chi.NewRouter()
 
-	r.Mount("/micro-apps", MicroAppRoutes(db))
-	r.Mount("/device-tokens", DeviceTokenRoutes(db, fcmService))
+	r.Mount("/micro-apps", MicroAppRoutes(db, cfg, fileService))
+	r.Mount("/micro-app-categories", MicroAppCategoryRoutes(db, cfg))
+	r.Mount("/catalog-changes", CatalogChangeRoutes(db, fileService))
+	r.Mount("/catalog", CatalogTransferRoutes(db, cfg))
+	r.Mount("/analytics", AnalyticsRoutes(db))
+	r.Mount("/deep-links", DeepLinkRoutes(db))
+	r.Mount("/device-tokens", DeviceTokenRoutes(db, cfg, notificationService))
+	r.Mount("/notification-quotas", NotificationQuotaRoutes(db, cfg))
 	r.Mount("/token", TokenRoutes(db, cfg))
-	r.Mount("/files", fileRoutes(fileService))
+	r.Mount("/files", fileRoutes(cfg, fileService))
 	r.Mount("/users", userRoutes(db, userService))
 	r.Mount("/user-info", userInfoRoutes(userService))
 
@@ -29,10 +36,10 @@ func NewUserRouter(db *gorm.DB, fcmService services.NotificationService, fileSer
 }
 
 // NewServiceRouter returns the http.Handler for service-authenticated routes (Internal IDP).
-func NewServiceRouter(db *gorm.DB, fcmService services.NotificationService) http.Handler {
+func NewServiceRouter(db *gorm.DB, cfg *config.Config, notificationService notificationservice.NotificationService) http.Handler {
 	r := chi.NewRouter()
 
-	r.Mount("/notifications", NotificationRoutes(db, fcmService))
+	r.Mount("/notifications", NotificationRoutes(db, cfg, notificationService))
 
 	return r
 }
@@ -67,16 +74,37 @@ func PublicRoutes(fileService fileservice.FileService) http.Handler {
 }
 
 // MicroAppRoutes sets up a sub-router for all endpoints prefixed with /micro-apps.
-func MicroAppRoutes(db *gorm.DB) http.Handler {
+func MicroAppRoutes(db *gorm.DB, cfg *config.Config, fileService fileservice.FileService) http.Handler {
 	r := chi.NewRouter()
 
 	// Initialize Microapp Handlers
-	microappHandler := handler.NewMicroAppHandler(db)
-	microappVersionHandler := handler.NewMicroAppVersionHandler(db)
+	microappHandler := handler.NewMicroAppHandler(db, cfg, fileService)
+	microappVersionHandler := handler.NewMicroAppVersionHandler(db, cfg, fileService)
+	microappPromotionHandler := handler.NewMicroAppPromotionHandler(db)
+	microappPermissionHandler := handler.NewMicroAppPermissionHandler(db)
+	notificationTemplateHandler := handler.NewNotificationTemplateHandler(db)
 
-	// GET /micro-apps
+	// Writes to the live catalog outside the review workflow
+	live := r.With(handler.RequireLiveCatalogWrites(cfg))
+
+	// GET /micro-apps?category=xxx&tag=xxx&groupBy=category (or ?since={syncToken} for delta sync)
 	r.Get("/", microappHandler.GetAll)
 
+	// GET /micro-apps/featured
+	r.Get("/featured", microappHandler.GetFeatured)
+
+	// GET /micro-apps/promotions
+	r.Get("/promotions", microappPromotionHandler.GetAll)
+
+	// POST /micro-apps/promotions
+	live.Post("/promotions", microappPromotionHandler.Create)
+
+	// PUT /micro-apps/promotions/{promotionID}
+	live.Put("/promotions/{promotionID}", microappPromotionHandler.Update)
+
+	// DELETE /micro-apps/promotions/{promotionID}
+	live.Delete("/promotions/{promotionID}", microappPromotionHandler.Deactivate)
+
 	// GET /micro-apps/{appID}
 	r.Get("/{appID}", microappHandler.GetByID)
 
@@ -84,35 +112,243 @@ func MicroAppRoutes(db *gorm.DB) http.Handler {
 	r.Post("/", microappHandler.Upsert)
 
 	// PUT /micro-apps/deactivate/{appID}
-	r.Put("/deactivate/{appID}", microappHandler.Deactivate)
+	live.Put("/deactivate/{appID}", microappHandler.Deactivate)
+
+	// PUT /micro-apps/reactivate/{appID}
+	live.Put("/reactivate/{appID}", microappHandler.Reactivate)
+
+	// DELETE /micro-apps/{appID}?confirm={appID}
+	live.Delete("/{appID}", microappHandler.Delete)
 
 	// POST /micro-apps/{appID}/versions
 	r.Post("/{appID}/versions", microappVersionHandler.UpsertVersion)
 
+	// POST /micro-apps/{appID}/versions/publish (body: bundle zip containing manifest.json)
+	r.Post("/{appID}/versions/publish", microappVersionHandler.PublishVersion)
+
+	// GET /micro-apps/{appID}/versions/diff?from={build}&to={build}
+	r.Get("/{appID}/versions/diff", microappVersionHandler.DiffVersions)
+
+	// GET /micro-apps/{appID}/permissions
+	r.Get("/{appID}/permissions", microappPermissionHandler.GetAll)
+
+	// PUT /micro-apps/{appID}/permissions/{capability}
+	live.Put("/{appID}/permissions/{capability}", microappPermissionHandler.Review)
+
+	// GET /micro-apps/{appID}/config-overrides
+	r.Get("/{appID}/config-overrides", microappHandler.GetConfigOverrides)
+
+	// DELETE /micro-apps/{appID}/config-overrides?configKey=xxx&scopeType=xxx&scopeValue=xxx
+	live.Delete("/{appID}/config-overrides", microappHandler.DeactivateConfigOverride)
+
+	// GET /micro-apps/{appID}/deep-links
+	r.Get("/{appID}/deep-links", microappHandler.GetDeepLinks)
+
+	// POST /micro-apps/{appID}/deep-links
+	live.Post("/{appID}/deep-links", microappHandler.UpsertDeepLink)
+
+	// DELETE /micro-apps/{appID}/deep-links/{linkID}
+	live.Delete("/{appID}/deep-links/{linkID}", microappHandler.DeactivateDeepLink)
+
+	// GET /micro-apps/{appID}/config-schemas
+	r.Get("/{appID}/config-schemas", microappHandler.GetConfigSchemas)
+
+	// PUT /micro-apps/{appID}/config-schemas/{configKey}
+	live.Put("/{appID}/config-schemas/{configKey}", microappHandler.UpsertConfigSchema)
+
+	// DELETE /micro-apps/{appID}/config-schemas/{configKey}
+	live.Delete("/{appID}/config-schemas/{configKey}", microappHandler.DeactivateConfigSchema)
+
+	// GET /micro-apps/{appID}/notification-templates
+	r.Get("/{appID}/notification-templates", notificationTemplateHandler.GetAll)
+
+	// GET /micro-apps/{appID}/notification-templates/{templateKey}
+	r.Get("/{appID}/notification-templates/{templateKey}", notificationTemplateHandler.GetByKey)
+
+	// PUT /micro-apps/{appID}/notification-templates/{templateKey}
+	r.Put("/{appID}/notification-templates/{templateKey}", notificationTemplateHandler.Upsert)
+
+	// DELETE /micro-apps/{appID}/notification-templates/{templateKey}
+	r.Delete("/{appID}/notification-templates/{templateKey}", notificationTemplateHandler.Delete)
+
+	return r
+}
+
+// MicroAppCategoryRoutes sets up a sub-router for all endpoints prefixed with /micro-app-categories.
+func MicroAppCategoryRoutes(db *gorm.DB, cfg *config.Config) http.Handler {
+	r := chi.NewRouter()
+
+	categoryHandler := handler.NewMicroAppCategoryHandler(db)
+
+	// Writes to the live catalog outside the review workflow
+	live := r.With(handler.RequireLiveCatalogWrites(cfg))
+
+	// GET /micro-app-categories
+	r.Get("/", categoryHandler.GetAll)
+
+	// POST /micro-app-categories
+	live.Post("/", categoryHandler.Upsert)
+
+	// PUT /micro-app-categories/deactivate/{categoryID}
+	live.Put("/deactivate/{categoryID}", categoryHandler.Deactivate)
+
+	return r
+}
+
+// CatalogChangeRoutes sets up a sub-router for the draft, review and publish workflow of catalog changes.
+func CatalogChangeRoutes(db *gorm.DB, fileService fileservice.FileService) http.Handler {
+	r := chi.NewRouter()
+
+	changeHandler := handler.NewCatalogChangeHandler(db, fileService)
+
+	// GET /catalog-changes?status=xxx&appId=xxx
+	r.Get("/", changeHandler.GetAll)
+
+	// POST /catalog-changes
+	r.Post("/", changeHandler.Create)
+
+	// GET /catalog-changes/snapshots?appId=xxx
+	r.Get("/snapshots", changeHandler.GetSnapshots)
+
+	// POST /catalog-changes/snapshots/{snapshotID}/restore
+	r.Post("/snapshots/{snapshotID}/restore", changeHandler.RestoreSnapshot)
+
+	// GET /catalog-changes/{changeID}
+	r.Get("/{changeID}", changeHandler.GetByID)
+
+	// PUT /catalog-changes/{changeID}
+	r.Put("/{changeID}", changeHandler.Update)
+
+	// POST /catalog-changes/{changeID}/submit
+	r.Post("/{changeID}/submit", changeHandler.Submit)
+
+	// POST /catalog-changes/{changeID}/approve
+	r.Post("/{changeID}/approve", changeHandler.Approve)
+
+	// POST /catalog-changes/{changeID}/reject
+	r.Post("/{changeID}/reject", changeHandler.Reject)
+
+	// POST /catalog-changes/{changeID}/publish
+	r.Post("/{changeID}/publish", changeHandler.Publish)
+
+	return r
+}
+
+// CatalogTransferRoutes sets up a sub-router for exporting and importing the catalog between environments.
+func CatalogTransferRoutes(db *gorm.DB, cfg *config.Config) http.Handler {
+	r := chi.NewRouter()
+
+	transferHandler := handler.NewCatalogTransferHandler(db, cfg)
+
+	// GET /catalog/export?format=json|yaml&appId=xxx
+	r.Get("/export", transferHandler.Export)
+
+	// POST /catalog/import?dryRun=true&overwriteRoles=true&overwriteConfigs=true (body: JSON or YAML catalog document)
+	r.Post("/import", transferHandler.Import)
+
+	return r
+}
+
+// AnalyticsRoutes sets up a sub-router for micro app usage analytics endpoints.
+func AnalyticsRoutes(db *gorm.DB) http.Handler {
+	r := chi.NewRouter()
+
+	analyticsHandler := handler.NewAnalyticsHandler(db)
+
+	// POST /analytics/events
+	r.Post("/events", analyticsHandler.IngestEvents)
+
+	// GET /analytics/reports/daily?from=YYYY-MM-DD&to=YYYY-MM-DD&appId=xxx&by=app|group|version
+	r.Get("/reports/daily", analyticsHandler.GetDailyUsage)
+
+	// GET /analytics/reports/active-users?date=YYYY-MM-DD&appId=xxx&by=app|group|version
+	r.Get("/reports/active-users", analyticsHandler.GetActiveUsers)
+
+	return r
+}
+
+// DeepLinkRoutes sets up a sub-router for deep link endpoints.
+func DeepLinkRoutes(db *gorm.DB) http.Handler {
+	r := chi.NewRouter()
+
+	deepLinkHandler := handler.NewDeepLinkHandler(db)
+
+	// GET /deep-links/resolve?url=xxx
+	r.Get("/resolve", deepLinkHandler.Resolve)
+
 	return r
 }
 
 // DeviceTokenRoutes sets up a sub-router for device token endpoints
-func DeviceTokenRoutes(db *gorm.DB, fcmService services.NotificationService) http.Handler {
+func DeviceTokenRoutes(db *gorm.DB, cfg *config.Config, notificationService notificationservice.NotificationService) http.Handler {
 	r := chi.NewRouter()
 
-	notificationHandler := handler.NewNotificationHandler(db, fcmService)
+	notificationHandler := handler.NewNotificationHandler(db, cfg, notificationService)
 
 	// POST /device-tokens
 	r.Post("/", notificationHandler.RegisterDeviceToken)
 
+	// DELETE /device-tokens/{deviceID}
+	r.Delete("/{deviceID}", notificationHandler.UnregisterDeviceToken)
+
 	return r
 }
 
 // NotificationRoutes sets up a sub-router for notification endpoints
-func NotificationRoutes(db *gorm.DB, fcmService services.NotificationService) http.Handler {
+func NotificationRoutes(db *gorm.DB, cfg *config.Config, notificationService notificationservice.NotificationService) http.Handler {
 	r := chi.NewRouter()
 
-	notificationHandler := handler.NewNotificationHandler(db, fcmService)
+	notificationHandler := handler.NewNotificationHandler(db, cfg, notificationService)
+	templateHandler := handler.NewServiceNotificationTemplateHandler(db)
 
 	// POST /notifications/send
 	r.Post("/send", notificationHandler.SendNotification)
 
+	// POST /notifications/send-to-groups
+	r.Post("/send-to-groups", notificationHandler.SendToGroups)
+
+	// GET /notifications/jobs/{jobID}
+	r.Get("/jobs/{jobID}", notificationHandler.GetJob)
+
+	// GET /notifications/scheduled
+	r.Get("/scheduled", notificationHandler.GetScheduled)
+
+	// DELETE /notifications/scheduled/{jobID}
+	r.Delete("/scheduled/{jobID}", notificationHandler.CancelScheduled)
+
+	// GET /notifications/templates
+	r.Get("/templates", templateHandler.GetAll)
+
+	// GET /notifications/templates/{templateKey}
+	r.Get("/templates/{templateKey}", templateHandler.GetByKey)
+
+	// PUT /notifications/templates/{templateKey}
+	r.Put("/templates/{templateKey}", templateHandler.Upsert)
+
+	// DELETE /notifications/templates/{templateKey}
+	r.Delete("/templates/{templateKey}", templateHandler.Delete)
+
+	return r
+}
+
+// NotificationQuotaRoutes sets up a sub-router for viewing and overriding the notification quotas of micro apps.
+func NotificationQuotaRoutes(db *gorm.DB, cfg *config.Config) http.Handler {
+	r := chi.NewRouter()
+
+	quotaHandler := handler.NewNotificationQuotaHandler(db, cfg)
+
+	// GET /notification-quotas
+	r.Get("/", quotaHandler.GetAll)
+
+	// GET /notification-quotas/{appID}
+	r.Get("/{appID}", quotaHandler.GetByID)
+
+	// PUT /notification-quotas/{appID}
+	r.Put("/{appID}", quotaHandler.Update)
+
+	// DELETE /notification-quotas/{appID}
+	r.Delete("/{appID}", quotaHandler.Delete)
+
 	return r
 }
 
@@ -128,17 +364,19 @@ func TokenRoutes(db *gorm.DB, cfg *config.Config) http.Handler {
 	return r
 }
 
-// fileRoutes sets up a sub-router for file operations.
-func fileRoutes(fileService fileservice.FileService) http.Handler {
+// fileRoutes sets up a sub-router for file operations. Files back live micro app versions, so
+// writing them is a live catalog write.
+func fileRoutes(cfg *config.Config, fileService fileservice.FileService) http.Handler {
 	r := chi.NewRouter()
 
 	fileHandler := handler.NewFileHandler(fileService)
+	live := r.With(handler.RequireLiveCatalogWrites(cfg))
 
 	// POST /files?fileName=xxx
-	r.Post("/", fileHandler.UploadFile)
+	live.Post("/", fileHandler.UploadFile)
 
 	// DELETE /files?fileName=xxx
-	r.Delete("/", fileHandler.DeleteFile)
+	live.Delete("/", fileHandler.DeleteFile)
 
 	return r
 }
@@ -162,6 +400,9 @@ func userRoutes(db *gorm.DB, userService userservice.UserService) http.Handler {
 	// Initialize User Config Handler
 	userConfigHandler := handler.NewUserConfigHandler(db)
 	userHandler := handler.NewUserHandler(userService)
+	userLibraryHandler := handler.NewUserLibraryHandler(db)
+	notificationInboxHandler := handler.NewNotificationInboxHandler(db)
+	notificationPreferenceHandler := handler.NewNotificationPreferenceHandler(db)
 
 	// GET /users
 	r.Get("/", userHandler.GetAll)
@@ -178,5 +419,44 @@ func userRoutes(db *gorm.DB, userService userservice.UserService) http.Handler {
 	// POST /users/app-configs
 	r.Post("/app-configs", userConfigHandler.UpsertAppConfig)
 
+	// GET /users/micro-app-library
+	r.Get("/micro-app-library", userLibraryHandler.GetAll)
+
+	// PUT /users/micro-app-library/order
+	r.Put("/micro-app-library/order", userLibraryHandler.Reorder)
+
+	// PUT /users/micro-app-library/{appID}
+	r.Put("/micro-app-library/{appID}", userLibraryHandler.Update)
+
+	// DELETE /users/micro-app-library/{appID}
+	r.Delete("/micro-app-library/{appID}", userLibraryHandler.Remove)
+
+	// GET /users/notifications?limit=20&before=xxx&appId=xxx&unread=true
+	r.Get("/notifications", notificationInboxHandler.GetAll)
+
+	// GET /users/notifications/unread-count
+	r.Get("/notifications/unread-count", notificationInboxHandler.GetUnreadCount)
+
+	// POST /users/notifications/read-all
+	r.Post("/notifications/read-all", notificationInboxHandler.MarkAllRead)
+
+	// PUT /users/notifications/{notificationID}
+	r.Put("/notifications/{notificationID}", notificationInboxHandler.Update)
+
+	// DELETE /users/notifications/{notificationID}
+	r.Delete("/notifications/{notificationID}", notificationInboxHandler.Delete)
+
+	// GET /users/notification-preferences
+	r.Get("/notification-preferences", notificationPreferenceHandler.Get)
+
+	// PUT /users/notification-preferences
+	r.Put("/notification-preferences", notificationPreferenceHandler.Update)
+
+	// PUT /users/notification-preferences/mutes/{appID}
+	r.Put("/notification-preferences/mutes/{appID}", notificationPreferenceHandler.Mute)
+
+	// DELETE /users/notification-preferences/mutes/{appID}
+	r.Delete("/notification-preferences/mutes/{appID}", notificationPreferenceHandler.Unmute)
+
 	return r
 }
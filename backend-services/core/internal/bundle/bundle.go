@@ -0,0 +1,153 @@
+// Package bundle reads and validates micro app bundles (zip archives) uploaded for publishing.
+package bundle
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+)
+
+const (
+	// ManifestFile is the bundle-root file describing the micro app build
+	ManifestFile = "manifest.json"
+
+	// The super app loads index.html from the bundle root or from build/
+	indexFile      = "index.html"
+	buildIndexFile = "build/index.html"
+
+	// Upper bound for manifest.json, which only holds a handful of short fields
+	maxManifestSize = 64 << 10 // 64KB
+
+	// Upper bound for a single extracted icon, so a bogus manifest can't make us buffer a huge file
+	maxIconSize = 5 << 20 // 5MB
+)
+
+var iconExtensions = map[string]bool{".png": true, ".jpg": true, ".jpeg": true, ".svg": true, ".webp": true}
+
+// ErrInvalidBundle wraps every structural problem with an uploaded bundle.
+var ErrInvalidBundle = errors.New("invalid micro app bundle")
+
+// Manifest is the contents of manifest.json.
+type Manifest struct {
+	ID           string   `json:"id"`
+	Version      string   `json:"version"`
+	Build        int      `json:"build"`
+	Name         string   `json:"name"`
+	Icon         string   `json:"icon"`
+	Permissions  []string `json:"permissions"`
+	ReleaseNotes *string  `json:"releaseNotes,omitempty"`
+}
+
+// Bundle is a validated micro app package.
+type Bundle struct {
+	Manifest Manifest
+	// Icon is the content of the file the manifest's icon points at
+	Icon []byte
+	// IconExt is the icon's file extension including the dot, e.g. ".png"
+	IconExt string
+}
+
+// Parse validates the zip structure and reads the manifest and icon from it.
+func Parse(content []byte) (*Bundle, error) {
+	reader, err := zip.NewReader(bytes.NewReader(content), int64(len(content)))
+	if err != nil {
+		return nil, invalid("not a zip archive")
+	}
+
+	files := make(map[string]*zip.File, len(reader.File))
+	for _, f := range reader.File {
+		clean := path.Clean(f.Name)
+		if path.IsAbs(f.Name) || strings.Contains(f.Name, "\\") || clean == ".." || strings.HasPrefix(clean, "../") {
+			return nil, invalid("unsafe path %q", f.Name)
+		}
+		files[clean] = f
+	}
+
+	if files[indexFile] == nil && files[buildIndexFile] == nil {
+		return nil, invalid("%s not found at the bundle root or in build/", indexFile)
+	}
+
+	manifestFile := files[ManifestFile]
+	if manifestFile == nil {
+		return nil, invalid("%s not found at the bundle root", ManifestFile)
+	}
+	raw, err := readFile(manifestFile, maxManifestSize)
+	if err != nil {
+		return nil, err
+	}
+	var manifest Manifest
+	if err := json.Unmarshal(raw, &manifest); err != nil {
+		return nil, invalid("%s is not valid JSON", ManifestFile)
+	}
+	if err := manifest.validate(); err != nil {
+		return nil, err
+	}
+
+	iconPath := strings.TrimPrefix(path.Clean(manifest.Icon), "./")
+	iconFile := files[iconPath]
+	if iconFile == nil {
+		return nil, invalid("icon %q not found in bundle", manifest.Icon)
+	}
+	iconExt := strings.ToLower(path.Ext(iconPath))
+	if !iconExtensions[iconExt] {
+		return nil, invalid("icon %q must be a png, jpg, svg or webp image", manifest.Icon)
+	}
+	icon, err := readFile(iconFile, maxIconSize)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Bundle{Manifest: manifest, Icon: icon, IconExt: iconExt}, nil
+}
+
+func (m Manifest) validate() error {
+	var missing []string
+	if strings.TrimSpace(m.ID) == "" {
+		missing = append(missing, "id")
+	}
+	if strings.TrimSpace(m.Version) == "" {
+		missing = append(missing, "version")
+	}
+	if m.Build < 1 {
+		missing = append(missing, "build")
+	}
+	if strings.TrimSpace(m.Name) == "" {
+		missing = append(missing, "name")
+	}
+	if strings.TrimSpace(m.Icon) == "" {
+		missing = append(missing, "icon")
+	}
+	if len(missing) > 0 {
+		return invalid("%s is missing %s", ManifestFile, strings.Join(missing, ", "))
+	}
+	if len(m.Version) > 32 {
+		return invalid("version must be at most 32 characters")
+	}
+	return nil
+}
+
+func readFile(f *zip.File, limit int64) ([]byte, error) {
+	if f.UncompressedSize64 > uint64(limit) {
+		return nil, invalid("%s is too large", f.Name)
+	}
+	rc, err := f.Open()
+	if err != nil {
+		return nil, invalid("cannot read %s", f.Name)
+	}
+	defer rc.Close()
+
+	content, err := io.ReadAll(io.LimitReader(rc, limit+1))
+	if err != nil || int64(len(content)) > limit {
+		return nil, invalid("cannot read %s", f.Name)
+	}
+	return content, nil
+}
+
+func invalid(format string, args ...any) error {
+	return fmt.Errorf("%w: %s", ErrInvalidBundle, fmt.Sprintf(format, args...))
+}
@@ -0,0 +1,143 @@
+package bundle
+
+import (
+	"archive/zip"
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+)
+
+const validManifest = `{"id":"leave","version":"1.2.0","build":3,"name":"Leave","icon":"./icon.png","permissions":["camera"]}`
+
+// zipOf builds a zip archive holding the given files
+func zipOf(t *testing.T, files map[string]string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	w := zip.NewWriter(&buf)
+	for name, content := range files {
+		f, err := w.Create(name)
+		if err != nil {
+			t.Fatalf("create %s: %v", name, err)
+		}
+		if _, err := f.Write([]byte(content)); err != nil {
+			t.Fatalf("write %s: %v", name, err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("close zip: %v", err)
+	}
+	return buf.Bytes()
+}
+
+func TestParse(t *testing.T) {
+	b, err := Parse(zipOf(t, map[string]string{
+		"index.html":    "<html></html>",
+		"manifest.json": validManifest,
+		"icon.png":      "png",
+	}))
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	if b.Manifest.ID != "leave" || b.Manifest.Version != "1.2.0" || b.Manifest.Build != 3 || b.Manifest.Name != "Leave" {
+		t.Errorf("unexpected manifest %+v", b.Manifest)
+	}
+	if string(b.Icon) != "png" || b.IconExt != ".png" {
+		t.Errorf("icon = %q %q, want png .png", b.Icon, b.IconExt)
+	}
+}
+
+func TestParseBuildIndex(t *testing.T) {
+	_, err := Parse(zipOf(t, map[string]string{
+		"build/index.html": "<html></html>",
+		"manifest.json":    validManifest,
+		"icon.png":         "png",
+	}))
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+}
+
+func TestParseInvalid(t *testing.T) {
+	tests := []struct {
+		name  string
+		files map[string]string
+		want  string
+	}{
+		{
+			name:  "no index",
+			files: map[string]string{"manifest.json": validManifest, "icon.png": "png"},
+			want:  "index.html not found",
+		},
+		{
+			name:  "no manifest",
+			files: map[string]string{"index.html": "", "icon.png": "png"},
+			want:  "manifest.json not found",
+		},
+		{
+			name:  "manifest not JSON",
+			files: map[string]string{"index.html": "", "manifest.json": "{", "icon.png": "png"},
+			want:  "not valid JSON",
+		},
+		{
+			name:  "manifest missing fields",
+			files: map[string]string{"index.html": "", "manifest.json": `{"id":"leave","icon":"icon.png"}`, "icon.png": "png"},
+			want:  "missing version, build, name",
+		},
+		{
+			name: "version too long",
+			files: map[string]string{
+				"index.html":    "",
+				"manifest.json": `{"id":"leave","version":"` + strings.Repeat("1", 33) + `","build":1,"name":"Leave","icon":"icon.png"}`,
+				"icon.png":      "png",
+			},
+			want: "at most 32 characters",
+		},
+		{
+			name:  "manifest too large",
+			files: map[string]string{"index.html": "", "manifest.json": strings.Repeat(" ", maxManifestSize+1), "icon.png": "png"},
+			want:  "manifest.json is too large",
+		},
+		{
+			name:  "icon missing",
+			files: map[string]string{"index.html": "", "manifest.json": validManifest},
+			want:  `icon "./icon.png" not found`,
+		},
+		{
+			name: "icon not an image",
+			files: map[string]string{
+				"index.html":    "",
+				"manifest.json": `{"id":"leave","version":"1","build":1,"name":"Leave","icon":"icon.txt"}`,
+				"icon.txt":      "txt",
+			},
+			want: "must be a png, jpg, svg or webp image",
+		},
+		{
+			name:  "path traversal",
+			files: map[string]string{"index.html": "", "manifest.json": validManifest, "icon.png": "png", "../evil.js": ""},
+			want:  "unsafe path",
+		},
+		{
+			name:  "backslash path",
+			files: map[string]string{"index.html": "", "manifest.json": validManifest, "icon.png": "png", `dir\evil.js`: ""},
+			want:  "unsafe path",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Parse(zipOf(t, tt.files))
+			if !errors.Is(err, ErrInvalidBundle) {
+				t.Fatalf("err = %v, want ErrInvalidBundle", err)
+			}
+			if !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("err = %q, want it to mention %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseNotZip(t *testing.T) {
+	if _, err := Parse([]byte("not a zip")); !errors.Is(err, ErrInvalidBundle) {
+		t.Fatalf("err = %v, want ErrInvalidBundle", err)
+	}
+}
@@ -0,0 +1,92 @@
+// Package catalog tracks the catalog revision used for conditional GETs and delta sync, and
+// holds the catalog writes shared by the API and background jobs.
+package catalog
+
+import (
+	"time"
+
+	"go-backend/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// Touch bumps the catalog revision and stamps the new value on the given micro apps so that
+// delta sync clients pick them up. It also bumps the row version (ETag) of those apps. It must be called inside the transaction making the change,
+// which also serialises concurrent writers on the catalog_revision row until commit.
+// Calling it without app IDs only bumps the revision (e.g. for category changes).
+func Touch(tx *gorm.DB, appIDs ...string) (int64, error) {
+	if err := tx.Exec(
+		"INSERT INTO catalog_revision (id, revision) VALUES (?, 1) ON DUPLICATE KEY UPDATE revision = revision + 1",
+		models.CatalogRevisionID,
+	).Error; err != nil {
+		return 0, err
+	}
+
+	revision, err := CurrentRevision(tx)
+	if err != nil {
+		return 0, err
+	}
+
+	if len(appIDs) > 0 {
+		if err := tx.Model(&models.MicroApp{}).Where("micro_app_id IN ?", appIDs).
+			UpdateColumns(map[string]any{"revision": revision, "version": gorm.Expr("version + 1")}).Error; err != nil {
+			return 0, err
+		}
+	}
+
+	return revision, nil
+}
+
+// Tombstone bumps the catalog revision and records that the given micro app was permanently deleted,
+// together with the groups it had roles for. It must be called before the app's roles are deleted.
+func Tombstone(tx *gorm.DB, appID string) error {
+	var roles []string
+	if err := tx.Model(&models.MicroAppRole{}).Distinct("role").
+		Where("micro_app_id = ?", appID).Pluck("role", &roles).Error; err != nil {
+		return err
+	}
+
+	revision, err := Touch(tx)
+	if err != nil {
+		return err
+	}
+	return tx.Save(&models.CatalogTombstone{
+		MicroAppID: appID,
+		Revision:   revision,
+		Roles:      models.StringList(roles),
+		DeletedAt:  time.Now(),
+	}).Error
+}
+
+// CurrentRevision returns the latest committed catalog revision, or 0 if nothing has been recorded yet.
+func CurrentRevision(db *gorm.DB) (int64, error) {
+	var revisions []int64
+	if err := db.Model(&models.CatalogRevision{}).Where("id = ?", models.CatalogRevisionID).
+		Pluck("revision", &revisions).Error; err != nil {
+		return 0, err
+	}
+	if len(revisions) == 0 {
+		return 0, nil
+	}
+	return revisions[0], nil
+}
+
+// Deactivate takes a micro app out of the catalog. Its active versions, roles, configs and config
+// overrides are suspended rather than deactivated, so that reactivating the app restores them.
+// It must be called inside a transaction.
+func Deactivate(tx *gorm.DB, appID string) error {
+	if err := tx.Model(&models.MicroApp{}).Where("micro_app_id = ?", appID).
+		Update("active", models.StatusInactive).Error; err != nil {
+		return err
+	}
+	if _, err := Touch(tx, appID); err != nil {
+		return err
+	}
+	for _, child := range []any{&models.MicroAppVersion{}, &models.MicroAppRole{}, &models.MicroAppConfig{}, &models.MicroAppConfigOverride{}} {
+		if err := tx.Model(child).Where("micro_app_id = ? AND active = ?", appID, models.StatusActive).
+			Update("active", models.StatusSuspended).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,65 @@
+package catalog
+
+import (
+	"slices"
+	"testing"
+
+	"go-backend/internal/models"
+	"go-backend/internal/testdb"
+)
+
+func TestTouch(t *testing.T) {
+	db := testdb.Open(t)
+	app := models.MicroApp{MicroAppID: "revision-touch", Name: "Test app", CreatedBy: "admin@example.com", RowVersion: 1}
+	if err := db.Create(&app).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	before, err := CurrentRevision(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	revision, err := Touch(db, app.MicroAppID)
+	if err != nil {
+		t.Fatalf("Touch: %v", err)
+	}
+	if revision != before+1 {
+		t.Errorf("revision = %d, want %d", revision, before+1)
+	}
+
+	if err := db.First(&app, app.ID).Error; err != nil {
+		t.Fatal(err)
+	}
+	if app.Revision != revision || app.RowVersion != 2 {
+		t.Errorf("app revision %d version %d, want %d and 2", app.Revision, app.RowVersion, revision)
+	}
+}
+
+func TestTombstone(t *testing.T) {
+	db := testdb.Open(t)
+	app := models.MicroApp{MicroAppID: "revision-tombstone", Name: "Test app", CreatedBy: "admin@example.com"}
+	if err := db.Create(&app).Error; err != nil {
+		t.Fatal(err)
+	}
+	for _, role := range []string{"it", "hr"} {
+		if err := db.Create(&models.MicroAppRole{MicroAppID: "revision-tombstone", Role: role, CreatedBy: "admin@example.com"}).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := Tombstone(db, "revision-tombstone"); err != nil {
+		t.Fatalf("Tombstone: %v", err)
+	}
+	var tombstone models.CatalogTombstone
+	if err := db.First(&tombstone, "micro_app_id = ?", "revision-tombstone").Error; err != nil {
+		t.Fatal(err)
+	}
+	current, err := CurrentRevision(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	roles := slices.Sorted(slices.Values(tombstone.Roles))
+	if tombstone.Revision != current || !slices.Equal(roles, []string{"hr", "it"}) {
+		t.Errorf("tombstone at %d with roles %v, want %d with hr and it", tombstone.Revision, roles, current)
+	}
+}
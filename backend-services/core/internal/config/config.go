@@ -4,14 +4,16 @@ import (
 	"fmt"
 	"log/slog"
 	"os"
+	"strconv"
 	"strings"
 
 	"github.com/joho/godotenv"
 )
 
 const (
-	fileServiceConfigPrefix = "FILE_SERVICE_"
-	userServiceConfigPrefix = "USER_SERVICE_"
+	fileServiceConfigPrefix         = "FILE_SERVICE_"
+	userServiceConfigPrefix         = "USER_SERVICE_"
+	notificationServiceConfigPrefix = "NOTIFICATION_SERVICE_"
 )
 
 type Config struct {
@@ -27,6 +29,35 @@ type Config struct {
 	DBConnectRetries  int
 	ServerPort        string
 
+	// AppEnvironment selects environment-scoped micro app config overrides. Only users in one
+	// of the AppEnvironmentGroups may pick another environment with the X-App-Environment header.
+	AppEnvironment       string
+	AppEnvironmentGroups []string
+
+	// CatalogReviewRequired disables direct catalog writes; micro app changes must then be
+	// drafted, approved by a second admin and published through /catalog-changes
+	CatalogReviewRequired bool
+
+	// How often scheduled publish and expiry dates of micro apps and versions are applied
+	CatalogSchedulerIntervalSec int
+
+	// How often micro app usage events are rolled up, and how long raw events are kept
+	AnalyticsRollupIntervalSec int
+	AnalyticsRetentionDays     int
+
+	// Size of the notification worker pool (0 disables sending), how often a failed send is
+	// tried, and the wait after the first failure, which doubles on each further one
+	NotificationWorkers      int
+	NotificationMaxAttempts  int
+	NotificationRetryBaseSec int
+
+	// Default send limits of a micro app: send requests per minute, notifications per UTC day
+	// and notifications to one user per UTC day (0, the default, lifts a limit). Admins can
+	// override them per micro app through /notification-quotas.
+	NotificationQuotaPerMinute          int
+	NotificationQuotaPerDay             int
+	NotificationQuotaPerRecipientPerDay int
+
 	FirebaseCredentialsPath string
 
 	// External IDP (Asgardeo) - for user authentication
@@ -45,6 +76,9 @@ type Config struct {
 	// User Service
 	UserServiceType string
 
+	// Notification Service
+	NotificationServiceType string
+
 	// RawEnv stores all environment variables for plugins
 	RawEnv map[string]any
 }
@@ -77,6 +111,21 @@ func Load() *Config {
 		DBConnectRetries:  getEnvInt("DB_CONNECT_RETRIES", 5),
 		ServerPort:        getEnv("SERVER_PORT", "9090"),
 
+		AppEnvironment:        getEnv("APP_ENVIRONMENT", ""),
+		AppEnvironmentGroups:  getEnvList("APP_ENVIRONMENT_GROUPS"),
+		CatalogReviewRequired: getEnvBool("CATALOG_REVIEW_REQUIRED", false),
+
+		CatalogSchedulerIntervalSec: getEnvInt("CATALOG_SCHEDULER_INTERVAL_SEC", 60),
+		AnalyticsRollupIntervalSec:  getEnvInt("ANALYTICS_ROLLUP_INTERVAL_SEC", 3600),
+		AnalyticsRetentionDays:      getEnvInt("ANALYTICS_RETENTION_DAYS", 90),
+		NotificationWorkers:         getEnvInt("NOTIFICATION_WORKERS", 4),
+		NotificationMaxAttempts:     getEnvInt("NOTIFICATION_MAX_ATTEMPTS", 5),
+		NotificationRetryBaseSec:    getEnvInt("NOTIFICATION_RETRY_BASE_SEC", 30),
+
+		NotificationQuotaPerMinute:          getEnvInt("NOTIFICATION_QUOTA_PER_MINUTE", 0),
+		NotificationQuotaPerDay:             getEnvInt("NOTIFICATION_QUOTA_PER_DAY", 0),
+		NotificationQuotaPerRecipientPerDay: getEnvInt("NOTIFICATION_QUOTA_PER_RECIPIENT_PER_DAY", 0),
+
 		FirebaseCredentialsPath: getEnv("FIREBASE_CREDENTIALS_PATH", ""),
 
 		// External IDP (Asgardeo)
@@ -95,6 +144,9 @@ func Load() *Config {
 		// User Service
 		UserServiceType: getEnv("USER_SERVICE_TYPE", "db"),
 
+		// Notification Service
+		NotificationServiceType: getEnv("NOTIFICATION_SERVICE_TYPE", "fcm"),
+
 		RawEnv: rawEnv,
 	}
 
@@ -121,6 +173,17 @@ func getEnvRequired(key string) string {
 	return value
 }
 
+func getEnvBool(key string, fallback bool) bool {
+	if value := os.Getenv(key); value != "" {
+		boolValue, err := strconv.ParseBool(value)
+		if err == nil {
+			return boolValue
+		}
+		slog.Warn("Invalid boolean value for environment variable, using default", "key", key, "value", value, "default", fallback)
+	}
+	return fallback
+}
+
 func getEnvInt(key string, fallback int) int {
 	if value := os.Getenv(key); value != "" {
 		var intValue int
@@ -132,6 +195,17 @@ func getEnvInt(key string, fallback int) int {
 	return fallback
 }
 
+// getEnvList splits a comma-separated variable, dropping blank entries
+func getEnvList(key string) []string {
+	var values []string
+	for value := range strings.SplitSeq(os.Getenv(key), ",") {
+		if value = strings.TrimSpace(value); value != "" {
+			values = append(values, value)
+		}
+	}
+	return values
+}
+
 // get file service config
 func (c *Config) GetFileServiceConfig() map[string]any {
 	return c.GetPluginConfig(fileServiceConfigPrefix)
@@ -142,6 +216,11 @@ func (c *Config) GetUserServiceConfig() map[string]any {
 	return c.GetPluginConfig(userServiceConfigPrefix)
 }
 
+// get notification service config
+func (c *Config) GetNotificationServiceConfig() map[string]any {
+	return c.GetPluginConfig(notificationServiceConfigPrefix)
+}
+
 // GetPluginConfig returns a map of environment variables that start with the given prefix.
 func (c *Config) GetPluginConfig(prefix string) map[string]any {
 	filtered := make(map[string]any)
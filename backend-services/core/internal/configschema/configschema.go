@@ -0,0 +1,95 @@
+// Package configschema validates micro app config values against their registered JSON Schema.
+package configschema
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/santhosh-tekuri/jsonschema/v6"
+	"golang.org/x/text/language"
+	"golang.org/x/text/message"
+)
+
+// Resource name the schema is compiled under; it only shows up in compiler errors.
+const schemaResource = "config-schema.json"
+
+var printer = message.NewPrinter(language.English)
+
+// FieldError describes one violation. Pointer is the JSON Pointer of the offending
+// value within the config value ("" for the value itself).
+type FieldError struct {
+	Pointer string
+	Message string
+}
+
+// Compile parses and compiles a JSON Schema document.
+func Compile(raw []byte) (*jsonschema.Schema, error) {
+	doc, err := jsonschema.UnmarshalJSON(bytes.NewReader(raw))
+	if err != nil {
+		return nil, fmt.Errorf("schema is not valid JSON: %w", err)
+	}
+
+	c := jsonschema.NewCompiler()
+	if err := c.AddResource(schemaResource, doc); err != nil {
+		return nil, err
+	}
+	schema, err := c.Compile(schemaResource)
+	if err != nil {
+		return nil, fmt.Errorf("invalid JSON Schema: %w", err)
+	}
+	return schema, nil
+}
+
+// Validate checks a JSON value against the schema and returns every leaf violation,
+// ordered by pointer. A nil result means the value is valid.
+func Validate(schema *jsonschema.Schema, value []byte) []FieldError {
+	inst, err := jsonschema.UnmarshalJSON(bytes.NewReader(value))
+	if err != nil {
+		return []FieldError{{Message: "value is not valid JSON"}}
+	}
+
+	err = schema.Validate(inst)
+	if err == nil {
+		return nil
+	}
+	validationErr, ok := err.(*jsonschema.ValidationError)
+	if !ok {
+		return []FieldError{{Message: err.Error()}}
+	}
+
+	var fieldErrors []FieldError
+	collect(validationErr, &fieldErrors)
+	sort.SliceStable(fieldErrors, func(i, j int) bool {
+		return fieldErrors[i].Pointer < fieldErrors[j].Pointer
+	})
+	return fieldErrors
+}
+
+// collect walks the error tree down to the causes that actually point at a value.
+func collect(err *jsonschema.ValidationError, out *[]FieldError) {
+	if len(err.Causes) == 0 {
+		*out = append(*out, FieldError{
+			Pointer: pointer(err.InstanceLocation),
+			Message: err.ErrorKind.LocalizedString(printer),
+		})
+		return
+	}
+	for _, cause := range err.Causes {
+		collect(cause, out)
+	}
+}
+
+func pointer(tokens []string) string {
+	if len(tokens) == 0 {
+		return ""
+	}
+	escaper := strings.NewReplacer("~", "~0", "/", "~1")
+	var sb strings.Builder
+	for _, token := range tokens {
+		sb.WriteByte('/')
+		sb.WriteString(escaper.Replace(token))
+	}
+	return sb.String()
+}
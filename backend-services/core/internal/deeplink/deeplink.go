@@ -0,0 +1,174 @@
+// Package deeplink matches links against the URL patterns micro apps register, such as
+// /leave/requests/{id}, and builds the in-app path a matched link opens.
+package deeplink
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// ErrInvalidPattern is wrapped by every pattern and target path validation error.
+var ErrInvalidPattern = errors.New("invalid deep link pattern")
+
+var paramName = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+type segment struct {
+	literal string
+	param   string
+}
+
+// Pattern is a parsed URL pattern. Each path segment is either literal or a {name}
+// placeholder matching exactly one segment.
+type Pattern struct {
+	segments []segment
+}
+
+// Parse parses a URL pattern. Patterns start with a slash, have no empty segments and
+// use each placeholder name once.
+func Parse(pattern string) (*Pattern, error) {
+	if !strings.HasPrefix(pattern, "/") {
+		return nil, fmt.Errorf("%w: %q must start with /", ErrInvalidPattern, pattern)
+	}
+	if strings.ContainsAny(pattern, "?#") {
+		return nil, fmt.Errorf("%w: %q must not contain a query or fragment", ErrInvalidPattern, pattern)
+	}
+
+	p := &Pattern{}
+	seen := make(map[string]bool)
+	for _, part := range splitPath(pattern) {
+		if part == "" {
+			return nil, fmt.Errorf("%w: %q has an empty segment", ErrInvalidPattern, pattern)
+		}
+		name, isParam := placeholder(part)
+		if !isParam {
+			if strings.ContainsAny(part, "{}") {
+				return nil, fmt.Errorf("%w: segment %q mixes text and placeholders", ErrInvalidPattern, part)
+			}
+			p.segments = append(p.segments, segment{literal: part})
+			continue
+		}
+		if !paramName.MatchString(name) {
+			return nil, fmt.Errorf("%w: invalid placeholder name %q", ErrInvalidPattern, name)
+		}
+		if seen[name] {
+			return nil, fmt.Errorf("%w: placeholder %q is used twice", ErrInvalidPattern, name)
+		}
+		seen[name] = true
+		p.segments = append(p.segments, segment{param: name})
+	}
+	if len(p.segments) == 0 {
+		return nil, fmt.Errorf("%w: %q has no segments", ErrInvalidPattern, pattern)
+	}
+	return p, nil
+}
+
+// Match reports whether path matches the pattern and returns the placeholder values.
+func (p *Pattern) Match(path string) (map[string]string, bool) {
+	parts := splitPath(path)
+	if len(parts) != len(p.segments) {
+		return nil, false
+	}
+	params := make(map[string]string)
+	for i, s := range p.segments {
+		if s.param != "" {
+			if parts[i] == "" {
+				return nil, false
+			}
+			value, err := url.PathUnescape(parts[i])
+			if err != nil {
+				return nil, false
+			}
+			params[s.param] = value
+			continue
+		}
+		if parts[i] != s.literal {
+			return nil, false
+		}
+	}
+	return params, true
+}
+
+// Specificity is the number of literal segments. When several patterns match a link the most
+// specific one wins, so /leave/requests/new beats /leave/requests/{id}.
+func (p *Pattern) Specificity() int {
+	n := 0
+	for _, s := range p.segments {
+		if s.param == "" {
+			n++
+		}
+	}
+	return n
+}
+
+// Params returns the placeholder names of the pattern.
+func (p *Pattern) Params() []string {
+	var names []string
+	for _, s := range p.segments {
+		if s.param != "" {
+			names = append(names, s.param)
+		}
+	}
+	return names
+}
+
+// ValidateTarget checks that a target path template only uses placeholders of the pattern.
+func (p *Pattern) ValidateTarget(target string) error {
+	if !strings.HasPrefix(target, "/") {
+		return fmt.Errorf("%w: target path %q must start with /", ErrInvalidPattern, target)
+	}
+	names := make(map[string]bool)
+	for _, n := range p.Params() {
+		names[n] = true
+	}
+	for _, part := range splitPath(target) {
+		if name, isParam := placeholder(part); isParam && !names[name] {
+			return fmt.Errorf("%w: target path uses unknown placeholder %q", ErrInvalidPattern, name)
+		}
+	}
+	return nil
+}
+
+// Expand fills the placeholders of a target path template with the matched values.
+func Expand(target string, params map[string]string) string {
+	parts := splitPath(target)
+	for i, part := range parts {
+		if name, isParam := placeholder(part); isParam {
+			parts[i] = url.PathEscape(params[name])
+		}
+	}
+	return "/" + strings.Join(parts, "/")
+}
+
+// SplitLink returns the path and raw query of a link. Links may be absolute web URLs, custom
+// scheme URLs (superapp://leave/requests/1, where the host is the first segment) or plain paths.
+func SplitLink(link string) (string, string, error) {
+	u, err := url.Parse(link)
+	if err != nil {
+		return "", "", fmt.Errorf("malformed link: %w", err)
+	}
+	path := u.EscapedPath()
+	if u.Scheme != "" && u.Scheme != "http" && u.Scheme != "https" && u.Host != "" {
+		path = "/" + u.Host + path
+	}
+	if path == "" || !strings.HasPrefix(path, "/") {
+		return "", "", fmt.Errorf("link %q has no path", link)
+	}
+	if len(path) > 1 {
+		path = strings.TrimSuffix(path, "/")
+	}
+	return path, u.RawQuery, nil
+}
+
+func splitPath(path string) []string {
+	return strings.Split(strings.TrimPrefix(path, "/"), "/")
+}
+
+func placeholder(part string) (string, bool) {
+	if len(part) > 2 && strings.HasPrefix(part, "{") && strings.HasSuffix(part, "}") {
+		return part[1 : len(part)-1], true
+	}
+	return "", false
+}
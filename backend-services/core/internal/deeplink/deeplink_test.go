@@ -0,0 +1,106 @@
+package deeplink
+
+import (
+	"errors"
+	"maps"
+	"testing"
+)
+
+func TestParseInvalid(t *testing.T) {
+	for _, pattern := range []string{
+		"leave/{id}",
+		"/leave?tab=1",
+		"/leave//{id}",
+		"/leave/req{id}",
+		"/leave/{1id}",
+		"/leave/{id}/{id}",
+		"/",
+	} {
+		if _, err := Parse(pattern); !errors.Is(err, ErrInvalidPattern) {
+			t.Errorf("Parse(%q) err = %v, want ErrInvalidPattern", pattern, err)
+		}
+	}
+}
+
+func TestMatch(t *testing.T) {
+	tests := []struct {
+		pattern string
+		path    string
+		want    map[string]string
+		ok      bool
+	}{
+		{"/leave/requests/{id}", "/leave/requests/42", map[string]string{"id": "42"}, true},
+		{"/leave/requests/{id}", "/leave/requests/a%20b", map[string]string{"id": "a b"}, true},
+		{"/leave/requests/{id}", "/leave/requests", nil, false},
+		{"/leave/requests/{id}", "/leave/requests/42/edit", nil, false},
+		{"/leave/requests/{id}", "/leave/approvals/42", nil, false},
+		{"/leave/{kind}/{id}", "/leave/requests/42", map[string]string{"kind": "requests", "id": "42"}, true},
+		{"/leave/requests/new", "/leave/requests/new", map[string]string{}, true},
+	}
+	for _, tt := range tests {
+		p, err := Parse(tt.pattern)
+		if err != nil {
+			t.Fatalf("Parse(%q): %v", tt.pattern, err)
+		}
+		params, ok := p.Match(tt.path)
+		if ok != tt.ok || !maps.Equal(params, tt.want) {
+			t.Errorf("%q.Match(%q) = %v, %v; want %v, %v", tt.pattern, tt.path, params, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestSpecificity(t *testing.T) {
+	literal, _ := Parse("/leave/requests/new")
+	param, _ := Parse("/leave/requests/{id}")
+	if literal.Specificity() <= param.Specificity() {
+		t.Errorf("specificity %d of a literal pattern should beat %d", literal.Specificity(), param.Specificity())
+	}
+}
+
+func TestValidateTarget(t *testing.T) {
+	p, _ := Parse("/leave/requests/{id}")
+	if err := p.ValidateTarget("/requests/{id}/view"); err != nil {
+		t.Errorf("ValidateTarget: %v", err)
+	}
+	if err := p.ValidateTarget("/requests/{other}"); !errors.Is(err, ErrInvalidPattern) {
+		t.Errorf("unknown placeholder err = %v, want ErrInvalidPattern", err)
+	}
+	if err := p.ValidateTarget("requests"); !errors.Is(err, ErrInvalidPattern) {
+		t.Errorf("relative target err = %v, want ErrInvalidPattern", err)
+	}
+}
+
+func TestExpand(t *testing.T) {
+	got := Expand("/requests/{id}/view", map[string]string{"id": "a/b"})
+	if want := "/requests/a%2Fb/view"; got != want {
+		t.Errorf("Expand = %q, want %q", got, want)
+	}
+}
+
+func TestSplitLink(t *testing.T) {
+	tests := []struct {
+		link      string
+		path      string
+		query     string
+		wantError bool
+	}{
+		{link: "https://example.com/leave/requests/1?tab=2", path: "/leave/requests/1", query: "tab=2"},
+		{link: "superapp://leave/requests/1", path: "/leave/requests/1"},
+		{link: "/leave/requests/1/", path: "/leave/requests/1"},
+		{link: "/", path: "/"},
+		{link: "leave", wantError: true},
+		{link: "%zz", wantError: true},
+	}
+	for _, tt := range tests {
+		path, query, err := SplitLink(tt.link)
+		if tt.wantError {
+			if err == nil {
+				t.Errorf("SplitLink(%q) succeeded, want an error", tt.link)
+			}
+			continue
+		}
+		if err != nil || path != tt.path || query != tt.query {
+			t.Errorf("SplitLink(%q) = %q, %q, %v; want %q, %q", tt.link, path, query, err, tt.path, tt.query)
+		}
+	}
+}
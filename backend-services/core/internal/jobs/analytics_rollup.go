@@ -0,0 +1,108 @@
+package jobs
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"go-backend/internal/models"
+
+	"gorm.io/gorm"
+)
+
+const rollupDayLayout = "2006-01-02"
+
+// AnalyticsRollup aggregates raw micro app usage events into the daily tables read by the
+// reporting endpoints, and purges raw events once they are past the retention period.
+type AnalyticsRollup struct {
+	db            *gorm.DB
+	interval      time.Duration
+	retentionDays int
+}
+
+// NewAnalyticsRollup creates a rollup job. A non-positive retentionDays keeps raw events forever.
+func NewAnalyticsRollup(db *gorm.DB, interval time.Duration, retentionDays int) *AnalyticsRollup {
+	return &AnalyticsRollup{db: db, interval: interval, retentionDays: retentionDays}
+}
+
+// Start runs the rollup in the background until ctx is cancelled. A non-positive interval
+// disables it.
+func (a *AnalyticsRollup) Start(ctx context.Context) {
+	if a.interval <= 0 {
+		slog.Warn("Analytics rollup disabled", "interval", a.interval)
+		return
+	}
+
+	go func() {
+		ticker := time.NewTicker(a.interval)
+		defer ticker.Stop()
+
+		slog.Info("Analytics rollup started", "interval", a.interval)
+		for {
+			a.RunOnce(time.Now())
+			select {
+			case <-ctx.Done():
+				slog.Info("Analytics rollup stopped")
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// RunOnce recomputes the aggregates of today and the previous models.AnalyticsLateDays days,
+// then purges expired raw events. Each day is rebuilt from scratch, so running it repeatedly or
+// on several replicas is safe.
+func (a *AnalyticsRollup) RunOnce(now time.Time) {
+	start := models.AnalyticsRollupStart(now)
+	for day := start; !day.After(now.UTC()); day = day.AddDate(0, 0, 1) {
+		if err := a.rollupDay(day); err != nil {
+			slog.Error("Failed to roll up analytics", "error", err, "day", day.Format(rollupDayLayout))
+		}
+	}
+
+	if a.retentionDays > 0 {
+		cutoff := now.UTC().Truncate(24*time.Hour).AddDate(0, 0, -a.retentionDays)
+		result := a.db.Where("occurred_at < ?", cutoff).Delete(&models.AnalyticsEvent{})
+		if result.Error != nil {
+			slog.Error("Failed to purge analytics events", "error", result.Error)
+		} else if result.RowsAffected > 0 {
+			slog.Info("Purged analytics events", "count", result.RowsAffected, "before", cutoff)
+		}
+	}
+}
+
+func (a *AnalyticsRollup) rollupDay(day time.Time) error {
+	dayValue := day.Format(rollupDayLayout)
+	from, to := day, day.AddDate(0, 0, 1)
+
+	return a.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("day = ?", dayValue).Delete(&models.AnalyticsDailyUser{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Exec(`INSERT INTO analytics_daily_user (day, micro_app_id, version, user_email)
+			SELECT DISTINCT ?, micro_app_id, version, user_email
+			FROM analytics_event
+			WHERE occurred_at >= ? AND occurred_at < ?`, dayValue, from, to).Error; err != nil {
+			return err
+		}
+
+		if err := tx.Where("day = ?", dayValue).Delete(&models.AnalyticsDailyApp{}).Error; err != nil {
+			return err
+		}
+		return tx.Exec(`INSERT INTO analytics_daily_app
+				(day, micro_app_id, version, launches, sessions, session_ms, errors, active_users)
+			SELECT ?, micro_app_id, version,
+				SUM(event_type = ?), SUM(event_type = ?),
+				COALESCE(SUM(CASE WHEN event_type = ? THEN duration_ms END), 0),
+				SUM(event_type = ?), COUNT(DISTINCT user_email)
+			FROM analytics_event
+			WHERE occurred_at >= ? AND occurred_at < ?
+			GROUP BY micro_app_id, version`,
+			dayValue,
+			models.AnalyticsEventLaunch, models.AnalyticsEventSession,
+			models.AnalyticsEventSession,
+			models.AnalyticsEventError,
+			from, to).Error
+	})
+}
@@ -0,0 +1,59 @@
+package jobs
+
+import (
+	"testing"
+	"time"
+
+	"go-backend/internal/models"
+	"go-backend/internal/testdb"
+)
+
+func TestAnalyticsRollup(t *testing.T) {
+	db := testdb.Open(t)
+	now := time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)
+	duration := int64(3000)
+	events := []models.AnalyticsEvent{
+		{MicroAppID: "rollup-test", UserEmail: "a@example.com", EventType: models.AnalyticsEventLaunch, Version: "1.0", OccurredAt: now.Add(-time.Hour)},
+		{MicroAppID: "rollup-test", UserEmail: "a@example.com", EventType: models.AnalyticsEventLaunch, Version: "1.0", OccurredAt: now.Add(-time.Minute)},
+		{MicroAppID: "rollup-test", UserEmail: "b@example.com", EventType: models.AnalyticsEventSession, Version: "1.0", DurationMs: &duration, OccurredAt: now.Add(-time.Hour)},
+		{MicroAppID: "rollup-test", UserEmail: "b@example.com", EventType: models.AnalyticsEventError, Version: "1.0", OccurredAt: now.Add(-time.Hour)},
+		// The day before is rolled up on its own
+		{MicroAppID: "rollup-test", UserEmail: "c@example.com", EventType: models.AnalyticsEventLaunch, Version: "1.0", OccurredAt: now.AddDate(0, 0, -1)},
+		// Past the retention period
+		{MicroAppID: "rollup-test", UserEmail: "d@example.com", EventType: models.AnalyticsEventLaunch, Version: "1.0", OccurredAt: now.AddDate(0, 0, -100)},
+	}
+	if err := db.Create(&events).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	rollup := NewAnalyticsRollup(db, time.Hour, 90)
+	rollup.RunOnce(now)
+	// Rebuilding a day must not double count it
+	rollup.RunOnce(now)
+
+	var today models.AnalyticsDailyApp
+	if err := db.Where("micro_app_id = ? AND day = ?", "rollup-test", "2026-10-18").First(&today).Error; err != nil {
+		t.Fatalf("today's rollup: %v", err)
+	}
+	want := models.AnalyticsDailyApp{Launches: 2, Sessions: 1, SessionMs: 3000, Errors: 1, ActiveUsers: 2}
+	if today.Launches != want.Launches || today.Sessions != want.Sessions || today.SessionMs != want.SessionMs ||
+		today.Errors != want.Errors || today.ActiveUsers != want.ActiveUsers {
+		t.Errorf("today = %+v, want %+v", today, want)
+	}
+
+	var users int64
+	if err := db.Model(&models.AnalyticsDailyUser{}).Where("micro_app_id = ?", "rollup-test").Count(&users).Error; err != nil {
+		t.Fatal(err)
+	}
+	if users != 3 {
+		t.Errorf("got %d daily users, want a and b today and c yesterday", users)
+	}
+
+	var kept int64
+	if err := db.Model(&models.AnalyticsEvent{}).Where("micro_app_id = ?", "rollup-test").Count(&kept).Error; err != nil {
+		t.Fatal(err)
+	}
+	if kept != int64(len(events)-1) {
+		t.Errorf("kept %d raw events, want the expired one purged", kept)
+	}
+}
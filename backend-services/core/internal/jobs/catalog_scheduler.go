@@ -0,0 +1,186 @@
+// Package jobs contains background workers started alongside the HTTP server.
+package jobs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"go-backend/internal/catalog"
+	"go-backend/internal/models"
+	"go-backend/internal/notification"
+
+	"gorm.io/gorm"
+)
+
+const (
+	// Data keys sent with catalog push notifications
+	dataKeyMicroappID = "microappId"
+	dataKeyType       = "type"
+	dataTypeCatalog   = "catalog_update"
+
+	notificationStatusSent = "sent"
+)
+
+// CatalogScheduler opens and closes the schedule windows of micro apps and versions. Catalog
+// queries already hide rows outside their window; the scheduler makes the change visible to
+// caching clients by bumping the catalog revision, deactivates expired rows, and queues the
+// optional publish notification for the notification worker.
+type CatalogScheduler struct {
+	db       *gorm.DB
+	quotas   notification.Limits
+	interval time.Duration
+}
+
+// NewCatalogScheduler creates a scheduler. Publish notifications count against the notification
+// quotas of the micro app, with quotas as the defaults.
+func NewCatalogScheduler(db *gorm.DB, quotas notification.Limits, interval time.Duration) *CatalogScheduler {
+	return &CatalogScheduler{db: db, quotas: quotas, interval: interval}
+}
+
+// Start runs the scheduler in the background until ctx is cancelled. A non-positive
+// interval disables it.
+func (s *CatalogScheduler) Start(ctx context.Context) {
+	if s.interval <= 0 {
+		slog.Warn("Catalog scheduler disabled", "interval", s.interval)
+		return
+	}
+
+	go func() {
+		ticker := time.NewTicker(s.interval)
+		defer ticker.Stop()
+
+		slog.Info("Catalog scheduler started", "interval", s.interval)
+		for {
+			s.RunOnce(ctx, time.Now())
+			select {
+			case <-ctx.Done():
+				slog.Info("Catalog scheduler stopped")
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// RunOnce handles every schedule transition due at now.
+func (s *CatalogScheduler) RunOnce(ctx context.Context, now time.Time) {
+	var apps []models.MicroApp
+	if err := s.db.Where("publish_at <= ? AND publish_handled_at IS NULL", now).Find(&apps).Error; err != nil {
+		slog.Error("Failed to fetch scheduled micro apps", "error", err)
+	}
+	for _, app := range apps {
+		s.claim(&models.MicroApp{}, app.ID, "publish_handled_at", now, app.MicroAppID, func(tx *gorm.DB) error {
+			if !app.NotifyOnPublish {
+				return nil
+			}
+			return s.notify(tx, app.MicroAppID, fmt.Sprintf("%s is now available", app.Name), "Open the app to start using it.")
+		})
+	}
+
+	var versions []models.MicroAppVersion
+	if err := s.db.Where("publish_at <= ? AND publish_handled_at IS NULL", now).Find(&versions).Error; err != nil {
+		slog.Error("Failed to fetch scheduled micro app versions", "error", err)
+	}
+	for _, v := range versions {
+		s.claim(&models.MicroAppVersion{}, v.ID, "publish_handled_at", now, v.MicroAppID, func(tx *gorm.DB) error {
+			if !v.NotifyOnPublish {
+				return nil
+			}
+			var app models.MicroApp
+			if err := tx.Where("micro_app_id = ?", v.MicroAppID).First(&app).Error; err != nil {
+				return err
+			}
+			return s.notify(tx, v.MicroAppID, fmt.Sprintf("%s %s is available", app.Name, v.Version), "Update the app to get the latest version.")
+		})
+	}
+
+	// An expired app is taken out of the catalog like a deactivated one, so reactivating it
+	// restores its versions, roles and configs
+	var expiredApps []models.MicroApp
+	if err := s.db.Where("expire_at <= ? AND expire_handled_at IS NULL", now).Find(&expiredApps).Error; err != nil {
+		slog.Error("Failed to fetch expired micro apps", "error", err)
+	}
+	for _, app := range expiredApps {
+		s.claim(&models.MicroApp{}, app.ID, "expire_handled_at", now, app.MicroAppID, func(tx *gorm.DB) error {
+			return catalog.Deactivate(tx, app.MicroAppID)
+		})
+	}
+
+	var expiredVersions []models.MicroAppVersion
+	if err := s.db.Where("expire_at <= ? AND expire_handled_at IS NULL", now).Find(&expiredVersions).Error; err != nil {
+		slog.Error("Failed to fetch expired micro app versions", "error", err)
+	}
+	for _, v := range expiredVersions {
+		s.claim(&models.MicroAppVersion{}, v.ID, "expire_handled_at", now, v.MicroAppID, func(tx *gorm.DB) error {
+			return tx.Model(&models.MicroAppVersion{}).Where("id = ?", v.ID).Update("active", models.StatusInactive).Error
+		})
+	}
+}
+
+// claim marks a transition as handled, applies it and bumps the catalog revision for the app in
+// one transaction. Only one instance wins the conditional update, so running several replicas
+// does not duplicate notifications.
+func (s *CatalogScheduler) claim(model any, id int, column string, now time.Time, appID string, apply func(tx *gorm.DB) error) bool {
+	claimed := false
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(model).Where("id = ? AND "+column+" IS NULL", id).Update(column, now)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return nil
+		}
+		claimed = true
+
+		if err := apply(tx); err != nil {
+			return err
+		}
+		_, err := catalog.Touch(tx, appID)
+		return err
+	})
+	if err != nil {
+		slog.Error("Failed to apply catalog schedule", "error", err, "appID", appID, "column", column)
+		return false
+	}
+	if claimed {
+		slog.Info("Applied catalog schedule", "appID", appID, "column", column)
+	}
+	return claimed
+}
+
+// notify queues a notification to every user the micro app is offered to, i.e. the groups it
+// has active roles for. It goes through the notification worker like any other send, so users'
+// preferences apply and failed sends are retried. A send over the micro app's quota is dropped
+// without failing the schedule transition.
+func (s *CatalogScheduler) notify(tx *gorm.DB, appID, title, body string) error {
+	var groups []string
+	if err := tx.Model(&models.MicroAppRole{}).
+		Where("micro_app_id = ? AND active = ?", appID, models.StatusActive).
+		Pluck("role", &groups).Error; err != nil {
+		return err
+	}
+	if len(groups) == 0 {
+		return nil
+	}
+
+	payload := models.NotificationJobPayload{
+		Groups: groups,
+		Title:  title,
+		Body:   body,
+		Data:   map[string]interface{}{dataKeyType: dataTypeCatalog},
+	}
+	job, err := notification.Enqueue(tx, appID, payload, nil, "", s.quotas)
+	var exceeded *notification.QuotaExceededError
+	if errors.As(err, &exceeded) {
+		slog.Warn("Notification quota exceeded, skipping publish notification", "appID", appID, "limit", exceeded.Limit, "max", exceeded.Max)
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	slog.Info("Publish notification queued", "appID", appID, "jobID", job.ID)
+	return nil
+}
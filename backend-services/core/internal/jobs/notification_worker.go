@@ -0,0 +1,569 @@
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"maps"
+	"slices"
+	"sync"
+	"time"
+
+	"go-backend/internal/models"
+	"go-backend/internal/notification"
+	notificationservice "go-backend/plugins/notification-service"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	// How long a worker waits before looking for new jobs when the queue is empty
+	notificationPollInterval = 2 * time.Second
+	// How long a claimed job is reserved for its worker. A job whose worker died is picked up
+	// again once the lease runs out.
+	notificationJobLease = 5 * time.Minute
+	// Longest wait between two attempts of a job
+	notificationMaxBackoff = time.Hour
+	// Rows per insert when logging a notification sent to many users
+	notificationLogBatchSize = 500
+	// Users sent to between two saves of a job's progress
+	notificationProgressBatchSize = 500
+
+	notificationStatusPartialFailure = "partial_failure"
+	// Log status of a notification none of whose recipients has an active device
+	notificationStatusNoDevice = "no_device"
+	// Log statuses of notifications suppressed by the recipient's preferences
+	notificationStatusMuted        = "suppressed_muted"
+	notificationStatusCriticalOnly = "suppressed_critical_only"
+)
+
+// NotificationWorker sends the notifications queued in notification_jobs. A fixed pool of
+// workers claims due jobs, so slow or failing FCM calls never block the API. Failed sends are
+// retried with exponential backoff and dead-lettered once the attempts run out.
+type NotificationWorker struct {
+	db          *gorm.DB
+	notifier    notificationservice.NotificationService
+	workers     int
+	maxAttempts int
+	retryBase   time.Duration
+}
+
+// NewNotificationWorker creates a worker pool of the given size. A job is tried at most
+// maxAttempts times, waiting retryBase after the first failure and twice as long after each
+// further one.
+func NewNotificationWorker(db *gorm.DB, notifier notificationservice.NotificationService, workers, maxAttempts int, retryBase time.Duration) *NotificationWorker {
+	return &NotificationWorker{
+		db:          db,
+		notifier:    notifier,
+		workers:     workers,
+		maxAttempts: max(maxAttempts, 1),
+		retryBase:   retryBase,
+	}
+}
+
+// Start runs the workers in the background until ctx is cancelled. The pool is disabled when
+// its size is not positive or no notification service is available.
+func (n *NotificationWorker) Start(ctx context.Context) {
+	if n.workers <= 0 || n.notifier == nil {
+		slog.Warn("Notification worker disabled", "workers", n.workers, "notifierAvailable", n.notifier != nil)
+		return
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < n.workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				if !n.RunOnce(ctx, time.Now()) {
+					select {
+					case <-ctx.Done():
+						return
+					case <-time.After(notificationPollInterval):
+					}
+				}
+				if ctx.Err() != nil {
+					return
+				}
+			}
+		}()
+	}
+	slog.Info("Notification worker started", "workers", n.workers, "maxAttempts", n.maxAttempts)
+
+	go func() {
+		wg.Wait()
+		slog.Info("Notification worker stopped")
+	}()
+}
+
+// RunOnce claims one due job and processes it. It reports whether a job was found.
+func (n *NotificationWorker) RunOnce(ctx context.Context, now time.Time) bool {
+	job, err := n.claim(now)
+	if err != nil {
+		slog.Error("Failed to claim notification job", "error", err)
+		return false
+	}
+	if job == nil {
+		return false
+	}
+
+	// A job whose lease ran out on every attempt never reaches finish, so it is stopped here
+	if job.Attempts > n.maxAttempts {
+		n.finish(job, time.Now(), nil, errors.New("no attempt completed before the job lease expired"))
+		return true
+	}
+
+	sendCtx, cancel := context.WithTimeout(ctx, notificationJobLease)
+	defer cancel()
+	delivery, err := n.deliver(sendCtx, job)
+	n.finish(job, time.Now(), delivery, err)
+	return true
+}
+
+// claim reserves the oldest due job. SKIP LOCKED lets every worker, on every replica, claim a
+// different job without waiting on the others.
+func (n *NotificationWorker) claim(now time.Time) (*models.NotificationJob, error) {
+	var job models.NotificationJob
+	err := n.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("(status IN ? AND next_attempt_at <= ?) OR (status = ? AND locked_until < ?)",
+				[]string{models.NotificationJobQueued, models.NotificationJobRetrying}, now,
+				models.NotificationJobProcessing, now).
+			Order("next_attempt_at ASC").
+			First(&job).Error; err != nil {
+			return err
+		}
+
+		lockedUntil := now.Add(notificationJobLease)
+		if err := tx.Model(&job).Updates(map[string]any{
+			"status":       models.NotificationJobProcessing,
+			"attempts":     gorm.Expr("attempts + 1"),
+			"locked_until": lockedUntil,
+		}).Error; err != nil {
+			return err
+		}
+		job.Status = models.NotificationJobProcessing
+		job.Attempts++
+		job.LockedUntil = &lockedUntil
+		return nil
+	})
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &job, nil
+}
+
+// errJobReclaimed stops an attempt whose job was claimed by another worker after its lease ran out
+var errJobReclaimed = errors.New("notification job was reclaimed by another worker")
+
+// notificationDelivery is the outcome of a send
+type notificationDelivery struct {
+	recipients  int
+	success     int
+	failed      int
+	deactivated int
+}
+
+// finish records the outcome of an attempt. A failed attempt is rescheduled with backoff, or
+// dead-lettered when it was the last one. The update only applies while the worker still holds
+// the job, so a worker whose lease expired cannot overwrite the outcome of the next attempt.
+// Send counts are not written here, since saveProgress adds them as the batches go out.
+func (n *NotificationWorker) finish(job *models.NotificationJob, now time.Time, delivery *notificationDelivery, sendErr error) {
+	updates := map[string]any{"locked_until": nil}
+	switch {
+	case sendErr == nil:
+		updates["status"] = models.NotificationJobSucceeded
+		updates["completed_at"] = now
+		updates["last_error"] = nil
+		updates["recipients"] = delivery.recipients
+	case job.Attempts >= n.maxAttempts:
+		updates["status"] = models.NotificationJobFailed
+		updates["completed_at"] = now
+		updates["last_error"] = sendErr.Error()
+	default:
+		updates["status"] = models.NotificationJobRetrying
+		updates["next_attempt_at"] = now.Add(n.backoff(job.Attempts))
+		updates["last_error"] = sendErr.Error()
+	}
+
+	result := n.db.Model(&models.NotificationJob{}).
+		Where("id = ? AND status = ? AND attempts = ?", job.ID, models.NotificationJobProcessing, job.Attempts).
+		Updates(updates)
+	if result.Error != nil {
+		slog.Error("Failed to update notification job", "error", result.Error, "jobID", job.ID)
+		return
+	}
+	if result.RowsAffected == 0 {
+		slog.Warn("Notification job was reclaimed before it finished", "jobID", job.ID, "attempt", job.Attempts)
+		return
+	}
+
+	switch updates["status"] {
+	case models.NotificationJobSucceeded:
+		slog.Info("Notification job sent", "jobID", job.ID, "microapp_id", job.MicroappID,
+			"recipients", delivery.recipients, "success", delivery.success, "failed", delivery.failed, "deactivated", delivery.deactivated)
+	case models.NotificationJobFailed:
+		slog.Error("Notification job dead-lettered", "error", sendErr, "jobID", job.ID, "attempts", job.Attempts)
+	default:
+		slog.Warn("Notification job failed, retrying", "error", sendErr, "jobID", job.ID, "attempt", job.Attempts, "nextAttemptAt", updates["next_attempt_at"])
+	}
+}
+
+// backoff is the wait after the given failed attempt: retryBase, doubling per attempt, capped
+func (n *NotificationWorker) backoff(attempt int) time.Duration {
+	wait := n.retryBase
+	for i := 1; i < attempt && wait < notificationMaxBackoff; i++ {
+		wait *= 2
+	}
+	return min(wait, notificationMaxBackoff)
+}
+
+// deliver resolves the audience of a job to device tokens, sends the notification and logs it
+// for each user. Users are sent to in batches, and each batch is taken out of the job once it is
+// sent, so a retried job only reaches the users an earlier attempt did not get to.
+func (n *NotificationWorker) deliver(ctx context.Context, job *models.NotificationJob) (*notificationDelivery, error) {
+	var payload models.NotificationJobPayload
+	if err := json.Unmarshal(job.Payload, &payload); err != nil {
+		return nil, fmt.Errorf("invalid job payload: %w", err)
+	}
+
+	emails, err := notification.Recipients(n.db, payload)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve recipients: %w", err)
+	}
+	// A retried job only holds the users left after an earlier attempt applied preferences
+	delivery := &notificationDelivery{recipients: max(job.Recipients, len(emails))}
+	if len(emails) == 0 {
+		return delivery, nil
+	}
+
+	plan, err := n.planDelivery(job.MicroappID, payload.Critical, emails, time.Now())
+	if err != nil {
+		return nil, fmt.Errorf("failed to apply notification preferences: %w", err)
+	}
+	if len(plan.suppressed) > 0 || len(plan.deferred) > 0 || len(payload.Groups) > 0 {
+		if err := n.narrow(job, payload, plan, len(emails)); err != nil {
+			return nil, fmt.Errorf("failed to apply notification preferences: %w", err)
+		}
+	}
+
+	reached := make(map[string]bool, len(plan.now))
+	for _, message := range localize(payload, plan) {
+		for emails := range slices.Chunk(message.emails, notificationProgressBatchSize) {
+			batch := &notificationDelivery{}
+			err := n.send(ctx, job, localizedMessage{emails: emails, NotificationMessage: message.NotificationMessage}, payload.Data, batch)
+			delivery.deactivated += batch.deactivated
+			if err != nil {
+				return nil, err
+			}
+			delivery.success += batch.success
+			delivery.failed += batch.failed
+
+			for _, email := range emails {
+				reached[email] = true
+			}
+			remaining := slices.DeleteFunc(slices.Clone(plan.now), func(email string) bool { return reached[email] })
+			if err := n.saveProgress(job, payload, remaining, delivery.recipients, batch); err != nil {
+				return nil, fmt.Errorf("failed to save notification job progress: %w", err)
+			}
+		}
+	}
+	return delivery, nil
+}
+
+// saveProgress leaves only the users not reached yet in the job and adds the outcome of a sent
+// batch to its counts. Like finish, it only applies while the worker still holds the job.
+func (n *NotificationWorker) saveProgress(job *models.NotificationJob, payload models.NotificationJobPayload, remaining []string, recipients int, batch *notificationDelivery) error {
+	payload.UserEmails = remaining
+	payload.Groups = nil
+	payload.ExcludeGroups = nil
+	encoded, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	result := n.db.Model(&models.NotificationJob{}).
+		Where("id = ? AND status = ? AND attempts = ?", job.ID, models.NotificationJobProcessing, job.Attempts).
+		Updates(map[string]any{
+			"payload":           encoded,
+			"recipients":        recipients,
+			"success_count":     gorm.Expr("success_count + ?", batch.success),
+			"failure_count":     gorm.Expr("failure_count + ?", batch.failed),
+			"deactivated_count": gorm.Expr("deactivated_count + ?", batch.deactivated),
+		})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return errJobReclaimed
+	}
+	return nil
+}
+
+// localizedMessage is the copy of a job sent to the recipients of one locale
+type localizedMessage struct {
+	emails []string
+	models.NotificationMessage
+}
+
+// localize splits the users to send to by the copy they get: the one closest to their
+// language for a templated message, the job's title and body otherwise
+func localize(payload models.NotificationJobPayload, plan *deliveryPlan) []localizedMessage {
+	if len(plan.now) == 0 {
+		return nil
+	}
+	message := models.NotificationMessage{Title: payload.Title, Body: payload.Body}
+	if len(payload.Translations) == 0 {
+		return []localizedMessage{{emails: plan.now, NotificationMessage: message}}
+	}
+
+	matcher := notification.NewLocaleMatcher(payload.DefaultLocale, slices.Collect(maps.Keys(payload.Translations)))
+	byLocale := make(map[string][]string)
+	for _, email := range plan.now {
+		locale := matcher.Match(plan.locales[email])
+		byLocale[locale] = append(byLocale[locale], email)
+	}
+
+	messages := make([]localizedMessage, 0, len(byLocale))
+	for _, locale := range slices.Sorted(maps.Keys(byLocale)) {
+		messages = append(messages, localizedMessage{emails: byLocale[locale], NotificationMessage: payload.Translations[locale]})
+	}
+	return messages
+}
+
+// send pushes one copy of a job to the devices of its users, logs it for each of them, and
+// adds the outcome to the delivery
+func (n *NotificationWorker) send(ctx context.Context, job *models.NotificationJob, message localizedMessage, data map[string]interface{}, delivery *notificationDelivery) error {
+	query := n.db.Model(&models.DeviceToken{}).
+		Distinct("device_token").
+		Where("user_email IN ? AND is_active = ?", message.emails, true)
+	// A provider such as APNs would reject the tokens of other platforms as invalid
+	if platforms, ok := n.notifier.(notificationservice.PlatformService); ok {
+		query = query.Where("platform IN ?", platforms.Platforms())
+	}
+	var tokens []string
+	if err := query.Pluck("device_token", &tokens).Error; err != nil {
+		return fmt.Errorf("failed to fetch device tokens: %w", err)
+	}
+	if len(tokens) == 0 {
+		// Still logged, so the notification reaches the users' inboxes
+		if err := logNotifications(n.db, message.emails, message.Title, message.Body, job.MicroappID, notificationStatusNoDevice, data); err != nil {
+			slog.Error("Failed to log notifications", "error", err, "jobID", job.ID, "users", len(message.emails))
+		}
+		return nil
+	}
+
+	result, err := n.notifier.SendNotificationToMultiple(ctx, tokens, message.Title, message.Body, fcmData(data, job.MicroappID))
+	// Batches sent before an error still report dead tokens
+	delivery.deactivated += deactivateInvalidTokens(n.db, result)
+	if err != nil {
+		return err
+	}
+	delivery.success += result.SuccessCount
+	delivery.failed += result.FailureCount
+
+	status := notificationStatusSent
+	if result.FailureCount > 0 {
+		status = notificationStatusPartialFailure
+	}
+	if err := logNotifications(n.db, message.emails, message.Title, message.Body, job.MicroappID, status, data); err != nil {
+		slog.Error("Failed to log notifications", "error", err, "jobID", job.ID, "users", len(message.emails))
+	}
+	return nil
+}
+
+// deliveryPlan splits the recipients of a job by their notification preferences
+type deliveryPlan struct {
+	// Users to send to right away
+	now []string
+	// Users who muted the micro app or only accept critical messages, with the log status
+	suppressed map[string]string
+	// Users in quiet hours, by the time their quiet hours end
+	deferred map[time.Time][]string
+	// Preferred language of the users who set one
+	locales map[string]string
+}
+
+// planDelivery applies the recipients' preferences to a message. Mutes and critical-only mode
+// suppress it; quiet hours defer it unless it is critical.
+func (n *NotificationWorker) planDelivery(microappID string, critical bool, emails []string, now time.Time) (*deliveryPlan, error) {
+	var muted []string
+	if err := n.db.Model(&models.NotificationMute{}).
+		Where("user_email IN ? AND micro_app_id = ?", emails, microappID).
+		Where(models.ActiveMuteCondition, now).
+		Pluck("user_email", &muted).Error; err != nil {
+		return nil, err
+	}
+
+	isMuted := make(map[string]bool, len(muted))
+	for _, email := range muted {
+		isMuted[email] = true
+	}
+
+	var prefs []models.NotificationPreference
+	if err := n.db.Where("user_email IN ?", emails).Find(&prefs).Error; err != nil {
+		return nil, err
+	}
+	prefByEmail := make(map[string]models.NotificationPreference, len(prefs))
+	for _, p := range prefs {
+		prefByEmail[p.UserEmail] = p
+	}
+
+	plan := &deliveryPlan{
+		suppressed: make(map[string]string),
+		deferred:   make(map[time.Time][]string),
+		locales:    make(map[string]string),
+	}
+	for _, email := range emails {
+		pref, hasPref := prefByEmail[email]
+		if hasPref && pref.Locale != nil {
+			plan.locales[email] = *pref.Locale
+		}
+		switch {
+		case isMuted[email]:
+			plan.suppressed[email] = notificationStatusMuted
+		case critical || !hasPref:
+			plan.now = append(plan.now, email)
+		case pref.CriticalOnly:
+			plan.suppressed[email] = notificationStatusCriticalOnly
+		default:
+			if until, quiet := pref.QuietUntil(now); quiet {
+				until = until.UTC()
+				plan.deferred[until] = append(plan.deferred[until], email)
+			} else {
+				plan.now = append(plan.now, email)
+			}
+		}
+	}
+	return plan, nil
+}
+
+// narrow records the suppressed recipients, queues the deferred ones as jobs due when their
+// quiet hours end, and leaves only the remaining users in the job. It runs before sending, so
+// a retried attempt neither repeats this nor sends to users taken out of the job. Like
+// saveProgress, it only applies while the worker still holds the job.
+func (n *NotificationWorker) narrow(job *models.NotificationJob, payload models.NotificationJobPayload, plan *deliveryPlan, recipients int) error {
+	deferredCount := 0
+	return n.db.Transaction(func(tx *gorm.DB) error {
+		for until, emails := range plan.deferred {
+			deferred := payload
+			deferred.UserEmails = emails
+			deferred.Groups = nil
+			deferred.ExcludeGroups = nil
+			encoded, err := json.Marshal(deferred)
+			if err != nil {
+				return err
+			}
+			if err := tx.Create(&models.NotificationJob{
+				MicroappID:    job.MicroappID,
+				Status:        models.NotificationJobQueued,
+				Payload:       encoded,
+				NextAttemptAt: until,
+			}).Error; err != nil {
+				return err
+			}
+			deferredCount += len(emails)
+		}
+
+		for status, emails := range groupByStatus(plan.suppressed) {
+			if err := logNotifications(tx, emails, payload.Title, payload.Body, job.MicroappID, status, payload.Data); err != nil {
+				return err
+			}
+		}
+
+		remaining := payload
+		remaining.UserEmails = plan.now
+		remaining.Groups = nil
+		remaining.ExcludeGroups = nil
+		encoded, err := json.Marshal(remaining)
+		if err != nil {
+			return err
+		}
+		updates := map[string]any{
+			"payload":          encoded,
+			"suppressed_count": gorm.Expr("suppressed_count + ?", len(plan.suppressed)),
+			"deferred_count":   gorm.Expr("deferred_count + ?", deferredCount),
+		}
+		if job.Recipients == 0 {
+			updates["recipients"] = recipients
+		}
+		result := tx.Model(&models.NotificationJob{}).
+			Where("id = ? AND status = ? AND attempts = ?", job.ID, models.NotificationJobProcessing, job.Attempts).
+			Updates(updates)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return errJobReclaimed
+		}
+		return nil
+	})
+}
+
+func groupByStatus(statuses map[string]string) map[string][]string {
+	grouped := make(map[string][]string)
+	for email, status := range statuses {
+		grouped[status] = append(grouped[status], email)
+	}
+	return grouped
+}
+
+// fcmData converts notification data to the string map FCM expects, marshalling non-string
+// values to JSON, and adds the sending micro app
+func fcmData(data map[string]interface{}, microappID string) map[string]string {
+	dataStr := make(map[string]string)
+	for k, v := range data {
+		if str, ok := v.(string); ok {
+			dataStr[k] = str
+		} else if bytes, err := json.Marshal(v); err == nil {
+			dataStr[k] = string(bytes)
+		}
+	}
+	if microappID != "" {
+		dataStr[dataKeyMicroappID] = microappID
+	}
+	return dataStr
+}
+
+// deactivateInvalidTokens stops sending to tokens FCM reported as unregistered or malformed,
+// and returns how many were deactivated
+func deactivateInvalidTokens(db *gorm.DB, result *notificationservice.SendResult) int {
+	if result == nil {
+		return 0
+	}
+	invalid := result.InvalidTokens()
+	if len(invalid) == 0 {
+		return 0
+	}
+	update := db.Model(&models.DeviceToken{}).
+		Where("device_token IN ? AND is_active = ?", invalid, true).
+		Update("is_active", false)
+	if update.Error != nil {
+		slog.Error("Failed to deactivate invalid device tokens", "error", update.Error, "tokens", len(invalid))
+		return 0
+	}
+	return int(update.RowsAffected)
+}
+
+// logNotifications records a notification for each user. Group sends can address thousands
+// of users, so the rows are written in batches.
+func logNotifications(db *gorm.DB, userEmails []string, title, body, microappID, status string, data map[string]interface{}) error {
+	logs := make([]models.NotificationLog, 0, len(userEmails))
+	for _, email := range userEmails {
+		logs = append(logs, models.NotificationLog{
+			UserEmail:  email,
+			Title:      &title,
+			Body:       &body,
+			Data:       data,
+			Status:     &status,
+			MicroappID: &microappID,
+		})
+	}
+	return db.CreateInBatches(&logs, notificationLogBatchSize).Error
+}
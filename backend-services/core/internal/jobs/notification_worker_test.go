@@ -0,0 +1,349 @@
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"go-backend/internal/models"
+	"go-backend/internal/testdb"
+	notificationservice "go-backend/plugins/notification-service"
+	logservice "go-backend/plugins/notification-service/log"
+
+	"gorm.io/gorm"
+)
+
+func TestLocalize(t *testing.T) {
+	plan := &deliveryPlan{
+		now:     []string{"a@example.com", "b@example.com", "c@example.com", "d@example.com"},
+		locales: map[string]string{"a@example.com": "fr-CA", "b@example.com": "en-GB", "c@example.com": "ja"},
+	}
+	payload := models.NotificationJobPayload{
+		Title:         "Approved",
+		Body:          "Your request was approved",
+		DefaultLocale: "en",
+		Translations: map[string]models.NotificationMessage{
+			"en": {Title: "Approved", Body: "Your request was approved"},
+			"fr": {Title: "Approuvée", Body: "Votre demande est approuvée"},
+		},
+	}
+
+	messages := localize(payload, plan)
+	if len(messages) != 2 {
+		t.Fatalf("got %d messages, want one per locale", len(messages))
+	}
+	if messages[0].Title != "Approved" || !slices.Equal(messages[0].emails, []string{"b@example.com", "c@example.com", "d@example.com"}) {
+		t.Errorf("en message = %+v", messages[0])
+	}
+	if messages[1].Title != "Approuvée" || !slices.Equal(messages[1].emails, []string{"a@example.com"}) {
+		t.Errorf("fr message = %+v", messages[1])
+	}
+}
+
+func TestLocalizeUntranslated(t *testing.T) {
+	plan := &deliveryPlan{now: []string{"a@example.com"}, locales: map[string]string{"a@example.com": "fr"}}
+	messages := localize(models.NotificationJobPayload{Title: "Hi", Body: "There"}, plan)
+	if len(messages) != 1 || messages[0].Title != "Hi" || messages[0].Body != "There" {
+		t.Errorf("messages = %+v, want the job's own copy", messages)
+	}
+	if messages := localize(models.NotificationJobPayload{Title: "Hi"}, &deliveryPlan{}); len(messages) != 0 {
+		t.Errorf("messages = %+v, want none without recipients", messages)
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	n := NewNotificationWorker(nil, nil, 1, 10, 30*time.Second)
+	for attempt, want := range map[int]time.Duration{
+		1:  30 * time.Second,
+		2:  time.Minute,
+		3:  2 * time.Minute,
+		10: notificationMaxBackoff,
+	} {
+		if got := n.backoff(attempt); got != want {
+			t.Errorf("backoff(%d) = %v, want %v", attempt, got, want)
+		}
+	}
+}
+
+func TestFCMData(t *testing.T) {
+	data := fcmData(map[string]interface{}{"id": "42", "count": 3, "tags": []string{"a"}}, "leave")
+	want := map[string]string{"id": "42", "count": "3", "tags": `["a"]`, dataKeyMicroappID: "leave"}
+	if len(data) != len(want) {
+		t.Fatalf("data = %v, want %v", data, want)
+	}
+	for k, v := range want {
+		if data[k] != v {
+			t.Errorf("data[%s] = %q, want %q", k, data[k], v)
+		}
+	}
+}
+
+// recordingNotifier records the tokens of each send and fails the sends listed in failOn
+type recordingNotifier struct {
+	mu        sync.Mutex
+	sends     [][]string
+	failOn    map[int]bool
+	platforms []string
+}
+
+func (r *recordingNotifier) SendNotificationToMultiple(_ context.Context, tokens []string, _, _ string, _ map[string]string) (*notificationservice.SendResult, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.sends = append(r.sends, slices.Clone(tokens))
+	if r.failOn[len(r.sends)] {
+		return &notificationservice.SendResult{}, errors.New("provider unavailable")
+	}
+	return &notificationservice.SendResult{SuccessCount: len(tokens)}, nil
+}
+
+// iosNotifier is a recordingNotifier that only reaches iOS devices
+type iosNotifier struct {
+	recordingNotifier
+}
+
+func (*iosNotifier) Platforms() []string {
+	return []string{"ios"}
+}
+
+// seedDevices registers one active device per user, with the given token and platform
+func seedDevices(t *testing.T, db *gorm.DB, devices map[string][2]string) {
+	t.Helper()
+	for email, device := range devices {
+		if err := db.Create(&models.DeviceToken{
+			UserEmail:   email,
+			DeviceID:    "device-" + email,
+			DeviceToken: device[0],
+			Platform:    device[1],
+			LastSeenAt:  time.Now(),
+			IsActive:    true,
+		}).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// queueJob queues a job that is already due
+func queueJob(t *testing.T, db *gorm.DB, payload models.NotificationJobPayload) *models.NotificationJob {
+	t.Helper()
+	encoded, err := json.Marshal(payload)
+	if err != nil {
+		t.Fatal(err)
+	}
+	job := &models.NotificationJob{
+		MicroappID:    "worker-test",
+		Status:        models.NotificationJobQueued,
+		Payload:       encoded,
+		NextAttemptAt: time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC),
+	}
+	if err := db.Create(job).Error; err != nil {
+		t.Fatal(err)
+	}
+	return job
+}
+
+func reloadJob(t *testing.T, db *gorm.DB, id int64) (models.NotificationJob, models.NotificationJobPayload) {
+	t.Helper()
+	var job models.NotificationJob
+	if err := db.First(&job, id).Error; err != nil {
+		t.Fatal(err)
+	}
+	var payload models.NotificationJobPayload
+	if err := json.Unmarshal(job.Payload, &payload); err != nil {
+		t.Fatal(err)
+	}
+	return job, payload
+}
+
+func TestWorkerRetrySkipsReachedUsers(t *testing.T) {
+	db := testdb.Open(t)
+	seedDevices(t, db, map[string][2]string{
+		"a@example.com": {"token-a", "android"},
+		"b@example.com": {"token-b", "android"},
+	})
+	if err := db.Create(&models.NotificationPreference{UserEmail: "b@example.com", Locale: ptr("fr")}).Error; err != nil {
+		t.Fatal(err)
+	}
+	// Two locales make two sends; the second fails on the first attempt
+	job := queueJob(t, db, models.NotificationJobPayload{
+		UserEmails:    []string{"a@example.com", "b@example.com"},
+		Title:         "Hello",
+		Body:          "World",
+		DefaultLocale: "en",
+		Translations: map[string]models.NotificationMessage{
+			"en": {Title: "Hello", Body: "World"},
+			"fr": {Title: "Bonjour", Body: "Monde"},
+		},
+	})
+	notifier := &recordingNotifier{failOn: map[int]bool{2: true}}
+	worker := NewNotificationWorker(db, notifier, 1, 3, time.Second)
+
+	worker.RunOnce(context.Background(), time.Now())
+	got, payload := reloadJob(t, db, job.ID)
+	if got.Status != models.NotificationJobRetrying {
+		t.Fatalf("status after the failed attempt = %s, want retrying", got.Status)
+	}
+	if !slices.Equal(payload.UserEmails, []string{"b@example.com"}) || got.SuccessCount != 1 {
+		t.Fatalf("after the failed attempt users = %v success = %d, want only b@example.com left and 1 sent",
+			payload.UserEmails, got.SuccessCount)
+	}
+
+	worker.RunOnce(context.Background(), time.Now().Add(time.Hour))
+	got, _ = reloadJob(t, db, job.ID)
+	if got.Status != models.NotificationJobSucceeded || got.SuccessCount != 2 {
+		t.Fatalf("after the retry status = %s success = %d, want succeeded with 2 sent", got.Status, got.SuccessCount)
+	}
+	if len(notifier.sends) != 3 || !slices.Equal(notifier.sends[2], []string{"token-b"}) {
+		t.Errorf("sends = %v, want the retry to reach only token-b", notifier.sends)
+	}
+}
+
+func TestWorkerSendsWithLogProvider(t *testing.T) {
+	db := testdb.Open(t)
+	notifier, err := logservice.New(map[string]any{"NOTIFICATION_SERVICE_LOG_INVALID_PREFIX": "bad-"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	seedDevices(t, db, map[string][2]string{
+		"a@example.com": {"token-a", "android"},
+		"b@example.com": {"bad-b", "ios"},
+		"c@example.com": {"token-c", "ios"},
+	})
+	if err := db.Create(&models.NotificationMute{UserEmail: "c@example.com", MicroAppID: "worker-test"}).Error; err != nil {
+		t.Fatal(err)
+	}
+	job := queueJob(t, db, models.NotificationJobPayload{
+		UserEmails: []string{"a@example.com", "b@example.com", "c@example.com"},
+		Title:      "Hello",
+		Body:       "World",
+	})
+
+	worker := NewNotificationWorker(db, notifier, 1, 3, time.Second)
+	if !worker.RunOnce(context.Background(), time.Now()) {
+		t.Fatal("RunOnce found no job")
+	}
+
+	got, payload := reloadJob(t, db, job.ID)
+	if got.Status != models.NotificationJobSucceeded {
+		t.Fatalf("status = %s (%v), want succeeded", got.Status, got.LastError)
+	}
+	if got.Recipients != 3 || got.SuccessCount != 1 || got.FailureCount != 1 || got.DeactivatedCount != 1 || got.SuppressedCount != 1 {
+		t.Errorf("counts = recipients %d success %d failed %d deactivated %d suppressed %d, want 3 1 1 1 1",
+			got.Recipients, got.SuccessCount, got.FailureCount, got.DeactivatedCount, got.SuppressedCount)
+	}
+	if len(payload.UserEmails) != 0 {
+		t.Errorf("users left in the job = %v, want none", payload.UserEmails)
+	}
+
+	sent := notifier.(*logservice.LogService).Sent()
+	if len(sent) != 1 {
+		t.Fatalf("got %d sends, want 1", len(sent))
+	}
+	tokens := slices.Sorted(slices.Values(sent[0].Tokens))
+	if want := []string{"bad-b", "token-a"}; !slices.Equal(tokens, want) {
+		t.Errorf("tokens = %v, want %v", tokens, want)
+	}
+
+	var bad models.DeviceToken
+	if err := db.Where("device_token = ?", "bad-b").First(&bad).Error; err != nil {
+		t.Fatal(err)
+	}
+	if bad.IsActive {
+		t.Error("invalid token is still active")
+	}
+}
+
+func TestWorkerSkipsOtherPlatforms(t *testing.T) {
+	db := testdb.Open(t)
+	seedDevices(t, db, map[string][2]string{
+		"a@example.com": {"token-a", "android"},
+		"b@example.com": {"token-b", "ios"},
+	})
+	job := queueJob(t, db, models.NotificationJobPayload{
+		UserEmails: []string{"a@example.com", "b@example.com"},
+		Title:      "Hello",
+		Body:       "World",
+	})
+	notifier := &iosNotifier{}
+	worker := NewNotificationWorker(db, notifier, 1, 3, time.Second)
+
+	worker.RunOnce(context.Background(), time.Now())
+	if got, _ := reloadJob(t, db, job.ID); got.Status != models.NotificationJobSucceeded {
+		t.Fatalf("status = %s, want succeeded", got.Status)
+	}
+	if len(notifier.sends) != 1 || !slices.Equal(notifier.sends[0], []string{"token-b"}) {
+		t.Errorf("sends = %v, want only the iOS token", notifier.sends)
+	}
+}
+
+func TestWorkerLogsUsersWithoutDevices(t *testing.T) {
+	db := testdb.Open(t)
+	job := queueJob(t, db, models.NotificationJobPayload{
+		UserEmails: []string{"a@example.com", "b@example.com"},
+		Title:      "Hello",
+		Body:       "World",
+	})
+	notifier := &recordingNotifier{}
+	worker := NewNotificationWorker(db, notifier, 1, 3, time.Second)
+
+	worker.RunOnce(context.Background(), time.Now())
+	if got, _ := reloadJob(t, db, job.ID); got.Status != models.NotificationJobSucceeded {
+		t.Fatalf("status = %s, want succeeded", got.Status)
+	}
+	if len(notifier.sends) != 0 {
+		t.Errorf("sends = %v, want none without devices", notifier.sends)
+	}
+
+	var logs []models.NotificationLog
+	if err := db.Where("microapp_id = ?", job.MicroappID).Order("user_email").Find(&logs).Error; err != nil {
+		t.Fatal(err)
+	}
+	if len(logs) != 2 {
+		t.Fatalf("got %d log rows, want one per user", len(logs))
+	}
+	for _, l := range logs {
+		if l.Status == nil || *l.Status != notificationStatusNoDevice {
+			t.Errorf("%s logged as %v, want %s", l.UserEmail, l.Status, notificationStatusNoDevice)
+		}
+	}
+}
+
+func TestNarrowReclaimedJob(t *testing.T) {
+	db := testdb.Open(t)
+	payload := models.NotificationJobPayload{UserEmails: []string{"a@example.com", "b@example.com"}, Title: "Hello", Body: "World"}
+	job := queueJob(t, db, payload)
+	// Another worker claimed the job after this attempt's lease ran out
+	if err := db.Model(job).Updates(map[string]any{"status": models.NotificationJobProcessing, "attempts": 2}).Error; err != nil {
+		t.Fatal(err)
+	}
+	stale := *job
+	stale.Status = models.NotificationJobProcessing
+	stale.Attempts = 1
+
+	plan := &deliveryPlan{
+		now:      []string{"a@example.com"},
+		deferred: map[time.Time][]string{time.Now().Add(time.Hour): {"b@example.com"}},
+	}
+	worker := NewNotificationWorker(db, &recordingNotifier{}, 1, 3, time.Second)
+	if err := worker.narrow(&stale, payload, plan, 2); !errors.Is(err, errJobReclaimed) {
+		t.Fatalf("narrow = %v, want errJobReclaimed", err)
+	}
+
+	if _, got := reloadJob(t, db, job.ID); len(got.UserEmails) != 2 {
+		t.Errorf("users in the job = %v, want both left for the worker holding it", got.UserEmails)
+	}
+	var jobs int64
+	if err := db.Model(&models.NotificationJob{}).Where("microapp_id = ?", job.MicroappID).Count(&jobs).Error; err != nil {
+		t.Fatal(err)
+	}
+	if jobs != 1 {
+		t.Errorf("got %d jobs, want no deferred job queued", jobs)
+	}
+}
+
+func ptr[T any](v T) *T {
+	return &v
+}
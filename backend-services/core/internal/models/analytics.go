@@ -0,0 +1,66 @@
+package models
+
+import "time"
+
+// Micro app usage event types reported by the host app
+const (
+	AnalyticsEventLaunch  = "launch"
+	AnalyticsEventSession = "session"
+	AnalyticsEventError   = "error"
+)
+
+// AnalyticsLateDays is how many days before today the rollup recomputes, so events that
+// arrive late (devices that were offline) are still counted. Older events are rejected.
+const AnalyticsLateDays = 2
+
+// AnalyticsRollupStart returns the start of the oldest UTC day still being rolled up at now
+func AnalyticsRollupStart(now time.Time) time.Time {
+	return now.UTC().Truncate(24*time.Hour).AddDate(0, 0, -AnalyticsLateDays)
+}
+
+// AnalyticsEvent is a raw usage event. Raw events are kept for a retention period only;
+// reports read the daily rollups.
+type AnalyticsEvent struct {
+	ID           int64     `gorm:"column:id;primaryKey;autoIncrement"`
+	MicroAppID   string    `gorm:"column:micro_app_id;type:varchar(255);not null"`
+	UserEmail    string    `gorm:"column:user_email;type:varchar(319);not null"`
+	EventType    string    `gorm:"column:event_type;type:enum('launch','session','error');not null"`
+	Version      string    `gorm:"column:version;type:varchar(32);not null;default:''"`
+	DurationMs   *int64    `gorm:"column:duration_ms"`
+	ErrorMessage *string   `gorm:"column:error_message;type:varchar(1024)"`
+	OccurredAt   time.Time `gorm:"column:occurred_at;not null;index"`
+	ReceivedAt   time.Time `gorm:"column:received_at;not null;autoCreateTime"`
+}
+
+func (AnalyticsEvent) TableName() string {
+	return "analytics_event"
+}
+
+// AnalyticsDailyApp holds the event counts of one micro app version on one (UTC) day.
+type AnalyticsDailyApp struct {
+	Day         time.Time `gorm:"column:day;type:date;primaryKey"`
+	MicroAppID  string    `gorm:"column:micro_app_id;type:varchar(255);primaryKey"`
+	Version     string    `gorm:"column:version;type:varchar(32);primaryKey"`
+	Launches    int64     `gorm:"column:launches;not null;default:0"`
+	Sessions    int64     `gorm:"column:sessions;not null;default:0"`
+	SessionMs   int64     `gorm:"column:session_ms;not null;default:0"`
+	Errors      int64     `gorm:"column:errors;not null;default:0"`
+	ActiveUsers int64     `gorm:"column:active_users;not null;default:0"`
+}
+
+func (AnalyticsDailyApp) TableName() string {
+	return "analytics_daily_app"
+}
+
+// AnalyticsDailyUser records that a user used a micro app version on a day. One row per
+// user/app/version/day, which is what distinct-user metrics (DAU, MAU) are computed from.
+type AnalyticsDailyUser struct {
+	Day        time.Time `gorm:"column:day;type:date;primaryKey"`
+	MicroAppID string    `gorm:"column:micro_app_id;type:varchar(255);primaryKey"`
+	Version    string    `gorm:"column:version;type:varchar(32);primaryKey"`
+	UserEmail  string    `gorm:"column:user_email;type:varchar(319);primaryKey"`
+}
+
+func (AnalyticsDailyUser) TableName() string {
+	return "analytics_daily_user"
+}
@@ -0,0 +1,87 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Catalog change lifecycle: draft -> submitted -> approved -> published.
+// A rejected change can be edited, which puts it back into draft.
+const (
+	CatalogChangeDraft     = "draft"
+	CatalogChangeSubmitted = "submitted"
+	CatalogChangeApproved  = "approved"
+	CatalogChangeRejected  = "rejected"
+	CatalogChangePublished = "published"
+)
+
+// Actions recorded in the catalog change audit trail
+const (
+	CatalogChangeActionCreated   = "created"
+	CatalogChangeActionUpdated   = "updated"
+	CatalogChangeActionSubmitted = "submitted"
+	CatalogChangeActionApproved  = "approved"
+	CatalogChangeActionRejected  = "rejected"
+	CatalogChangeActionPublished = "published"
+)
+
+// CatalogChange is a pending micro app change. Payload holds the upsert request that
+// is applied to the live catalog when the change is published.
+type CatalogChange struct {
+	ID         int64           `gorm:"column:id;primaryKey;autoIncrement"`
+	MicroAppID string          `gorm:"column:micro_app_id;type:varchar(255);not null;index"`
+	Status     string          `gorm:"column:status;type:enum('draft','submitted','approved','rejected','published');not null;default:draft"`
+	Payload    json.RawMessage `gorm:"column:payload;type:json;not null"`
+	// Replace publishes the payload as the complete app state (used for snapshot restores)
+	Replace          bool   `gorm:"column:replace_existing;not null;default:false"`
+	SourceSnapshotID *int64 `gorm:"column:source_snapshot_id"`
+	// BaseRowVersion is the row version of the live app the change was drafted against, 0 if the
+	// app did not exist yet. Publishing fails once the live app has moved on.
+	BaseRowVersion *int64 `gorm:"column:base_version"`
+	// StagedFiles names the files uploaded for the change (a version bundle and icon), which
+	// are deleted if the change is rejected
+	StagedFiles StringList `gorm:"column:staged_files;type:json"`
+	CreatedBy   string     `gorm:"column:created_by;type:varchar(319);not null"`
+	UpdatedBy   *string    `gorm:"column:updated_by;type:varchar(319)"`
+	ReviewedBy  *string    `gorm:"column:reviewed_by;type:varchar(319)"`
+	ReviewedAt  *time.Time `gorm:"column:reviewed_at"`
+	PublishedBy *string    `gorm:"column:published_by;type:varchar(319)"`
+	PublishedAt *time.Time `gorm:"column:published_at"`
+	CreatedAt   time.Time  `gorm:"column:created_at;not null;autoCreateTime"`
+	UpdatedAt   *time.Time `gorm:"column:updated_at;autoUpdateTime"`
+
+	Events []CatalogChangeEvent `gorm:"foreignKey:ChangeID;references:ID"`
+}
+
+func (CatalogChange) TableName() string {
+	return "catalog_change"
+}
+
+// CatalogChangeEvent is one step in the audit trail of a catalog change.
+type CatalogChangeEvent struct {
+	ID        int64     `gorm:"column:id;primaryKey;autoIncrement"`
+	ChangeID  int64     `gorm:"column:change_id;not null;index"`
+	Action    string    `gorm:"column:action;type:varchar(32);not null"`
+	Actor     string    `gorm:"column:actor;type:varchar(319);not null"`
+	Comment   *string   `gorm:"column:comment;type:text"`
+	CreatedAt time.Time `gorm:"column:created_at;not null;autoCreateTime"`
+}
+
+func (CatalogChangeEvent) TableName() string {
+	return "catalog_change_event"
+}
+
+// CatalogSnapshot is the complete state of a micro app right after a change was published.
+// Restoring a snapshot opens a new change with it as the payload.
+type CatalogSnapshot struct {
+	ID         int64           `gorm:"column:id;primaryKey;autoIncrement"`
+	MicroAppID string          `gorm:"column:micro_app_id;type:varchar(255);not null;index"`
+	ChangeID   int64           `gorm:"column:change_id;not null"`
+	Snapshot   json.RawMessage `gorm:"column:snapshot;type:json;not null"`
+	CreatedBy  string          `gorm:"column:created_by;type:varchar(319);not null"`
+	CreatedAt  time.Time       `gorm:"column:created_at;not null;autoCreateTime"`
+}
+
+func (CatalogSnapshot) TableName() string {
+	return "catalog_snapshot"
+}
@@ -0,0 +1,30 @@
+package models
+
+import "time"
+
+// CatalogRevisionID is the primary key of the single catalog_revision row
+const CatalogRevisionID = 1
+
+// CatalogRevision holds the catalog-wide revision counter. Every change to a micro app
+// or its children bumps the counter and stamps the new value on the micro app row.
+type CatalogRevision struct {
+	ID       int   `gorm:"column:id;primaryKey"`
+	Revision int64 `gorm:"column:revision;not null;default:0"`
+}
+
+func (CatalogRevision) TableName() string {
+	return "catalog_revision"
+}
+
+// CatalogTombstone records a permanently deleted micro app so delta sync clients can drop it.
+// Roles keeps the groups the app had roles for, so only users who could have seen it are told.
+type CatalogTombstone struct {
+	MicroAppID string     `gorm:"column:micro_app_id;type:varchar(255);primaryKey"`
+	Revision   int64      `gorm:"column:revision;not null;index:idx_catalog_tombstone_revision"`
+	Roles      StringList `gorm:"column:roles;type:json"`
+	DeletedAt  time.Time  `gorm:"column:deleted_at;not null;autoCreateTime"`
+}
+
+func (CatalogTombstone) TableName() string {
+	return "catalog_tombstone"
+}
@@ -3,4 +3,8 @@ package models
 const (
 	StatusActive   = 1
 	StatusInactive = 0
+
+	// StatusSuspended marks a version, role or config that was active when its
+	// micro app was deactivated, so that reactivating the app can restore it.
+	StatusSuspended = 2
 )
@@ -4,9 +4,13 @@ import "time"
 
 type DeviceToken struct {
 	ID          int64     `gorm:"column:id;primaryKey;autoIncrement"`
-	UserEmail   string    `gorm:"column:user_email;type:varchar(255);not null;index:idx_user_email"`
+	UserEmail   string    `gorm:"column:user_email;type:varchar(255);not null;index:idx_user_email;uniqueIndex:uq_device_tokens_user_device"`
+	DeviceID    string    `gorm:"column:device_id;type:varchar(255);not null;uniqueIndex:uq_device_tokens_user_device"`
 	DeviceToken string    `gorm:"column:device_token;type:text;not null"`
 	Platform    string    `gorm:"column:platform;type:enum('ios','android');not null"`
+	DeviceName  *string   `gorm:"column:device_name;type:varchar(255)"`
+	AppVersion  *string   `gorm:"column:app_version;type:varchar(50)"`
+	LastSeenAt  time.Time `gorm:"column:last_seen_at;not null"`
 	CreatedAt   time.Time `gorm:"column:created_at;not null;autoCreateTime"`
 	UpdatedAt   time.Time `gorm:"column:updated_at;not null;autoUpdateTime"`
 	IsActive    bool      `gorm:"column:is_active;type:tinyint(1);not null;default:1;index:idx_is_active"`
@@ -15,3 +19,9 @@ type DeviceToken struct {
 func (DeviceToken) TableName() string {
 	return "device_tokens"
 }
+
+// LegacyDeviceID is the device ID of tokens registered by clients that do not send one,
+// which keeps them to one token per user and platform
+func LegacyDeviceID(platform string) string {
+	return "legacy-" + platform
+}
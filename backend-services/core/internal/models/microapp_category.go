@@ -0,0 +1,21 @@
+package models
+
+import "time"
+
+// MicroAppCategory groups micro apps on the home screen. Categories are shown in ascending DisplayOrder.
+type MicroAppCategory struct {
+	ID           int        `gorm:"column:id;primaryKey;autoIncrement"`
+	CategoryID   string     `gorm:"column:category_id;type:varchar(255);not null;uniqueIndex"`
+	Name         string     `gorm:"column:name;type:varchar(255);not null"`
+	Description  *string    `gorm:"column:description;type:text"`
+	DisplayOrder int        `gorm:"column:display_order;not null;default:0"`
+	CreatedBy    string     `gorm:"column:created_by;type:varchar(319);not null"`
+	UpdatedBy    *string    `gorm:"column:updated_by;type:varchar(319)"`
+	CreatedAt    time.Time  `gorm:"column:created_at;not null;autoCreateTime"`
+	UpdatedAt    *time.Time `gorm:"column:updated_at;autoUpdateTime"`
+	Active       int        `gorm:"column:active;type:tinyint(1);not null;default:1"`
+}
+
+func (MicroAppCategory) TableName() string {
+	return "micro_app_category"
+}
@@ -0,0 +1,34 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Scopes a config value can be overridden for, from least to most specific
+const (
+	ConfigScopeEnvironment = "environment"
+	ConfigScopeChannel     = "channel"
+	ConfigScopeGroup       = "group"
+)
+
+// MicroAppConfigOverride replaces the value of a MicroAppConfig key for users matching the scope.
+// When several overrides match, the most specific scope wins, then the highest Priority.
+type MicroAppConfigOverride struct {
+	ID          int64           `gorm:"column:id;primaryKey;autoIncrement"`
+	MicroAppID  string          `gorm:"column:micro_app_id;type:varchar(255);not null;uniqueIndex:uq_maco_scope"`
+	ConfigKey   string          `gorm:"column:config_key;type:varchar(191);not null;uniqueIndex:uq_maco_scope"`
+	ScopeType   string          `gorm:"column:scope_type;type:enum('environment','channel','group');not null;uniqueIndex:uq_maco_scope"`
+	ScopeValue  string          `gorm:"column:scope_value;type:varchar(255);not null;uniqueIndex:uq_maco_scope"`
+	ConfigValue json.RawMessage `gorm:"column:config_value;type:json;not null"`
+	Priority    int             `gorm:"column:priority;not null;default:0"`
+	Active      int             `gorm:"column:active;type:tinyint(1);not null;default:1"`
+	CreatedBy   string          `gorm:"column:created_by;type:varchar(319);not null"`
+	UpdatedBy   *string         `gorm:"column:updated_by;type:varchar(319)"`
+	CreatedAt   time.Time       `gorm:"column:created_at;not null;autoCreateTime"`
+	UpdatedAt   *time.Time      `gorm:"column:updated_at;autoUpdateTime"`
+}
+
+func (MicroAppConfigOverride) TableName() string {
+	return "micro_app_config_override"
+}
@@ -0,0 +1,22 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// MicroAppConfigSchema is the JSON Schema that values of a micro app config key must satisfy.
+type MicroAppConfigSchema struct {
+	MicroAppID string          `gorm:"column:micro_app_id;type:varchar(255);primaryKey"`
+	ConfigKey  string          `gorm:"column:config_key;type:varchar(255);primaryKey"`
+	Schema     json.RawMessage `gorm:"column:schema;type:json;not null"`
+	Active     int             `gorm:"column:active;type:tinyint(1);not null;default:1"`
+	CreatedBy  string          `gorm:"column:created_by;type:varchar(319);not null"`
+	UpdatedBy  *string         `gorm:"column:updated_by;type:varchar(319)"`
+	CreatedAt  time.Time       `gorm:"column:created_at;not null;autoCreateTime"`
+	UpdatedAt  *time.Time      `gorm:"column:updated_at;autoUpdateTime"`
+}
+
+func (MicroAppConfigSchema) TableName() string {
+	return "micro_app_config_schema"
+}
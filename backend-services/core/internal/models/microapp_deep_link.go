@@ -0,0 +1,21 @@
+package models
+
+import "time"
+
+// MicroAppDeepLink maps links matching Pattern (e.g. /leave/requests/{id}) to a screen of the
+// micro app. TargetPath is the in-app path template; when nil the link path is used as is.
+type MicroAppDeepLink struct {
+	ID         int        `gorm:"column:id;primaryKey;autoIncrement"`
+	MicroAppID string     `gorm:"column:micro_app_id;type:varchar(255);not null;index"`
+	Pattern    string     `gorm:"column:pattern;type:varchar(512);not null;uniqueIndex"`
+	TargetPath *string    `gorm:"column:target_path;type:varchar(1024)"`
+	Active     int        `gorm:"column:active;type:tinyint(1);not null;default:1"`
+	CreatedBy  string     `gorm:"column:created_by;type:varchar(319);not null"`
+	UpdatedBy  *string    `gorm:"column:updated_by;type:varchar(319)"`
+	CreatedAt  time.Time  `gorm:"column:created_at;not null;autoCreateTime"`
+	UpdatedAt  *time.Time `gorm:"column:updated_at;autoUpdateTime"`
+}
+
+func (MicroAppDeepLink) TableName() string {
+	return "micro_app_deep_link"
+}
@@ -0,0 +1,50 @@
+package models
+
+import "time"
+
+// Bridge capabilities a micro app must be granted before the host app lets it call the matching
+// bridge functions. Harmless bridge functions (alerts, logging, closing the webview, tokens) are
+// always available and are not listed here.
+const (
+	CapabilityQRScanner     = "qr_scanner"     // QR_code
+	CapabilityGoogleAuth    = "google_auth"    // google_login, check_google_auth_state, google_user_info
+	CapabilityGoogleDrive   = "google_drive"   // upload_to_google_drive, restore_google_drive_backup
+	CapabilityTOTPMigration = "totp_migration" // totp_qr_migration_data
+	CapabilityLocalStorage  = "local_storage"  // save_local_data, get_local_data
+	CapabilityFileDownload  = "file_download"  // download_file
+	CapabilityNotifications = "notifications"  // notification_data
+)
+
+// KnownCapabilities is the set of capabilities micro apps may declare.
+var KnownCapabilities = map[string]bool{
+	CapabilityQRScanner:     true,
+	CapabilityGoogleAuth:    true,
+	CapabilityGoogleDrive:   true,
+	CapabilityTOTPMigration: true,
+	CapabilityLocalStorage:  true,
+	CapabilityFileDownload:  true,
+	CapabilityNotifications: true,
+}
+
+// Review states of a capability permission
+const (
+	PermissionRequested = "requested"
+	PermissionApproved  = "approved"
+	PermissionRejected  = "rejected"
+)
+
+// MicroAppPermission records a capability a micro app asked for and the admin decision on it.
+type MicroAppPermission struct {
+	MicroAppID string     `gorm:"column:micro_app_id;type:varchar(255);primaryKey"`
+	Capability string     `gorm:"column:capability;type:varchar(64);primaryKey"`
+	Status     string     `gorm:"column:status;type:enum('requested','approved','rejected');not null;default:requested"`
+	ReviewedBy *string    `gorm:"column:reviewed_by;type:varchar(319)"`
+	ReviewedAt *time.Time `gorm:"column:reviewed_at"`
+	CreatedBy  string     `gorm:"column:created_by;type:varchar(319);not null"`
+	CreatedAt  time.Time  `gorm:"column:created_at;not null;autoCreateTime"`
+	UpdatedAt  *time.Time `gorm:"column:updated_at;autoUpdateTime"`
+}
+
+func (MicroAppPermission) TableName() string {
+	return "micro_app_permission"
+}
@@ -0,0 +1,26 @@
+package models
+
+import "time"
+
+// MicroAppPromotion is a time-boxed slot that features a micro app in the home screen carousel.
+// PromoText and BannerImageURL override the values on the micro app when set.
+// An empty TargetGroups list shows the promotion to every user who can access the app.
+type MicroAppPromotion struct {
+	ID             int        `gorm:"column:id;primaryKey;autoIncrement"`
+	MicroAppID     string     `gorm:"column:micro_app_id;type:varchar(255);not null;index:idx_map_app"`
+	PromoText      *string    `gorm:"column:promo_text;type:varchar(1024)"`
+	BannerImageURL *string    `gorm:"column:banner_image_url;type:varchar(2083)"`
+	StartAt        time.Time  `gorm:"column:start_at;not null"`
+	EndAt          time.Time  `gorm:"column:end_at;not null"`
+	DisplayOrder   int        `gorm:"column:display_order;not null;default:0"`
+	TargetGroups   StringList `gorm:"column:target_groups;type:json"`
+	CreatedBy      string     `gorm:"column:created_by;type:varchar(319);not null"`
+	UpdatedBy      *string    `gorm:"column:updated_by;type:varchar(319)"`
+	CreatedAt      time.Time  `gorm:"column:created_at;not null;autoCreateTime"`
+	UpdatedAt      *time.Time `gorm:"column:updated_at;autoUpdateTime"`
+	Active         int        `gorm:"column:active;type:tinyint(1);not null;default:1"`
+}
+
+func (MicroAppPromotion) TableName() string {
+	return "micro_app_promotion"
+}
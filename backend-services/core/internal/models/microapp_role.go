@@ -4,8 +4,8 @@ import "time"
 
 type MicroAppRole struct {
 	ID         int        `gorm:"column:id;primaryKey;autoIncrement"`
-	MicroAppID string     `gorm:"column:micro_app_id;type:varchar(255);not null"`
-	Role       string     `gorm:"column:role;type:varchar(255);not null"`
+	MicroAppID string     `gorm:"column:micro_app_id;type:varchar(255);not null;uniqueIndex:uq_mar_app_role"`
+	Role       string     `gorm:"column:role;type:varchar(255);not null;uniqueIndex:uq_mar_app_role"`
 	CreatedBy  string     `gorm:"column:created_by;type:varchar(319);not null"`
 	UpdatedBy  *string    `gorm:"column:updated_by;type:varchar(319)"`
 	CreatedAt  time.Time  `gorm:"column:created_at;not null;autoCreateTime"`
@@ -0,0 +1,16 @@
+package models
+
+import "time"
+
+// MicroAppStoredFile records a file the backend stored through the file service for a micro app,
+// such as a published bundle or its icon. Version URLs can point anywhere, so only recorded files
+// are removed from the file service when the app is deleted.
+type MicroAppStoredFile struct {
+	FileName   string    `gorm:"column:file_name;type:varchar(255);primaryKey"`
+	MicroAppID string    `gorm:"column:micro_app_id;type:varchar(255);not null;index"`
+	CreatedAt  time.Time `gorm:"column:created_at;not null;autoCreateTime"`
+}
+
+func (MicroAppStoredFile) TableName() string {
+	return "micro_app_stored_file"
+}
@@ -0,0 +1,15 @@
+package models
+
+import "time"
+
+type MicroAppTag struct {
+	ID         int       `gorm:"column:id;primaryKey;autoIncrement"`
+	MicroAppID string    `gorm:"column:micro_app_id;type:varchar(255);not null;uniqueIndex:uq_mat_app_tag"`
+	Tag        string    `gorm:"column:tag;type:varchar(100);not null;uniqueIndex:uq_mat_app_tag"`
+	CreatedBy  string    `gorm:"column:created_by;type:varchar(319);not null"`
+	CreatedAt  time.Time `gorm:"column:created_at;not null;autoCreateTime"`
+}
+
+func (MicroAppTag) TableName() string {
+	return "micro_app_tag"
+}
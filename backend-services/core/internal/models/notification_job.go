@@ -0,0 +1,72 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Notification job lifecycle: queued -> processing -> succeeded. A failed attempt is put back
+// as retrying until the worker runs out of attempts, then the job is dead-lettered as failed.
+// A scheduled job stays queued until its send time and can be cancelled until then.
+const (
+	NotificationJobQueued     = "queued"
+	NotificationJobProcessing = "processing"
+	NotificationJobRetrying   = "retrying"
+	NotificationJobSucceeded  = "succeeded"
+	NotificationJobFailed     = "failed"
+	NotificationJobCancelled  = "cancelled"
+)
+
+// NotificationJob is a notification send waiting for or handled by the notification worker.
+// Payload holds a NotificationJobPayload.
+type NotificationJob struct {
+	ID               int64           `gorm:"column:id;primaryKey;autoIncrement"`
+	MicroappID       string          `gorm:"column:microapp_id;type:varchar(255);not null;index"`
+	Status           string          `gorm:"column:status;type:enum('queued','processing','retrying','succeeded','failed','cancelled');not null;default:queued"`
+	Payload          json.RawMessage `gorm:"column:payload;type:json;not null"`
+	SendAt           *time.Time      `gorm:"column:send_at"`
+	TimeZone         *string         `gorm:"column:time_zone;type:varchar(64)"`
+	Attempts         int             `gorm:"column:attempts;not null;default:0"`
+	NextAttemptAt    time.Time       `gorm:"column:next_attempt_at;not null"`
+	LockedUntil      *time.Time      `gorm:"column:locked_until"`
+	LastError        *string         `gorm:"column:last_error;type:text"`
+	Recipients       int             `gorm:"column:recipients;not null;default:0"`
+	SuccessCount     int             `gorm:"column:success_count;not null;default:0"`
+	FailureCount     int             `gorm:"column:failure_count;not null;default:0"`
+	DeactivatedCount int             `gorm:"column:deactivated_count;not null;default:0"`
+	SuppressedCount  int             `gorm:"column:suppressed_count;not null;default:0"`
+	DeferredCount    int             `gorm:"column:deferred_count;not null;default:0"`
+	CreatedAt        time.Time       `gorm:"column:created_at;not null;autoCreateTime"`
+	UpdatedAt        *time.Time      `gorm:"column:updated_at;autoUpdateTime"`
+	CompletedAt      *time.Time      `gorm:"column:completed_at"`
+}
+
+func (NotificationJob) TableName() string {
+	return "notification_jobs"
+}
+
+// NotificationJobPayload is the audience and message of a notification job. The audience is
+// either explicit users or groups, which are resolved to users when the job runs. Users whose
+// preferences suppress or defer the message are then taken out of the audience.
+// A message sent from a template carries its rendered copy per locale; each recipient gets the
+// one closest to their language, and Title and Body hold the copy in DefaultLocale.
+type NotificationJobPayload struct {
+	UserEmails    []string               `json:"userEmails,omitempty"`
+	Groups        []string               `json:"groups,omitempty"`
+	ExcludeGroups []string               `json:"excludeGroups,omitempty"`
+	Title         string                 `json:"title"`
+	Body          string                 `json:"body"`
+	Data          map[string]interface{} `json:"data,omitempty"`
+	// Critical messages bypass quiet hours and reach users in critical-only mode
+	Critical bool `json:"critical,omitempty"`
+	// Key of the template the message was rendered from
+	Template      string                         `json:"template,omitempty"`
+	DefaultLocale string                         `json:"defaultLocale,omitempty"`
+	Translations  map[string]NotificationMessage `json:"translations,omitempty"`
+}
+
+// NotificationMessage is the copy of a notification in one locale
+type NotificationMessage struct {
+	Title string `json:"title"`
+	Body  string `json:"body"`
+}
@@ -31,14 +31,16 @@ func (j JSONMap) Value() (driver.Value, error) {
 }
 
 type NotificationLog struct {
-	ID         int64     `gorm:"column:id;primaryKey;autoIncrement"`
-	UserEmail  string    `gorm:"column:user_email;type:varchar(255);not null;index:idx_user_email"`
-	Title      *string   `gorm:"column:title;type:varchar(255)"`
-	Body       *string   `gorm:"column:body;type:text"`
-	Data       JSONMap   `gorm:"column:data;type:json"`
-	SentAt     time.Time `gorm:"column:sent_at;not null;autoCreateTime;index:idx_sent_at"`
-	Status     *string   `gorm:"column:status;type:varchar(50)"`
-	MicroappID *string   `gorm:"column:microapp_id;type:varchar(100);index:idx_microapp_id"`
+	ID         int64      `gorm:"column:id;primaryKey;autoIncrement"`
+	UserEmail  string     `gorm:"column:user_email;type:varchar(255);not null;index:idx_user_email"`
+	Title      *string    `gorm:"column:title;type:varchar(255)"`
+	Body       *string    `gorm:"column:body;type:text"`
+	Data       JSONMap    `gorm:"column:data;type:json"`
+	SentAt     time.Time  `gorm:"column:sent_at;not null;autoCreateTime;index:idx_sent_at"`
+	Status     *string    `gorm:"column:status;type:varchar(50)"`
+	MicroappID *string    `gorm:"column:microapp_id;type:varchar(100);index:idx_microapp_id"`
+	ReadAt     *time.Time `gorm:"column:read_at"`
+	DeletedAt  *time.Time `gorm:"column:deleted_at"`
 }
 
 func (NotificationLog) TableName() string {
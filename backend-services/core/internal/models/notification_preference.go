@@ -0,0 +1,90 @@
+package models
+
+import (
+	"fmt"
+	"time"
+)
+
+// Layout of quiet hours boundaries
+const QuietHoursLayout = "15:04"
+
+// NotificationPreference holds a user's push settings. Quiet hours are read in TimeZone (UTC
+// when unset) and may span midnight, e.g. 22:00 to 07:00. Locale is the language templated
+// notifications are rendered in.
+type NotificationPreference struct {
+	UserEmail       string    `gorm:"column:user_email;type:varchar(319);primaryKey"`
+	QuietHoursStart *string   `gorm:"column:quiet_hours_start;type:char(5)"`
+	QuietHoursEnd   *string   `gorm:"column:quiet_hours_end;type:char(5)"`
+	TimeZone        *string   `gorm:"column:time_zone;type:varchar(64)"`
+	CriticalOnly    bool      `gorm:"column:critical_only;not null;default:false"`
+	Locale          *string   `gorm:"column:locale;type:varchar(35)"`
+	CreatedAt       time.Time `gorm:"column:created_at;not null;autoCreateTime"`
+	UpdatedAt       time.Time `gorm:"column:updated_at;not null;autoUpdateTime"`
+}
+
+func (NotificationPreference) TableName() string {
+	return "user_notification_preference"
+}
+
+// QuietUntil reports whether t falls in the user's quiet hours and, if so, when they end.
+func (p NotificationPreference) QuietUntil(t time.Time) (time.Time, bool) {
+	if p.QuietHoursStart == nil || p.QuietHoursEnd == nil {
+		return time.Time{}, false
+	}
+	start, err := minuteOfDay(*p.QuietHoursStart)
+	if err != nil {
+		return time.Time{}, false
+	}
+	end, err := minuteOfDay(*p.QuietHoursEnd)
+	if err != nil || start == end {
+		return time.Time{}, false
+	}
+
+	loc := time.UTC
+	if p.TimeZone != nil {
+		if l, err := time.LoadLocation(*p.TimeZone); err == nil {
+			loc = l
+		}
+	}
+	local := t.In(loc)
+	now := local.Hour()*60 + local.Minute()
+
+	quiet := now >= start && now < end
+	if start > end {
+		quiet = now >= start || now < end
+	}
+	if !quiet {
+		return time.Time{}, false
+	}
+
+	until := time.Date(local.Year(), local.Month(), local.Day(), end/60, end%60, 0, 0, loc)
+	if !until.After(local) {
+		until = time.Date(local.Year(), local.Month(), local.Day()+1, end/60, end%60, 0, 0, loc)
+	}
+	return until, true
+}
+
+func minuteOfDay(value string) (int, error) {
+	t, err := time.Parse(QuietHoursLayout, value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid time of day %q: %w", value, err)
+	}
+	return t.Hour()*60 + t.Minute(), nil
+}
+
+// NotificationMute stops a micro app's pushes to a user, until MutedUntil or for good when
+// it is nil
+type NotificationMute struct {
+	UserEmail  string     `gorm:"column:user_email;type:varchar(319);primaryKey"`
+	MicroAppID string     `gorm:"column:micro_app_id;type:varchar(255);primaryKey"`
+	MutedUntil *time.Time `gorm:"column:muted_until"`
+	CreatedAt  time.Time  `gorm:"column:created_at;not null;autoCreateTime"`
+}
+
+func (NotificationMute) TableName() string {
+	return "user_notification_mute"
+}
+
+// ActiveMuteCondition is the SQL condition matching mutes in force at a time, which must be
+// passed as its argument
+const ActiveMuteCondition = "(muted_until IS NULL OR muted_until > ?)"
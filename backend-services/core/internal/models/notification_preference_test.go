@@ -0,0 +1,94 @@
+package models
+
+import (
+	"testing"
+	"time"
+)
+
+func TestQuietUntil(t *testing.T) {
+	strp := func(s string) *string { return &s }
+	at := func(value string) time.Time {
+		t.Helper()
+		parsed, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return parsed
+	}
+
+	tests := []struct {
+		name      string
+		pref      NotificationPreference
+		now       string
+		wantQuiet bool
+		wantUntil string
+	}{
+		{
+			name: "no quiet hours",
+			pref: NotificationPreference{},
+			now:  "2026-10-18T23:00:00Z",
+		},
+		{
+			name:      "same-day window",
+			pref:      NotificationPreference{QuietHoursStart: strp("12:00"), QuietHoursEnd: strp("14:00")},
+			now:       "2026-10-18T13:30:00Z",
+			wantQuiet: true,
+			wantUntil: "2026-10-18T14:00:00Z",
+		},
+		{
+			name: "end is exclusive",
+			pref: NotificationPreference{QuietHoursStart: strp("12:00"), QuietHoursEnd: strp("14:00")},
+			now:  "2026-10-18T14:00:00Z",
+		},
+		{
+			name:      "overnight window before midnight",
+			pref:      NotificationPreference{QuietHoursStart: strp("22:00"), QuietHoursEnd: strp("07:00")},
+			now:       "2026-10-18T23:00:00Z",
+			wantQuiet: true,
+			wantUntil: "2026-10-19T07:00:00Z",
+		},
+		{
+			name:      "overnight window after midnight",
+			pref:      NotificationPreference{QuietHoursStart: strp("22:00"), QuietHoursEnd: strp("07:00")},
+			now:       "2026-10-19T06:59:00Z",
+			wantQuiet: true,
+			wantUntil: "2026-10-19T07:00:00Z",
+		},
+		{
+			name: "outside overnight window",
+			pref: NotificationPreference{QuietHoursStart: strp("22:00"), QuietHoursEnd: strp("07:00")},
+			now:  "2026-10-18T12:00:00Z",
+		},
+		{
+			name: "read in the user's time zone",
+			pref: NotificationPreference{
+				QuietHoursStart: strp("22:00"), QuietHoursEnd: strp("07:00"), TimeZone: strp("Asia/Colombo"),
+			},
+			// 23:30 in Colombo (UTC+05:30)
+			now:       "2026-10-18T18:00:00Z",
+			wantQuiet: true,
+			wantUntil: "2026-10-19T01:30:00Z",
+		},
+		{
+			name: "empty window",
+			pref: NotificationPreference{QuietHoursStart: strp("09:00"), QuietHoursEnd: strp("09:00")},
+			now:  "2026-10-18T09:00:00Z",
+		},
+		{
+			name: "malformed boundary",
+			pref: NotificationPreference{QuietHoursStart: strp("late"), QuietHoursEnd: strp("07:00")},
+			now:  "2026-10-18T23:00:00Z",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			until, quiet := tt.pref.QuietUntil(at(tt.now))
+			if quiet != tt.wantQuiet {
+				t.Fatalf("quiet = %v, want %v", quiet, tt.wantQuiet)
+			}
+			if quiet && !until.Equal(at(tt.wantUntil)) {
+				t.Errorf("until = %v, want %v", until, tt.wantUntil)
+			}
+		})
+	}
+}
@@ -0,0 +1,51 @@
+package models
+
+import "time"
+
+// Notification quota periods
+const (
+	QuotaPeriodMinute = "minute"
+	QuotaPeriodDay    = "day"
+)
+
+// NotificationQuota overrides the default send limits of a micro app. A nil limit falls back
+// to the configured default; 0 lifts it.
+type NotificationQuota struct {
+	MicroappID         string    `gorm:"column:microapp_id;type:varchar(255);primaryKey"`
+	PerMinute          *int      `gorm:"column:per_minute"`
+	PerDay             *int      `gorm:"column:per_day"`
+	PerRecipientPerDay *int      `gorm:"column:per_recipient_per_day"`
+	UpdatedBy          string    `gorm:"column:updated_by;type:varchar(319);not null"`
+	CreatedAt          time.Time `gorm:"column:created_at;not null;autoCreateTime"`
+	UpdatedAt          time.Time `gorm:"column:updated_at;not null;autoUpdateTime"`
+}
+
+func (NotificationQuota) TableName() string {
+	return "notification_quotas"
+}
+
+// NotificationQuotaUsage counts what a micro app sent in the current window of a period: send
+// requests per minute, notifications per UTC day. The row is reset when a new window starts.
+type NotificationQuotaUsage struct {
+	MicroappID  string    `gorm:"column:microapp_id;type:varchar(255);primaryKey"`
+	Period      string    `gorm:"column:period;type:enum('minute','day');primaryKey"`
+	WindowStart time.Time `gorm:"column:window_start;not null"`
+	Used        int       `gorm:"column:used;not null;default:0"`
+}
+
+func (NotificationQuotaUsage) TableName() string {
+	return "notification_quota_usage"
+}
+
+// NotificationRecipientUsage counts the notifications a micro app sent a user on Day (UTC).
+// The row is reset on the user's first notification of a new day.
+type NotificationRecipientUsage struct {
+	MicroappID string    `gorm:"column:microapp_id;type:varchar(255);primaryKey"`
+	UserEmail  string    `gorm:"column:user_email;type:varchar(319);primaryKey"`
+	Day        time.Time `gorm:"column:day;type:date;not null"`
+	Used       int       `gorm:"column:used;not null;default:0"`
+}
+
+func (NotificationRecipientUsage) TableName() string {
+	return "notification_recipient_usage"
+}
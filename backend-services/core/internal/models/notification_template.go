@@ -0,0 +1,34 @@
+package models
+
+import "time"
+
+// NotificationTemplate is a notification message a micro app registered under a key, with its
+// copy per locale. Titles and bodies may hold {{placeholders}}, filled in from the params of
+// each send.
+type NotificationTemplate struct {
+	ID            int64                             `gorm:"column:id;primaryKey;autoIncrement"`
+	MicroappID    string                            `gorm:"column:microapp_id;type:varchar(255);not null;uniqueIndex:uq_notification_template_key"`
+	TemplateKey   string                            `gorm:"column:template_key;type:varchar(100);not null;uniqueIndex:uq_notification_template_key"`
+	DefaultLocale string                            `gorm:"column:default_locale;type:varchar(35);not null"`
+	CreatedBy     string                            `gorm:"column:created_by;type:varchar(319);not null"`
+	UpdatedBy     *string                           `gorm:"column:updated_by;type:varchar(319)"`
+	CreatedAt     time.Time                         `gorm:"column:created_at;not null;autoCreateTime"`
+	UpdatedAt     *time.Time                        `gorm:"column:updated_at;autoUpdateTime"`
+	Translations  []NotificationTemplateTranslation `gorm:"foreignKey:TemplateID;references:ID"`
+}
+
+func (NotificationTemplate) TableName() string {
+	return "notification_templates"
+}
+
+// NotificationTemplateTranslation is the copy of a template in one locale (a BCP 47 tag)
+type NotificationTemplateTranslation struct {
+	TemplateID int64  `gorm:"column:template_id;primaryKey"`
+	Locale     string `gorm:"column:locale;type:varchar(35);primaryKey"`
+	Title      string `gorm:"column:title;type:varchar(255);not null"`
+	Body       string `gorm:"column:body;type:text;not null"`
+}
+
+func (NotificationTemplateTranslation) TableName() string {
+	return "notification_template_translations"
+}
@@ -0,0 +1,47 @@
+package models
+
+import "time"
+
+// Schedule limits when a catalog row is offered to users. Rows outside their window are hidden by
+// catalog queries; the catalog scheduler bumps the catalog revision when a window opens or closes,
+// notifies users on publish if asked to, and records that it did so in the *HandledAt columns.
+type Schedule struct {
+	PublishAt        *time.Time `gorm:"column:publish_at;index"`
+	ExpireAt         *time.Time `gorm:"column:expire_at;index"`
+	NotifyOnPublish  bool       `gorm:"column:notify_on_publish;not null;default:false"`
+	PublishHandledAt *time.Time `gorm:"column:publish_handled_at"`
+	ExpireHandledAt  *time.Time `gorm:"column:expire_handled_at"`
+}
+
+// LiveCondition is the SQL condition matching rows whose schedule window contains a time,
+// which must be passed twice as its arguments.
+const LiveCondition = "(publish_at IS NULL OR publish_at <= ?) AND (expire_at IS NULL OR expire_at > ?)"
+
+// LiveAt reports whether t falls inside the schedule window.
+func (s Schedule) LiveAt(t time.Time) bool {
+	return (s.PublishAt == nil || !s.PublishAt.After(t)) && (s.ExpireAt == nil || s.ExpireAt.After(t))
+}
+
+// ScheduleUpdates returns the columns to write when an admin sets a schedule. Like the other
+// optional fields of an upsert, unset dates and a false notifyOnPublish keep the stored value,
+// unless replace is set, in which case the schedule is written exactly as given. Moving a date
+// into the future re-arms the scheduler for it.
+func ScheduleUpdates(publishAt, expireAt *time.Time, notifyOnPublish, replace bool, now time.Time) map[string]any {
+	updates := make(map[string]any)
+	if publishAt != nil || replace {
+		updates["publish_at"] = publishAt
+	}
+	if expireAt != nil || replace {
+		updates["expire_at"] = expireAt
+	}
+	if notifyOnPublish || replace {
+		updates["notify_on_publish"] = notifyOnPublish
+	}
+	if publishAt != nil && publishAt.After(now) {
+		updates["publish_handled_at"] = nil
+	}
+	if expireAt != nil && expireAt.After(now) {
+		updates["expire_handled_at"] = nil
+	}
+	return updates
+}
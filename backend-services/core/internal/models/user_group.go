@@ -0,0 +1,17 @@
+package models
+
+import "time"
+
+// UserGroup records the groups a user belonged to the last time they fetched the catalog or
+// registered a device. Groups come from the identity provider token, so this is how background
+// jobs find the users a micro app is offered to. Nothing tells the server when a user leaves a
+// group, so rows are only as fresh as LastSeenAt.
+type UserGroup struct {
+	UserEmail  string    `gorm:"column:user_email;type:varchar(319);primaryKey"`
+	GroupName  string    `gorm:"column:group_name;type:varchar(255);primaryKey;index"`
+	LastSeenAt time.Time `gorm:"column:last_seen_at;not null"`
+}
+
+func (UserGroup) TableName() string {
+	return "user_groups"
+}
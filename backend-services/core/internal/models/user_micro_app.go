@@ -0,0 +1,21 @@
+package models
+
+import "time"
+
+// UserMicroApp is a user's library entry for a micro app: whether they installed, pinned or hid
+// it and where it goes on their home screen. Mandatory micro apps are installed whether or not
+// the user has an entry.
+type UserMicroApp struct {
+	UserEmail  string    `gorm:"column:user_email;type:varchar(319);primaryKey"`
+	MicroAppID string    `gorm:"column:micro_app_id;type:varchar(255);primaryKey;index"`
+	Installed  bool      `gorm:"column:installed;not null;default:false"`
+	Pinned     bool      `gorm:"column:pinned;not null;default:false"`
+	Hidden     bool      `gorm:"column:hidden;not null;default:false"`
+	SortOrder  int       `gorm:"column:sort_order;not null;default:0"`
+	CreatedAt  time.Time `gorm:"column:created_at;not null;autoCreateTime"`
+	UpdatedAt  time.Time `gorm:"column:updated_at;not null;autoUpdateTime"`
+}
+
+func (UserMicroApp) TableName() string {
+	return "user_micro_app"
+}
@@ -0,0 +1,77 @@
+package notification
+
+import (
+	"encoding/json"
+	"log/slog"
+	"slices"
+	"time"
+
+	"go-backend/internal/config"
+	"go-backend/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// DefaultLimits returns the quotas of micro apps without overrides
+func DefaultLimits(cfg *config.Config) Limits {
+	return Limits{
+		PerMinute:          cfg.NotificationQuotaPerMinute,
+		PerDay:             cfg.NotificationQuotaPerDay,
+		PerRecipientPerDay: cfg.NotificationQuotaPerRecipientPerDay,
+	}
+}
+
+// Enqueue queues a notification of a micro app for the notification worker, to be sent right
+// away or at sendAt. The send is counted against the micro app's quotas with the audience as it
+// is now, so scheduled sends are counted when queued. Users at their per-recipient limit are
+// left out of the job, which is then addressed to the remaining users. It fails with a
+// *QuotaExceededError when the send goes over another limit or nobody is left, in which case
+// nothing is queued or counted. Called inside a transaction, it only rolls back its own writes.
+func Enqueue(db *gorm.DB, appID string, payload models.NotificationJobPayload, sendAt *time.Time, timeZone string, defaults Limits) (models.NotificationJob, error) {
+	job := models.NotificationJob{
+		MicroappID:    appID,
+		Status:        models.NotificationJobQueued,
+		SendAt:        sendAt,
+		NextAttemptAt: time.Now(),
+	}
+	if sendAt != nil {
+		job.NextAttemptAt = *sendAt
+		if timeZone != "" {
+			job.TimeZone = &timeZone
+		}
+	}
+
+	emails, err := Recipients(db, payload)
+	if err != nil {
+		return job, err
+	}
+	limits, err := LimitsFor(db, appID, defaults)
+	if err != nil {
+		return job, err
+	}
+
+	err = db.Transaction(func(tx *gorm.DB) error {
+		dropped, err := Consume(tx, appID, limits, emails, time.Now())
+		if err != nil {
+			return err
+		}
+		if len(dropped) > 0 {
+			slog.Warn("Notification recipients over their daily quota left out", "microapp_id", appID, "recipients", len(dropped), "max", limits.PerRecipientPerDay)
+			over := make(map[string]bool, len(dropped))
+			for _, email := range dropped {
+				over[email] = true
+			}
+			payload.UserEmails = slices.DeleteFunc(emails, func(email string) bool { return over[email] })
+			payload.Groups = nil
+			payload.ExcludeGroups = nil
+		}
+
+		encoded, err := json.Marshal(payload)
+		if err != nil {
+			return err
+		}
+		job.Payload = encoded
+		return tx.Create(&job).Error
+	})
+	return job, err
+}
@@ -0,0 +1,233 @@
+package notification
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"go-backend/internal/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Names of the limits, as reported to callers that exceed them
+const (
+	LimitPerMinute          = "perMinute"
+	LimitPerDay             = "perDay"
+	LimitPerRecipientPerDay = "perRecipientPerDay"
+)
+
+// Users per statement when counting notifications per recipient
+const recipientUsageBatchSize = 500
+
+// Limits caps what a micro app may send. A limit of 0 is not enforced.
+type Limits struct {
+	// Send requests per minute
+	PerMinute int
+	// Notifications per UTC day, one per recipient of each send
+	PerDay int
+	// Notifications to the same user per UTC day
+	PerRecipientPerDay int
+}
+
+// LimitsFor returns the limits of a micro app: its overrides, if any, on top of the defaults
+func LimitsFor(db *gorm.DB, appID string, defaults Limits) (Limits, error) {
+	var quota models.NotificationQuota
+	err := db.Where("microapp_id = ?", appID).First(&quota).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return defaults, nil
+	}
+	if err != nil {
+		return Limits{}, err
+	}
+	return ApplyOverrides(defaults, quota), nil
+}
+
+// ApplyOverrides returns the defaults with the limits a quota overrides replaced
+func ApplyOverrides(defaults Limits, quota models.NotificationQuota) Limits {
+	limits := defaults
+	if quota.PerMinute != nil {
+		limits.PerMinute = *quota.PerMinute
+	}
+	if quota.PerDay != nil {
+		limits.PerDay = *quota.PerDay
+	}
+	if quota.PerRecipientPerDay != nil {
+		limits.PerRecipientPerDay = *quota.PerRecipientPerDay
+	}
+	return limits
+}
+
+// QuotaExceededError reports that a send would take a micro app over one of its limits
+type QuotaExceededError struct {
+	Limit string
+	Max   int
+	// Time until the window of the limit resets
+	RetryAfter time.Duration
+	// Users already at their per-recipient limit, when that is every user of the send
+	Recipients []string
+}
+
+func (e *QuotaExceededError) Error() string {
+	if len(e.Recipients) > 0 {
+		return fmt.Sprintf("notification quota exceeded: all %d recipients already received %d notifications today", len(e.Recipients), e.Max)
+	}
+	return fmt.Sprintf("notification quota exceeded: %s limit of %d", e.Limit, e.Max)
+}
+
+// Consume counts a send to emails, which must be distinct, against the micro app's quotas.
+// Usage is counted even for limits that are not enforced, so admins can see it before setting
+// one. Users already at their per-recipient limit are dropped from the send and returned; the
+// rest are counted. It fails with a *QuotaExceededError when the send goes over another limit or
+// nobody is left to send to; the caller must then roll back the transaction, which holds the
+// usage rows locked until it ends.
+func Consume(tx *gorm.DB, appID string, limits Limits, emails []string, now time.Time) ([]string, error) {
+	now = now.UTC()
+	minute := now.Truncate(time.Minute)
+	day := now.Truncate(24 * time.Hour)
+
+	used, err := addUsage(tx, appID, models.QuotaPeriodMinute, minute, 1)
+	if err != nil {
+		return nil, err
+	}
+	if limits.PerMinute > 0 && used > limits.PerMinute {
+		return nil, &QuotaExceededError{Limit: LimitPerMinute, Max: limits.PerMinute, RetryAfter: minute.Add(time.Minute).Sub(now)}
+	}
+
+	dropped, err := consumeRecipients(tx, appID, limits.PerRecipientPerDay, emails, day)
+	if err != nil {
+		return nil, err
+	}
+	if len(emails) > 0 && len(dropped) == len(emails) {
+		return nil, &QuotaExceededError{
+			Limit:      LimitPerRecipientPerDay,
+			Max:        limits.PerRecipientPerDay,
+			RetryAfter: day.AddDate(0, 0, 1).Sub(now),
+			Recipients: dropped,
+		}
+	}
+
+	used, err = addUsage(tx, appID, models.QuotaPeriodDay, day, len(emails)-len(dropped))
+	if err != nil {
+		return nil, err
+	}
+	if limits.PerDay > 0 && used > limits.PerDay {
+		return nil, &QuotaExceededError{Limit: LimitPerDay, Max: limits.PerDay, RetryAfter: day.AddDate(0, 0, 1).Sub(now)}
+	}
+	return dropped, nil
+}
+
+// consumeRecipients counts a notification to each user and returns the users it takes over
+// the per-recipient limit, whose count is then given back. Incrementing first locks the rows,
+// so concurrent sends cannot both let the same user's last notification through.
+func consumeRecipients(tx *gorm.DB, appID string, limit int, emails []string, day time.Time) ([]string, error) {
+	var over []string
+	for batch := range slices.Chunk(emails, recipientUsageBatchSize) {
+		rows := make([]models.NotificationRecipientUsage, 0, len(batch))
+		for _, email := range batch {
+			rows = append(rows, models.NotificationRecipientUsage{MicroappID: appID, UserEmail: email, Day: day, Used: 1})
+		}
+		if err := tx.Clauses(clause.OnConflict{DoUpdates: windowedIncrement("day")}).Create(&rows).Error; err != nil {
+			return nil, err
+		}
+		if limit <= 0 {
+			continue
+		}
+
+		var batchOver []string
+		if err := tx.Model(&models.NotificationRecipientUsage{}).
+			Where("microapp_id = ? AND user_email IN ? AND day = ? AND used > ?", appID, batch, day, limit).
+			Pluck("user_email", &batchOver).Error; err != nil {
+			return nil, err
+		}
+		if len(batchOver) == 0 {
+			continue
+		}
+		if err := tx.Model(&models.NotificationRecipientUsage{}).
+			Where("microapp_id = ? AND user_email IN ?", appID, batchOver).
+			Update("used", gorm.Expr("used - 1")).Error; err != nil {
+			return nil, err
+		}
+		over = append(over, batchOver...)
+	}
+	return over, nil
+}
+
+// Usage is what a micro app sent in the current windows of its limits
+type Usage struct {
+	Minute int
+	Day    int
+	// Most notifications sent to a single user today
+	MaxPerRecipient int
+}
+
+// CurrentUsage returns the micro app's usage in the windows that contain now
+func CurrentUsage(db *gorm.DB, appID string, now time.Time) (Usage, error) {
+	now = now.UTC()
+	windows := map[string]time.Time{
+		models.QuotaPeriodMinute: now.Truncate(time.Minute),
+		models.QuotaPeriodDay:    now.Truncate(24 * time.Hour),
+	}
+
+	var rows []models.NotificationQuotaUsage
+	if err := db.Where("microapp_id = ?", appID).Find(&rows).Error; err != nil {
+		return Usage{}, err
+	}
+
+	var usage Usage
+	for _, row := range rows {
+		// A row left from an earlier window counts as no usage
+		if !row.WindowStart.Equal(windows[row.Period]) {
+			continue
+		}
+		switch row.Period {
+		case models.QuotaPeriodMinute:
+			usage.Minute = row.Used
+		case models.QuotaPeriodDay:
+			usage.Day = row.Used
+		}
+	}
+
+	var maxPerRecipient *int
+	if err := db.Model(&models.NotificationRecipientUsage{}).
+		Where("microapp_id = ? AND day = ?", appID, windows[models.QuotaPeriodDay]).
+		Select("MAX(used)").
+		Scan(&maxPerRecipient).Error; err != nil {
+		return Usage{}, err
+	}
+	if maxPerRecipient != nil {
+		usage.MaxPerRecipient = *maxPerRecipient
+	}
+	return usage, nil
+}
+
+// addUsage adds n to the micro app's usage in the window of a period and returns the total
+func addUsage(tx *gorm.DB, appID, period string, windowStart time.Time, n int) (int, error) {
+	usage := models.NotificationQuotaUsage{MicroappID: appID, Period: period, WindowStart: windowStart, Used: n}
+	if err := tx.Clauses(clause.OnConflict{DoUpdates: windowedIncrement("window_start")}).Create(&usage).Error; err != nil {
+		return 0, err
+	}
+	if err := tx.Where("microapp_id = ? AND period = ?", appID, period).First(&usage).Error; err != nil {
+		return 0, err
+	}
+	return usage.Used, nil
+}
+
+// windowedIncrement adds to the usage of a row's window, or restarts the count when the insert
+// opens a later window. MySQL applies the assignments in order, so used is computed against the
+// stored window before it moves; a window behind the stored one (clock skew between replicas)
+// counts towards the stored one.
+func windowedIncrement(column string) clause.Set {
+	return clause.Set{
+		{
+			Column: clause.Column{Name: "used"},
+			Value:  gorm.Expr(fmt.Sprintf("IF(%[1]s >= VALUES(%[1]s), used + VALUES(used), VALUES(used))", column)),
+		},
+		{
+			Column: clause.Column{Name: column},
+			Value:  gorm.Expr(fmt.Sprintf("GREATEST(%[1]s, VALUES(%[1]s))", column)),
+		},
+	}
+}
@@ -0,0 +1,104 @@
+package notification
+
+import (
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"go-backend/internal/models"
+	"go-backend/internal/testdb"
+
+	"gorm.io/gorm"
+)
+
+func TestApplyOverrides(t *testing.T) {
+	perDay := 0
+	limits := ApplyOverrides(Limits{PerMinute: 10, PerDay: 100, PerRecipientPerDay: 5}, models.NotificationQuota{PerDay: &perDay})
+	if want := (Limits{PerMinute: 10, PerDay: 0, PerRecipientPerDay: 5}); limits != want {
+		t.Errorf("limits = %+v, want %+v", limits, want)
+	}
+}
+
+// consumeExceeded runs Consume and returns its quota error, failing the test on any other error
+func consumeExceeded(t *testing.T, db *gorm.DB, appID string, limits Limits, emails []string, now time.Time) *QuotaExceededError {
+	t.Helper()
+	_, err := Consume(db, appID, limits, emails, now)
+	if err == nil {
+		return nil
+	}
+	var exceeded *QuotaExceededError
+	if !errors.As(err, &exceeded) {
+		t.Fatalf("Consume: %v", err)
+	}
+	return exceeded
+}
+
+func TestConsumePerMinute(t *testing.T) {
+	db := testdb.Open(t)
+	now := time.Date(2026, 10, 18, 12, 0, 15, 0, time.UTC)
+	limits := Limits{PerMinute: 2}
+
+	for i := range 2 {
+		if exceeded := consumeExceeded(t, db, "quota-minute", limits, []string{"a@example.com"}, now); exceeded != nil {
+			t.Fatalf("send %d: %v", i+1, exceeded)
+		}
+	}
+	exceeded := consumeExceeded(t, db, "quota-minute", limits, []string{"a@example.com"}, now)
+	if exceeded == nil || exceeded.Limit != LimitPerMinute || exceeded.RetryAfter != 45*time.Second {
+		t.Fatalf("third send = %+v, want the per minute limit with 45s to wait", exceeded)
+	}
+
+	// The next minute opens a new window
+	if exceeded := consumeExceeded(t, db, "quota-minute", limits, []string{"a@example.com"}, now.Add(time.Minute)); exceeded != nil {
+		t.Fatalf("send in the next minute: %v", exceeded)
+	}
+}
+
+func TestConsumePerDay(t *testing.T) {
+	db := testdb.Open(t)
+	now := time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)
+	limits := Limits{PerDay: 3}
+
+	if exceeded := consumeExceeded(t, db, "quota-day", limits, []string{"a@example.com", "b@example.com"}, now); exceeded != nil {
+		t.Fatalf("first send: %v", exceeded)
+	}
+	exceeded := consumeExceeded(t, db, "quota-day", limits, []string{"c@example.com", "d@example.com"}, now)
+	if exceeded == nil || exceeded.Limit != LimitPerDay || exceeded.Max != 3 {
+		t.Fatalf("second send = %+v, want the per day limit", exceeded)
+	}
+}
+
+func TestConsumePerRecipient(t *testing.T) {
+	db := testdb.Open(t)
+	now := time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)
+	limits := Limits{PerRecipientPerDay: 1}
+
+	if exceeded := consumeExceeded(t, db, "quota-recipient", limits, []string{"a@example.com"}, now); exceeded != nil {
+		t.Fatalf("first send: %v", exceeded)
+	}
+	// Only the user at the limit is left out; the rest of the send goes ahead
+	dropped, err := Consume(db, "quota-recipient", limits, []string{"a@example.com", "b@example.com"}, now)
+	if err != nil || !slices.Equal(dropped, []string{"a@example.com"}) {
+		t.Fatalf("second send dropped %v (%v), want only a@example.com", dropped, err)
+	}
+	usage, err := CurrentUsage(db, "quota-recipient", now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if usage.Day != 2 || usage.MaxPerRecipient != 1 {
+		t.Errorf("usage = %+v, want 2 sent today and nobody over the limit", usage)
+	}
+
+	// A send nobody may receive fails as a whole
+	exceeded := consumeExceeded(t, db, "quota-recipient", limits, []string{"a@example.com", "b@example.com"}, now)
+	if exceeded == nil || exceeded.Limit != LimitPerRecipientPerDay || len(exceeded.Recipients) != 2 {
+		t.Fatalf("third send = %+v, want both users over the per recipient limit", exceeded)
+	}
+
+	// The next day restarts every user's count
+	tomorrow := now.AddDate(0, 0, 1)
+	if exceeded := consumeExceeded(t, db, "quota-recipient", limits, []string{"a@example.com"}, tomorrow); exceeded != nil {
+		t.Fatalf("send the next day: %v", exceeded)
+	}
+}
@@ -0,0 +1,44 @@
+// Package notification holds the notification rules shared by the send API and the
+// notification worker: who a message is addressed to and how much a micro app may send.
+package notification
+
+import (
+	"slices"
+	"time"
+
+	"go-backend/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// GroupMembershipTTL is how long a recorded group membership counts after the user last
+// fetched the catalog or registered a device. Memberships are only learned from user tokens,
+// so users removed from a group at the identity provider keep matching it until they sign in
+// again or this long has passed.
+const GroupMembershipTTL = 30 * 24 * time.Hour
+
+// Recipients returns the distinct users a notification is addressed to, sorted. Groups are
+// resolved from the groups recorded when users last fetched the catalog or registered a
+// device, ignoring memberships not seen within GroupMembershipTTL.
+func Recipients(db *gorm.DB, payload models.NotificationJobPayload) ([]string, error) {
+	if len(payload.Groups) == 0 {
+		emails := slices.Clone(payload.UserEmails)
+		slices.Sort(emails)
+		return slices.Compact(emails), nil
+	}
+
+	seenSince := time.Now().Add(-GroupMembershipTTL)
+	query := db.Model(&models.UserGroup{}).
+		Distinct("user_email").
+		Where("group_name IN ? AND last_seen_at >= ?", payload.Groups, seenSince)
+	if len(payload.ExcludeGroups) > 0 {
+		query = query.Where("user_email NOT IN (?)", db.Model(&models.UserGroup{}).
+			Select("user_email").
+			Where("group_name IN ?", payload.ExcludeGroups))
+	}
+	var emails []string
+	if err := query.Order("user_email ASC").Pluck("user_email", &emails).Error; err != nil {
+		return nil, err
+	}
+	return emails, nil
+}
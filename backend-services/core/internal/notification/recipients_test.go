@@ -0,0 +1,42 @@
+package notification
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	"go-backend/internal/models"
+	"go-backend/internal/testdb"
+)
+
+func TestRecipientsOfUsers(t *testing.T) {
+	emails, err := Recipients(nil, models.NotificationJobPayload{UserEmails: []string{"b@example.com", "a@example.com", "b@example.com"}})
+	if err != nil {
+		t.Fatalf("Recipients: %v", err)
+	}
+	if want := []string{"a@example.com", "b@example.com"}; !slices.Equal(emails, want) {
+		t.Errorf("recipients = %v, want %v", emails, want)
+	}
+}
+
+func TestRecipientsIgnoreStaleGroups(t *testing.T) {
+	db := testdb.Open(t)
+	now := time.Now()
+	rows := []models.UserGroup{
+		{UserEmail: "recent@example.com", GroupName: "test-staff", LastSeenAt: now},
+		{UserEmail: "stale@example.com", GroupName: "test-staff", LastSeenAt: now.Add(-GroupMembershipTTL - time.Hour)},
+		{UserEmail: "excluded@example.com", GroupName: "test-staff", LastSeenAt: now},
+		{UserEmail: "excluded@example.com", GroupName: "test-contractors", LastSeenAt: now},
+	}
+	if err := db.Create(&rows).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	emails, err := Recipients(db, models.NotificationJobPayload{Groups: []string{"test-staff"}, ExcludeGroups: []string{"test-contractors"}})
+	if err != nil {
+		t.Fatalf("Recipients: %v", err)
+	}
+	if want := []string{"recent@example.com"}; !slices.Equal(emails, want) {
+		t.Errorf("recipients = %v, want %v", emails, want)
+	}
+}
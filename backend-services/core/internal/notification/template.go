@@ -0,0 +1,106 @@
+package notification
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+
+	"go-backend/internal/models"
+
+	"golang.org/x/text/language"
+)
+
+// placeholderPattern matches a {{name}} placeholder in a template's title or body
+var placeholderPattern = regexp.MustCompile(`\{\{\s*([A-Za-z_][A-Za-z0-9_]*)\s*\}\}`)
+
+// CanonicalLocale returns the canonical form of a BCP 47 tag, e.g. en-us becomes en-US
+func CanonicalLocale(locale string) (string, error) {
+	tag, err := language.Parse(locale)
+	if err != nil {
+		return "", fmt.Errorf("invalid locale %q", locale)
+	}
+	return tag.String(), nil
+}
+
+// Placeholders returns the distinct placeholder names used in a template's copy, sorted
+func Placeholders(translations []models.NotificationTemplateTranslation) []string {
+	var names []string
+	for _, t := range translations {
+		for _, text := range []string{t.Title, t.Body} {
+			for _, match := range placeholderPattern.FindAllStringSubmatch(text, -1) {
+				names = append(names, match[1])
+			}
+		}
+	}
+	slices.Sort(names)
+	return slices.Compact(names)
+}
+
+// Render fills in the placeholders of every translation of a template. It fails, naming them,
+// when params lack a placeholder used in any locale; extra params are ignored.
+func Render(template models.NotificationTemplate, params map[string]string) (map[string]models.NotificationMessage, error) {
+	var missing []string
+	for _, name := range Placeholders(template.Translations) {
+		if _, ok := params[name]; !ok {
+			missing = append(missing, name)
+		}
+	}
+	if len(missing) > 0 {
+		return nil, fmt.Errorf("missing template params: %s", strings.Join(missing, ", "))
+	}
+
+	fill := func(text string) string {
+		return placeholderPattern.ReplaceAllStringFunc(text, func(placeholder string) string {
+			return params[placeholderPattern.FindStringSubmatch(placeholder)[1]]
+		})
+	}
+	messages := make(map[string]models.NotificationMessage, len(template.Translations))
+	for _, t := range template.Translations {
+		messages[t.Locale] = models.NotificationMessage{Title: fill(t.Title), Body: fill(t.Body)}
+	}
+	return messages, nil
+}
+
+// LocaleMatcher picks, for a recipient's preferred language, the closest locale a message was
+// rendered in
+type LocaleMatcher struct {
+	locales []string
+	matcher language.Matcher
+}
+
+// NewLocaleMatcher creates a matcher over the given locales that falls back to defaultLocale,
+// which must be one of them
+func NewLocaleMatcher(defaultLocale string, locales []string) *LocaleMatcher {
+	// The matcher falls back to the first supported tag
+	ordered := []string{defaultLocale}
+	for _, locale := range locales {
+		if locale != defaultLocale {
+			ordered = append(ordered, locale)
+		}
+	}
+	slices.Sort(ordered[1:])
+
+	tags := make([]language.Tag, 0, len(ordered))
+	for _, locale := range ordered {
+		tags = append(tags, language.Make(locale))
+	}
+	return &LocaleMatcher{locales: ordered, matcher: language.NewMatcher(tags)}
+}
+
+// Match returns the locale to use for a recipient, or the default when they have no preferred
+// language or none of the locales is close to it
+func (m *LocaleMatcher) Match(preferred string) string {
+	if preferred == "" {
+		return m.locales[0]
+	}
+	tag, err := language.Parse(preferred)
+	if err != nil {
+		return m.locales[0]
+	}
+	_, index, confidence := m.matcher.Match(tag)
+	if confidence == language.No {
+		return m.locales[0]
+	}
+	return m.locales[index]
+}
@@ -0,0 +1,77 @@
+package notification
+
+import (
+	"slices"
+	"strings"
+	"testing"
+
+	"go-backend/internal/models"
+)
+
+func testTemplate() models.NotificationTemplate {
+	return models.NotificationTemplate{
+		Translations: []models.NotificationTemplateTranslation{
+			{Locale: "en", Title: "Hi {{name}}", Body: "Your request {{ id }} was approved"},
+			{Locale: "fr", Title: "Bonjour {{name}}", Body: "Votre demande {{id}} est approuvée"},
+		},
+	}
+}
+
+func TestPlaceholders(t *testing.T) {
+	got := Placeholders(testTemplate().Translations)
+	if want := []string{"id", "name"}; !slices.Equal(got, want) {
+		t.Errorf("Placeholders = %v, want %v", got, want)
+	}
+}
+
+func TestRender(t *testing.T) {
+	messages, err := Render(testTemplate(), map[string]string{"name": "Ana", "id": "42", "unused": "x"})
+	if err != nil {
+		t.Fatalf("Render: %v", err)
+	}
+	want := map[string]models.NotificationMessage{
+		"en": {Title: "Hi Ana", Body: "Your request 42 was approved"},
+		"fr": {Title: "Bonjour Ana", Body: "Votre demande 42 est approuvée"},
+	}
+	for locale, message := range want {
+		if messages[locale] != message {
+			t.Errorf("%s = %+v, want %+v", locale, messages[locale], message)
+		}
+	}
+}
+
+func TestRenderMissingParams(t *testing.T) {
+	_, err := Render(testTemplate(), map[string]string{"name": "Ana"})
+	if err == nil || !strings.Contains(err.Error(), "id") {
+		t.Fatalf("err = %v, want the missing id param named", err)
+	}
+}
+
+func TestCanonicalLocale(t *testing.T) {
+	if got, err := CanonicalLocale("en-us"); err != nil || got != "en-US" {
+		t.Errorf("CanonicalLocale(en-us) = %q, %v; want en-US", got, err)
+	}
+	if _, err := CanonicalLocale("not a locale"); err == nil {
+		t.Error("CanonicalLocale accepted an invalid tag")
+	}
+}
+
+func TestLocaleMatcher(t *testing.T) {
+	m := NewLocaleMatcher("en", []string{"fr", "en", "pt-BR"})
+	tests := []struct {
+		preferred string
+		want      string
+	}{
+		{"", "en"},
+		{"fr", "fr"},
+		{"fr-CA", "fr"},
+		{"pt", "pt-BR"},
+		{"ja", "en"},
+		{"???", "en"},
+	}
+	for _, tt := range tests {
+		if got := m.Match(tt.preferred); got != tt.want {
+			t.Errorf("Match(%q) = %q, want %q", tt.preferred, got, tt.want)
+		}
+	}
+}
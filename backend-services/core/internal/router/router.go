@@ -1,16 +1,21 @@
 package router
 
 import (
+	"context"
 	"log/slog"
 	"net/http"
+	"time"
 
 	v1 "go-backend/internal/api/v1/router"
 	"go-backend/internal/auth"
 	"go-backend/internal/config"
+	"go-backend/internal/jobs"
+	"go-backend/internal/notification"
 	"go-backend/internal/services"
 
 	// pluggable services
 	fileservice "go-backend/plugins/file-service"
+	notificationservice "go-backend/plugins/notification-service"
 	userservice "go-backend/plugins/user-service"
 
 	"github.com/go-chi/chi/v5"
@@ -22,6 +27,9 @@ const (
 	apiV1Prefix         = "/api/v1"
 	userRoutesPrefix    = apiV1Prefix
 	serviceRoutesPrefix = apiV1Prefix + "/services"
+
+	// Config key of the FCM notification service's credentials file
+	fcmCredentialsPathKey = "NOTIFICATION_SERVICE_FCM_CREDENTIALS_PATH"
 )
 
 func NewRouter(db *gorm.DB, cfg *config.Config) http.Handler {
@@ -52,19 +60,34 @@ func NewRouter(db *gorm.DB, cfg *config.Config) http.Handler {
 		slog.Info("Internal IDP Validator initialized successfully", "idp_url", cfg.InternalIdPBaseURL)
 	}
 
-	// Initialize FCM service
-	var fcmService services.NotificationService
-	if cfg.FirebaseCredentialsPath != "" {
-		fcmService, err = services.NewFCMService(cfg.FirebaseCredentialsPath)
-		if err != nil {
-			slog.Error("Failed to initialize FCM service", "error", err)
-		} else {
-			slog.Info("FCM service initialized successfully")
-		}
+	// Initialize Notification Service
+	notificationConfig := cfg.GetNotificationServiceConfig()
+	if _, ok := notificationConfig[fcmCredentialsPathKey]; !ok && cfg.FirebaseCredentialsPath != "" {
+		// FIREBASE_CREDENTIALS_PATH predates the notification service plugins
+		notificationConfig[fcmCredentialsPathKey] = cfg.FirebaseCredentialsPath
+	}
+	notificationService, err := notificationservice.Registry.Get(cfg.NotificationServiceType, notificationConfig)
+	if err != nil {
+		// Push notifications are optional, so the server still starts without a provider
+		slog.Warn("Notification service not available, notification features will be unavailable", "type", cfg.NotificationServiceType, "error", err)
+		notificationService = nil
 	} else {
-		slog.Warn("Firebase credentials path not configured, notification features will be unavailable")
+		slog.Info("Notification Service initialized successfully", "type", cfg.NotificationServiceType)
 	}
 
+	// Start the catalog scheduler (scheduled publishing and expiry of micro apps and versions)
+	jobs.NewCatalogScheduler(db, notification.DefaultLimits(cfg), time.Duration(cfg.CatalogSchedulerIntervalSec)*time.Second).
+		Start(context.Background())
+
+	// Start the analytics rollup (daily micro app usage aggregates)
+	jobs.NewAnalyticsRollup(db, time.Duration(cfg.AnalyticsRollupIntervalSec)*time.Second, cfg.AnalyticsRetentionDays).
+		Start(context.Background())
+
+	// Start the notification worker (sends queued notifications with retries)
+	jobs.NewNotificationWorker(db, notificationService, cfg.NotificationWorkers, cfg.NotificationMaxAttempts,
+		time.Duration(cfg.NotificationRetryBaseSec)*time.Second).
+		Start(context.Background())
+
 	// Initialize File Service
 	fileServiceConfig := cfg.GetFileServiceConfig()
 	fileServiceConfig["DB"] = db // Add the database connection access for default db file service (and db user service)
@@ -99,7 +122,7 @@ func NewRouter(db *gorm.DB, cfg *config.Config) http.Handler {
 		if externalIDPValidator != nil {
 			r.Use(auth.AuthMiddleware(externalIDPValidator))
 		}
-		r.Mount("/", v1.NewUserRouter(db, fcmService, fileService, userService, cfg))
+		r.Mount("/", v1.NewUserRouter(db, notificationService, fileService, userService, cfg))
 	})
 
 	// Service Routes (validates against Internal IDP)
@@ -107,7 +130,7 @@ func NewRouter(db *gorm.DB, cfg *config.Config) http.Handler {
 		if internalIDPValidator != nil {
 			r.Use(auth.ServiceOAuthMiddleware(internalIDPValidator))
 		}
-		r.Mount("/", v1.NewServiceRouter(db, fcmService))
+		r.Mount("/", v1.NewServiceRouter(db, cfg, notificationService))
 	})
 
 	return r
@@ -0,0 +1,45 @@
+// Package testdb gives tests a MySQL database to run queries against. Tests that need one are
+// skipped unless TEST_DATABASE_DSN names a database with the migrations applied, e.g.
+// user:password@tcp(localhost:3306)/superapp_test?parseTime=True
+package testdb
+
+import (
+	"os"
+	"testing"
+
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// DSNVariable is the environment variable holding the test database DSN
+const DSNVariable = "TEST_DATABASE_DSN"
+
+// Open returns a transaction on the test database that is rolled back when the test ends, so
+// tests leave no rows behind. Nested transactions of the code under test become savepoints.
+func Open(t *testing.T) *gorm.DB {
+	t.Helper()
+	dsn := os.Getenv(DSNVariable)
+	if dsn == "" {
+		t.Skipf("%s not set", DSNVariable)
+	}
+
+	db, err := gorm.Open(mysql.Open(dsn), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	if err != nil {
+		t.Fatalf("failed to connect to the test database: %v", err)
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		t.Fatalf("failed to get DB instance: %v", err)
+	}
+
+	tx := db.Begin()
+	if tx.Error != nil {
+		t.Fatalf("failed to begin transaction: %v", tx.Error)
+	}
+	t.Cleanup(func() {
+		tx.Rollback()
+		sqlDB.Close()
+	})
+	return tx
+}
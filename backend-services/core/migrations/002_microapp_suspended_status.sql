@@ -0,0 +1,33 @@
+-- ========================================
+-- Migration: 002_microapp_suspended_status
+-- ========================================
+-- Description: Documents the suspended status (2) used for versions, roles and
+--              configs that were active when their micro app was deactivated.
+--              Reactivating the micro app restores suspended rows to active.
+--              Micro apps deactivated before this migration get their active
+--              rows suspended too, so they reactivate like any other.
+-- ========================================
+
+ALTER TABLE `micro_app_version`
+  MODIFY `active` TINYINT(1) NOT NULL DEFAULT 1 COMMENT 'Active status (1=active, 0=soft deleted, 2=suspended with app)';
+
+ALTER TABLE `micro_app_role`
+  MODIFY `active` TINYINT(1) NOT NULL DEFAULT 1 COMMENT 'Active status (1=active, 0=soft deleted, 2=suspended with app)';
+
+ALTER TABLE `micro_app_config`
+  MODIFY `active` TINYINT(1) NOT NULL DEFAULT 1 COMMENT 'Active status (1=active, 0=inactive, 2=suspended with app)';
+
+UPDATE `micro_app_version` c
+  JOIN `micro_app` a ON a.`micro_app_id` = c.`micro_app_id`
+  SET c.`active` = 2
+  WHERE a.`active` = 0 AND c.`active` = 1;
+
+UPDATE `micro_app_role` c
+  JOIN `micro_app` a ON a.`micro_app_id` = c.`micro_app_id`
+  SET c.`active` = 2
+  WHERE a.`active` = 0 AND c.`active` = 1;
+
+UPDATE `micro_app_config` c
+  JOIN `micro_app` a ON a.`micro_app_id` = c.`micro_app_id`
+  SET c.`active` = 2
+  WHERE a.`active` = 0 AND c.`active` = 1;
@@ -0,0 +1,37 @@
+-- ========================================
+-- Migration: 003_microapp_promotions
+-- ========================================
+-- Description: Time-boxed promotion slots that feature micro apps in the
+--              home screen carousel, with ordering and group targeting
+-- ========================================
+
+CREATE TABLE `micro_app_promotion` (
+  `id` INT NOT NULL AUTO_INCREMENT COMMENT 'Internal auto-increment ID',
+  `micro_app_id` VARCHAR(255) NOT NULL COMMENT 'Reference to micro_app.micro_app_id',
+  `promo_text` VARCHAR(1024) DEFAULT NULL COMMENT 'Overrides micro_app.promo_text for this slot',
+  `banner_image_url` VARCHAR(2083) DEFAULT NULL COMMENT 'Overrides micro_app.banner_image_url for this slot',
+  `start_at` DATETIME NOT NULL COMMENT 'Start of the promotion window',
+  `end_at` DATETIME NOT NULL COMMENT 'End of the promotion window (exclusive)',
+  `display_order` INT NOT NULL DEFAULT 0 COMMENT 'Carousel position (ascending)',
+  `target_groups` JSON DEFAULT NULL COMMENT 'User groups to show the slot to (empty = everyone)',
+  `created_by` VARCHAR(319) NOT NULL COMMENT 'Email of creator',
+  `updated_by` VARCHAR(319) DEFAULT NULL COMMENT 'Email of last updater',
+  `created_at` DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT 'Creation timestamp',
+  `updated_at` DATETIME DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP COMMENT 'Last update timestamp',
+  `active` TINYINT(1) NOT NULL DEFAULT 1 COMMENT 'Active status (1=active, 0=soft deleted)',
+
+  PRIMARY KEY (`id`),
+
+  INDEX `idx_map_app` (`micro_app_id`),
+  INDEX `idx_map_window` (`active`, `start_at`, `end_at`),
+
+  CONSTRAINT `fk_map_micro_app`
+    FOREIGN KEY (`micro_app_id`)
+    REFERENCES `micro_app` (`micro_app_id`)
+    ON DELETE CASCADE
+    ON UPDATE CASCADE
+) ENGINE=InnoDB
+  AUTO_INCREMENT=1
+  DEFAULT CHARSET=utf8mb4
+  COLLATE=utf8mb4_0900_ai_ci
+  COMMENT='Featured micro app promotion slots';
@@ -0,0 +1,69 @@
+-- ========================================
+-- Migration: 004_microapp_categories_tags
+-- ========================================
+-- Description: Admin-managed categories, free-form tags and a per-app sort
+--              weight for organising the home screen catalog
+-- ========================================
+
+-- ========================================
+-- TABLE: micro_app_category
+-- ========================================
+
+CREATE TABLE `micro_app_category` (
+  `id` INT NOT NULL AUTO_INCREMENT COMMENT 'Internal auto-increment ID',
+  `category_id` VARCHAR(255) NOT NULL COMMENT 'Unique category identifier',
+  `name` VARCHAR(255) NOT NULL COMMENT 'Category display name',
+  `description` TEXT COMMENT 'Category description',
+  `display_order` INT NOT NULL DEFAULT 0 COMMENT 'Home screen position (ascending)',
+  `created_by` VARCHAR(319) NOT NULL COMMENT 'Email of creator',
+  `updated_by` VARCHAR(319) DEFAULT NULL COMMENT 'Email of last updater',
+  `created_at` DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT 'Creation timestamp',
+  `updated_at` DATETIME DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP COMMENT 'Last update timestamp',
+  `active` TINYINT(1) NOT NULL DEFAULT 1 COMMENT 'Active status (1=active, 0=soft deleted)',
+
+  PRIMARY KEY (`id`),
+  UNIQUE KEY `uq_micro_app_category_id` (`category_id`),
+
+  INDEX `idx_mac_category_active` (`active`)
+) ENGINE=InnoDB
+  AUTO_INCREMENT=1
+  DEFAULT CHARSET=utf8mb4
+  COLLATE=utf8mb4_0900_ai_ci
+  COMMENT='Micro app categories';
+
+-- ========================================
+-- TABLE: micro_app_tag
+-- ========================================
+
+CREATE TABLE `micro_app_tag` (
+  `id` INT NOT NULL AUTO_INCREMENT COMMENT 'Internal auto-increment ID',
+  `micro_app_id` VARCHAR(255) NOT NULL COMMENT 'Reference to micro_app.micro_app_id',
+  `tag` VARCHAR(100) NOT NULL COMMENT 'Free-form tag',
+  `created_by` VARCHAR(319) NOT NULL COMMENT 'Email of creator',
+  `created_at` DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT 'Creation timestamp',
+
+  PRIMARY KEY (`id`),
+  UNIQUE KEY `uq_mat_app_tag` (`micro_app_id`, `tag`),
+
+  INDEX `idx_mat_tag` (`tag`),
+
+  CONSTRAINT `fk_mat_micro_app`
+    FOREIGN KEY (`micro_app_id`)
+    REFERENCES `micro_app` (`micro_app_id`)
+    ON DELETE CASCADE
+    ON UPDATE CASCADE
+) ENGINE=InnoDB
+  AUTO_INCREMENT=1
+  DEFAULT CHARSET=utf8mb4
+  COLLATE=utf8mb4_0900_ai_ci
+  COMMENT='Micro app tags';
+
+-- ========================================
+-- micro_app: category and sort weight
+-- ========================================
+
+ALTER TABLE `micro_app`
+  ADD COLUMN `category_id` VARCHAR(255) DEFAULT NULL COMMENT 'Reference to micro_app_category.category_id' AFTER `mandatory`,
+  ADD COLUMN `sort_weight` INT NOT NULL DEFAULT 0 COMMENT 'Catalog sort weight (higher first)' AFTER `category_id`,
+  ADD INDEX `idx_micro_app_category` (`category_id`),
+  ADD INDEX `idx_micro_app_sort_weight` (`sort_weight`);
@@ -0,0 +1,49 @@
+-- ========================================
+-- Migration: 005_catalog_revision
+-- ========================================
+-- Description: Catalog revision counter for ETag / If-None-Match support and
+--              ?since= delta sync, plus tombstones for deleted micro apps
+-- ========================================
+
+-- ========================================
+-- TABLE: catalog_revision
+-- Description: Single-row, catalog-wide revision counter
+-- ========================================
+
+CREATE TABLE `catalog_revision` (
+  `id` INT NOT NULL COMMENT 'Always 1',
+  `revision` BIGINT NOT NULL DEFAULT 0 COMMENT 'Latest catalog revision',
+
+  PRIMARY KEY (`id`)
+) ENGINE=InnoDB
+  DEFAULT CHARSET=utf8mb4
+  COLLATE=utf8mb4_0900_ai_ci
+  COMMENT='Catalog-wide revision counter';
+
+INSERT INTO `catalog_revision` (`id`, `revision`) VALUES (1, 1);
+
+-- ========================================
+-- TABLE: catalog_tombstone
+-- Description: Permanently deleted micro apps, for delta sync clients
+-- ========================================
+
+CREATE TABLE `catalog_tombstone` (
+  `micro_app_id` VARCHAR(255) NOT NULL COMMENT 'Deleted micro app identifier',
+  `revision` BIGINT NOT NULL COMMENT 'Catalog revision of the delete',
+  `deleted_at` DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT 'Deletion timestamp',
+
+  PRIMARY KEY (`micro_app_id`),
+
+  INDEX `idx_catalog_tombstone_revision` (`revision`)
+) ENGINE=InnoDB
+  DEFAULT CHARSET=utf8mb4
+  COLLATE=utf8mb4_0900_ai_ci
+  COMMENT='Deleted micro apps for delta sync';
+
+-- ========================================
+-- micro_app: revision of the last change to the app or its children
+-- ========================================
+
+ALTER TABLE `micro_app`
+  ADD COLUMN `revision` BIGINT NOT NULL DEFAULT 1 COMMENT 'Catalog revision of the last change to the app or its children' AFTER `sort_weight`,
+  ADD INDEX `idx_micro_app_revision` (`revision`);
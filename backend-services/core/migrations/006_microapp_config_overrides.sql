@@ -0,0 +1,37 @@
+-- ========================================
+-- Migration: 006_microapp_config_overrides
+-- ========================================
+-- Description: Config values overridden per user group, release channel or
+--              environment. The most specific matching override wins
+--              (group > channel > environment > base value).
+-- ========================================
+
+CREATE TABLE `micro_app_config_override` (
+  `id` BIGINT UNSIGNED NOT NULL AUTO_INCREMENT COMMENT 'Internal auto-increment ID',
+  `micro_app_id` VARCHAR(255) NOT NULL COMMENT 'Reference to micro_app.micro_app_id',
+  `config_key` VARCHAR(191) NOT NULL COMMENT 'Overridden micro_app_config.config_key',
+  `scope_type` ENUM('environment', 'channel', 'group') NOT NULL COMMENT 'What the override is scoped to',
+  `scope_value` VARCHAR(255) NOT NULL COMMENT 'Environment name, release channel or user group',
+  `config_value` JSON NOT NULL COMMENT 'Configuration value (JSON format)',
+  `priority` INT NOT NULL DEFAULT 0 COMMENT 'Tie-breaker between matching overrides of the same scope (higher wins)',
+  `active` TINYINT(1) NOT NULL DEFAULT 1 COMMENT 'Active status (1=active, 0=inactive, 2=suspended with app)',
+  `created_by` VARCHAR(319) NOT NULL COMMENT 'Email of creator',
+  `updated_by` VARCHAR(319) DEFAULT NULL COMMENT 'Email of last updater',
+  `created_at` DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT 'Creation timestamp',
+  `updated_at` DATETIME DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP COMMENT 'Last update timestamp',
+
+  PRIMARY KEY (`id`),
+  UNIQUE KEY `uq_maco_scope` (`micro_app_id`, `config_key`, `scope_type`, `scope_value`),
+
+  INDEX `idx_maco_app_active` (`micro_app_id`, `active`),
+
+  CONSTRAINT `fk_maco_micro_app`
+    FOREIGN KEY (`micro_app_id`)
+    REFERENCES `micro_app` (`micro_app_id`)
+    ON DELETE CASCADE
+    ON UPDATE CASCADE
+) ENGINE=InnoDB
+  AUTO_INCREMENT=1
+  DEFAULT CHARSET=utf8mb4
+  COLLATE=utf8mb4_0900_ai_ci
+  COMMENT='Scoped overrides of micro app configuration';
@@ -0,0 +1,29 @@
+-- ========================================
+-- Migration: 007_microapp_config_schemas
+-- ========================================
+-- Description: JSON Schema per micro app config key. Config values and
+--              overrides are validated against it on upsert, and the admin
+--              portal renders a form from it.
+-- ========================================
+
+CREATE TABLE `micro_app_config_schema` (
+  `micro_app_id` VARCHAR(255) NOT NULL COMMENT 'Reference to micro_app.micro_app_id',
+  `config_key` VARCHAR(255) NOT NULL COMMENT 'Config key the schema applies to',
+  `schema` JSON NOT NULL COMMENT 'JSON Schema document',
+  `active` TINYINT(1) NOT NULL DEFAULT 1 COMMENT 'Active status (1=active, 0=inactive)',
+  `created_by` VARCHAR(319) NOT NULL COMMENT 'Email of creator',
+  `updated_by` VARCHAR(319) DEFAULT NULL COMMENT 'Email of last updater',
+  `created_at` DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT 'Creation timestamp',
+  `updated_at` DATETIME DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP COMMENT 'Last update timestamp',
+
+  PRIMARY KEY (`micro_app_id`, `config_key`),
+
+  CONSTRAINT `fk_macs_micro_app`
+    FOREIGN KEY (`micro_app_id`)
+    REFERENCES `micro_app` (`micro_app_id`)
+    ON DELETE CASCADE
+    ON UPDATE CASCADE
+) ENGINE=InnoDB
+  DEFAULT CHARSET=utf8mb4
+  COLLATE=utf8mb4_0900_ai_ci
+  COMMENT='JSON Schemas for micro app config values';
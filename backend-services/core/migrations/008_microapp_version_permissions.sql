@@ -0,0 +1,9 @@
+-- ========================================
+-- Migration: 008_microapp_version_permissions
+-- ========================================
+-- Description: Bridge permissions declared in a published bundle's
+--              manifest.json
+-- ========================================
+
+ALTER TABLE `micro_app_version`
+  ADD COLUMN `required_permissions` JSON DEFAULT NULL COMMENT 'Bridge capabilities declared in manifest.json' AFTER `download_url`;
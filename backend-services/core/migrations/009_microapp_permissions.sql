@@ -0,0 +1,31 @@
+-- ========================================
+-- Migration: 009_microapp_permissions
+-- ========================================
+-- Description: Bridge capabilities requested by micro apps and the admin
+--              decision on each. Approved capabilities are returned with the
+--              catalog so the host app can enforce them.
+-- ========================================
+
+CREATE TABLE `micro_app_permission` (
+  `micro_app_id` VARCHAR(255) NOT NULL COMMENT 'Reference to micro_app.micro_app_id',
+  `capability` VARCHAR(64) NOT NULL COMMENT 'Bridge capability, e.g. qr_scanner, google_drive',
+  `status` ENUM('requested', 'approved', 'rejected') NOT NULL DEFAULT 'requested' COMMENT 'Review status',
+  `reviewed_by` VARCHAR(319) DEFAULT NULL COMMENT 'Email of the reviewing admin',
+  `reviewed_at` DATETIME DEFAULT NULL COMMENT 'Review timestamp',
+  `created_by` VARCHAR(319) NOT NULL COMMENT 'Email of the publisher that requested it',
+  `created_at` DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT 'Creation timestamp',
+  `updated_at` DATETIME DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP COMMENT 'Last update timestamp',
+
+  PRIMARY KEY (`micro_app_id`, `capability`),
+
+  INDEX `idx_map_status` (`micro_app_id`, `status`),
+
+  CONSTRAINT `fk_map_micro_app`
+    FOREIGN KEY (`micro_app_id`)
+    REFERENCES `micro_app` (`micro_app_id`)
+    ON DELETE CASCADE
+    ON UPDATE CASCADE
+) ENGINE=InnoDB
+  DEFAULT CHARSET=utf8mb4
+  COLLATE=utf8mb4_0900_ai_ci
+  COMMENT='Bridge capability permissions per micro app';
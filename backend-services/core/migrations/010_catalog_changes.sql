@@ -0,0 +1,91 @@
+-- ========================================
+-- Migration: 010_catalog_changes
+-- ========================================
+-- Description: Draft, review and publish (maker-checker) workflow for micro
+--              app changes, with an audit trail and snapshots of every
+--              published state for rollback
+-- ========================================
+
+-- ========================================
+-- TABLE: catalog_change
+-- Description: A pending micro app change and its review state
+-- ========================================
+
+CREATE TABLE `catalog_change` (
+  `id` BIGINT UNSIGNED NOT NULL AUTO_INCREMENT COMMENT 'Internal auto-increment ID',
+  `micro_app_id` VARCHAR(255) NOT NULL COMMENT 'Micro app the change applies to (may not exist yet)',
+  `status` ENUM('draft', 'submitted', 'approved', 'rejected', 'published') NOT NULL DEFAULT 'draft' COMMENT 'Workflow state',
+  `payload` JSON NOT NULL COMMENT 'Micro app upsert request applied on publish',
+  `replace_existing` TINYINT(1) NOT NULL DEFAULT 0 COMMENT 'Publish the payload as the complete app state',
+  `source_snapshot_id` BIGINT UNSIGNED DEFAULT NULL COMMENT 'Snapshot this change restores, if any',
+  `created_by` VARCHAR(319) NOT NULL COMMENT 'Email of the author',
+  `updated_by` VARCHAR(319) DEFAULT NULL COMMENT 'Email of the last actor',
+  `reviewed_by` VARCHAR(319) DEFAULT NULL COMMENT 'Email of the approving or rejecting admin',
+  `reviewed_at` DATETIME DEFAULT NULL COMMENT 'Review timestamp',
+  `published_by` VARCHAR(319) DEFAULT NULL COMMENT 'Email of the publishing admin',
+  `published_at` DATETIME DEFAULT NULL COMMENT 'Publish timestamp',
+  `created_at` DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT 'Creation timestamp',
+  `updated_at` DATETIME DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP COMMENT 'Last update timestamp',
+
+  PRIMARY KEY (`id`),
+
+  INDEX `idx_cc_app` (`micro_app_id`),
+  INDEX `idx_cc_status` (`status`)
+) ENGINE=InnoDB
+  AUTO_INCREMENT=1
+  DEFAULT CHARSET=utf8mb4
+  COLLATE=utf8mb4_0900_ai_ci
+  COMMENT='Micro app changes awaiting review and publication';
+
+-- ========================================
+-- TABLE: catalog_change_event
+-- Description: Audit trail of every workflow step
+-- ========================================
+
+CREATE TABLE `catalog_change_event` (
+  `id` BIGINT UNSIGNED NOT NULL AUTO_INCREMENT COMMENT 'Internal auto-increment ID',
+  `change_id` BIGINT UNSIGNED NOT NULL COMMENT 'Reference to catalog_change.id',
+  `action` VARCHAR(32) NOT NULL COMMENT 'created, updated, submitted, approved, rejected or published',
+  `actor` VARCHAR(319) NOT NULL COMMENT 'Email of the admin who acted',
+  `comment` TEXT DEFAULT NULL COMMENT 'Optional comment',
+  `created_at` DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT 'When the step happened',
+
+  PRIMARY KEY (`id`),
+
+  INDEX `idx_cce_change` (`change_id`),
+
+  CONSTRAINT `fk_cce_change`
+    FOREIGN KEY (`change_id`)
+    REFERENCES `catalog_change` (`id`)
+    ON DELETE CASCADE
+) ENGINE=InnoDB
+  AUTO_INCREMENT=1
+  DEFAULT CHARSET=utf8mb4
+  COLLATE=utf8mb4_0900_ai_ci
+  COMMENT='Audit trail of catalog changes';
+
+-- ========================================
+-- TABLE: catalog_snapshot
+-- Description: Complete micro app state after each publish
+-- ========================================
+
+CREATE TABLE `catalog_snapshot` (
+  `id` BIGINT UNSIGNED NOT NULL AUTO_INCREMENT COMMENT 'Internal auto-increment ID',
+  `micro_app_id` VARCHAR(255) NOT NULL COMMENT 'Snapshotted micro app',
+  `change_id` BIGINT UNSIGNED NOT NULL COMMENT 'Change whose publication produced this state',
+  `snapshot` JSON NOT NULL COMMENT 'Micro app state in upsert request form',
+  `created_by` VARCHAR(319) NOT NULL COMMENT 'Email of the publishing admin',
+  `created_at` DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT 'Publish timestamp',
+
+  PRIMARY KEY (`id`),
+
+  INDEX `idx_cs_app` (`micro_app_id`),
+
+  CONSTRAINT `fk_cs_change`
+    FOREIGN KEY (`change_id`)
+    REFERENCES `catalog_change` (`id`)
+) ENGINE=InnoDB
+  AUTO_INCREMENT=1
+  DEFAULT CHARSET=utf8mb4
+  COLLATE=utf8mb4_0900_ai_ci
+  COMMENT='Published micro app states for rollback';
@@ -0,0 +1,43 @@
+-- ========================================
+-- Migration: 011_catalog_schedule
+-- ========================================
+-- Description: Scheduled publishing and sunset dates for micro apps and
+--              versions, plus the group memberships the catalog scheduler
+--              uses to notify affected users
+-- ========================================
+
+ALTER TABLE `micro_app`
+  ADD COLUMN `publish_at` DATETIME DEFAULT NULL COMMENT 'Not offered before this time',
+  ADD COLUMN `expire_at` DATETIME DEFAULT NULL COMMENT 'Not offered from this time on',
+  ADD COLUMN `notify_on_publish` TINYINT(1) NOT NULL DEFAULT 0 COMMENT 'Push a notification when publish_at passes',
+  ADD COLUMN `publish_handled_at` DATETIME DEFAULT NULL COMMENT 'When the scheduler applied publish_at',
+  ADD COLUMN `expire_handled_at` DATETIME DEFAULT NULL COMMENT 'When the scheduler applied expire_at',
+  ADD INDEX `idx_ma_publish_at` (`publish_at`),
+  ADD INDEX `idx_ma_expire_at` (`expire_at`);
+
+ALTER TABLE `micro_app_version`
+  ADD COLUMN `publish_at` DATETIME DEFAULT NULL COMMENT 'Not offered before this time',
+  ADD COLUMN `expire_at` DATETIME DEFAULT NULL COMMENT 'Not offered from this time on',
+  ADD COLUMN `notify_on_publish` TINYINT(1) NOT NULL DEFAULT 0 COMMENT 'Push a notification when publish_at passes',
+  ADD COLUMN `publish_handled_at` DATETIME DEFAULT NULL COMMENT 'When the scheduler applied publish_at',
+  ADD COLUMN `expire_handled_at` DATETIME DEFAULT NULL COMMENT 'When the scheduler applied expire_at',
+  ADD INDEX `idx_mav_publish_at` (`publish_at`),
+  ADD INDEX `idx_mav_expire_at` (`expire_at`);
+
+-- ========================================
+-- TABLE: user_groups
+-- Description: Groups of each user as of their last catalog fetch
+-- ========================================
+
+CREATE TABLE `user_groups` (
+  `user_email` VARCHAR(319) NOT NULL COMMENT 'User email',
+  `group_name` VARCHAR(255) NOT NULL COMMENT 'Group from the identity provider token',
+  `last_seen_at` DATETIME NOT NULL COMMENT 'Last catalog fetch with this group',
+
+  PRIMARY KEY (`user_email`, `group_name`),
+
+  INDEX `idx_ug_group` (`group_name`)
+) ENGINE=InnoDB
+  DEFAULT CHARSET=utf8mb4
+  COLLATE=utf8mb4_0900_ai_ci
+  COMMENT='User group memberships seen by the catalog';
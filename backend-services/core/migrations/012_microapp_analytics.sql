@@ -0,0 +1,75 @@
+-- ========================================
+-- Migration: 012_microapp_analytics
+-- ========================================
+-- Description: Raw micro app usage events posted by the host app and the
+--              daily rollups the usage reports are served from
+-- ========================================
+
+-- ========================================
+-- TABLE: analytics_event
+-- Description: Raw launch, session and error events (purged after the
+--              retention period)
+-- ========================================
+
+CREATE TABLE `analytics_event` (
+  `id` BIGINT NOT NULL AUTO_INCREMENT COMMENT 'Primary key',
+  `micro_app_id` VARCHAR(255) NOT NULL COMMENT 'Micro app the event belongs to',
+  `user_email` VARCHAR(319) NOT NULL COMMENT 'User that reported the event',
+  `event_type` ENUM('launch','session','error') NOT NULL COMMENT 'Event type',
+  `version` VARCHAR(32) NOT NULL DEFAULT '' COMMENT 'Micro app version in use, if reported',
+  `duration_ms` BIGINT DEFAULT NULL COMMENT 'Session length (session events)',
+  `error_message` VARCHAR(1024) DEFAULT NULL COMMENT 'Error description (error events)',
+  `occurred_at` DATETIME NOT NULL COMMENT 'When the event happened on the device (UTC)',
+  `received_at` DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT 'When the backend received the event',
+
+  PRIMARY KEY (`id`),
+
+  INDEX `idx_ae_occurred_at` (`occurred_at`)
+) ENGINE=InnoDB
+  DEFAULT CHARSET=utf8mb4
+  COLLATE=utf8mb4_0900_ai_ci
+  COMMENT='Raw micro app usage events';
+
+-- ========================================
+-- TABLE: analytics_daily_app
+-- Description: Event counts per micro app version and UTC day
+-- ========================================
+
+CREATE TABLE `analytics_daily_app` (
+  `day` DATE NOT NULL COMMENT 'UTC day',
+  `micro_app_id` VARCHAR(255) NOT NULL COMMENT 'Micro app',
+  `version` VARCHAR(32) NOT NULL COMMENT 'Micro app version (empty if not reported)',
+  `launches` BIGINT NOT NULL DEFAULT 0 COMMENT 'Launch events',
+  `sessions` BIGINT NOT NULL DEFAULT 0 COMMENT 'Session events',
+  `session_ms` BIGINT NOT NULL DEFAULT 0 COMMENT 'Total session length',
+  `errors` BIGINT NOT NULL DEFAULT 0 COMMENT 'Error events',
+  `active_users` BIGINT NOT NULL DEFAULT 0 COMMENT 'Distinct users',
+
+  PRIMARY KEY (`day`, `micro_app_id`, `version`),
+
+  INDEX `idx_ada_app_day` (`micro_app_id`, `day`)
+) ENGINE=InnoDB
+  DEFAULT CHARSET=utf8mb4
+  COLLATE=utf8mb4_0900_ai_ci
+  COMMENT='Daily micro app usage counts';
+
+-- ========================================
+-- TABLE: analytics_daily_user
+-- Description: Users active per micro app version and UTC day, used for
+--              distinct user metrics (DAU, MAU)
+-- ========================================
+
+CREATE TABLE `analytics_daily_user` (
+  `day` DATE NOT NULL COMMENT 'UTC day',
+  `micro_app_id` VARCHAR(255) NOT NULL COMMENT 'Micro app',
+  `version` VARCHAR(32) NOT NULL COMMENT 'Micro app version (empty if not reported)',
+  `user_email` VARCHAR(319) NOT NULL COMMENT 'Active user',
+
+  PRIMARY KEY (`day`, `micro_app_id`, `version`, `user_email`),
+
+  INDEX `idx_adu_app_day` (`micro_app_id`, `day`),
+  INDEX `idx_adu_user` (`user_email`)
+) ENGINE=InnoDB
+  DEFAULT CHARSET=utf8mb4
+  COLLATE=utf8mb4_0900_ai_ci
+  COMMENT='Daily active micro app users';
@@ -0,0 +1,29 @@
+-- ========================================
+-- Migration: 013_user_micro_app_library
+-- ========================================
+-- Description: Per-user micro app library (installed, pinned, hidden and
+--              home screen order), so it survives a device change
+-- ========================================
+
+-- ========================================
+-- TABLE: user_micro_app
+-- Description: A user's library entry for a micro app
+-- ========================================
+
+CREATE TABLE `user_micro_app` (
+  `user_email` VARCHAR(319) NOT NULL COMMENT 'Library owner',
+  `micro_app_id` VARCHAR(255) NOT NULL COMMENT 'Micro app',
+  `installed` TINYINT(1) NOT NULL DEFAULT 0 COMMENT 'Installed by the user (mandatory apps always are)',
+  `pinned` TINYINT(1) NOT NULL DEFAULT 0 COMMENT 'Pinned to the top of the home screen',
+  `hidden` TINYINT(1) NOT NULL DEFAULT 0 COMMENT 'Hidden from the home screen',
+  `sort_order` INT NOT NULL DEFAULT 0 COMMENT 'Home screen position (ascending)',
+  `created_at` DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT 'Creation timestamp',
+  `updated_at` DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP COMMENT 'Last update timestamp',
+
+  PRIMARY KEY (`user_email`, `micro_app_id`),
+
+  INDEX `idx_uma_micro_app` (`micro_app_id`)
+) ENGINE=InnoDB
+  DEFAULT CHARSET=utf8mb4
+  COLLATE=utf8mb4_0900_ai_ci
+  COMMENT='Per-user micro app library';
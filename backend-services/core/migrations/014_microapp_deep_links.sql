@@ -0,0 +1,31 @@
+-- ========================================
+-- Migration: 014_microapp_deep_links
+-- ========================================
+-- Description: URL patterns registered by micro apps, used to resolve links
+--              in emails and notifications to a micro app screen
+-- ========================================
+
+-- ========================================
+-- TABLE: micro_app_deep_link
+-- Description: Deep link patterns and the in-app path they open
+-- ========================================
+
+CREATE TABLE `micro_app_deep_link` (
+  `id` INT NOT NULL AUTO_INCREMENT COMMENT 'Primary key',
+  `micro_app_id` VARCHAR(255) NOT NULL COMMENT 'Micro app the links open',
+  `pattern` VARCHAR(512) NOT NULL COMMENT 'URL path pattern, e.g. /leave/requests/{id}',
+  `target_path` VARCHAR(1024) DEFAULT NULL COMMENT 'In-app path template (defaults to the link path)',
+  `active` TINYINT(1) NOT NULL DEFAULT 1 COMMENT 'Active status (1=active, 0=inactive)',
+  `created_by` VARCHAR(319) NOT NULL COMMENT 'Creator email',
+  `updated_by` VARCHAR(319) DEFAULT NULL COMMENT 'Last updater email',
+  `created_at` DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT 'Creation timestamp',
+  `updated_at` DATETIME DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP COMMENT 'Last update timestamp',
+
+  PRIMARY KEY (`id`),
+
+  UNIQUE KEY `uk_madl_pattern` (`pattern`),
+  INDEX `idx_madl_micro_app` (`micro_app_id`)
+) ENGINE=InnoDB
+  DEFAULT CHARSET=utf8mb4
+  COLLATE=utf8mb4_0900_ai_ci
+  COMMENT='Micro app deep link patterns';
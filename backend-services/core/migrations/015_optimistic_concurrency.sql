@@ -0,0 +1,34 @@
+-- ========================================
+-- Migration: 015_optimistic_concurrency
+-- ========================================
+-- Description: Row versions exposed as ETags for If-Match conditional writes,
+--              and the unique key device token upserts rely on
+-- ========================================
+
+-- ========================================
+-- micro_app: version bumped by every change to the app or its children
+-- ========================================
+
+ALTER TABLE `micro_app`
+  ADD COLUMN `version` BIGINT NOT NULL DEFAULT 1 COMMENT 'Row version for optimistic concurrency (ETag)' AFTER `revision`;
+
+-- ========================================
+-- user_config: version bumped by every write
+-- ========================================
+
+ALTER TABLE `user_config`
+  ADD COLUMN `version` BIGINT NOT NULL DEFAULT 1 COMMENT 'Row version for optimistic concurrency (ETag)' AFTER `active`;
+
+-- ========================================
+-- device_tokens: one token per user and platform
+-- ========================================
+
+-- Keep only the latest token of any duplicates left by racing registrations
+DELETE t1 FROM `device_tokens` t1
+  JOIN `device_tokens` t2
+    ON t1.`user_email` = t2.`user_email`
+   AND t1.`platform` = t2.`platform`
+   AND t1.`id` < t2.`id`;
+
+ALTER TABLE `device_tokens`
+  ADD UNIQUE KEY `uq_device_tokens_user_platform` (`user_email`, `platform`);
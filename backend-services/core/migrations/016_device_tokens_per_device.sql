@@ -0,0 +1,28 @@
+-- ========================================
+-- Migration: 016_device_tokens_per_device
+-- ========================================
+-- Description: Device tokens keyed by a client-generated device ID, so a user
+--              receives pushes on every device they signed in on
+-- ========================================
+
+-- ========================================
+-- device_tokens: one token per user and device
+-- ========================================
+
+ALTER TABLE `device_tokens`
+  ADD COLUMN `device_id` VARCHAR(255) DEFAULT NULL COMMENT 'Client-generated app install ID' AFTER `user_email`,
+  ADD COLUMN `device_name` VARCHAR(255) DEFAULT NULL COMMENT 'Device name shown to the user' AFTER `platform`,
+  ADD COLUMN `app_version` VARCHAR(50) DEFAULT NULL COMMENT 'Super app version on the device' AFTER `device_name`,
+  ADD COLUMN `last_seen_at` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT 'Last time the device registered its token' AFTER `app_version`;
+
+-- Existing tokens were one per user and platform; clients that do not send a
+-- device ID keep using these legacy IDs
+UPDATE `device_tokens`
+  SET `device_id` = CONCAT('legacy-', `platform`),
+      `last_seen_at` = `updated_at`;
+
+ALTER TABLE `device_tokens`
+  MODIFY COLUMN `device_id` VARCHAR(255) NOT NULL COMMENT 'Client-generated app install ID',
+  DROP INDEX `uq_device_tokens_user_platform`,
+  ADD UNIQUE KEY `uq_device_tokens_user_device` (`user_email`, `device_id`),
+  ADD INDEX `idx_device_tokens_token` (`device_token`(255));
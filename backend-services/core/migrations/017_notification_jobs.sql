@@ -0,0 +1,37 @@
+-- ========================================
+-- Migration: 017_notification_jobs
+-- ========================================
+-- Description: Durable queue of notification sends, processed by the
+--              notification worker with retries and dead-lettering
+-- ========================================
+
+-- ========================================
+-- TABLE: notification_jobs
+-- Description: Queued notification sends and their outcome
+-- ========================================
+
+CREATE TABLE `notification_jobs` (
+  `id` BIGINT NOT NULL AUTO_INCREMENT COMMENT 'Job ID returned to the caller',
+  `microapp_id` VARCHAR(255) NOT NULL COMMENT 'Micro app (service client) that queued the notification',
+  `status` ENUM('queued', 'processing', 'retrying', 'succeeded', 'failed') NOT NULL DEFAULT 'queued' COMMENT 'Job status; failed jobs are dead-lettered',
+  `payload` JSON NOT NULL COMMENT 'Audience (users or groups) and message',
+  `attempts` INT NOT NULL DEFAULT 0 COMMENT 'Send attempts started',
+  `next_attempt_at` DATETIME NOT NULL COMMENT 'Earliest time of the next attempt',
+  `locked_until` DATETIME DEFAULT NULL COMMENT 'Lease of the worker processing the job',
+  `last_error` TEXT COMMENT 'Error of the last failed attempt',
+  `recipients` INT NOT NULL DEFAULT 0 COMMENT 'Distinct users the notification was addressed to',
+  `success_count` INT NOT NULL DEFAULT 0 COMMENT 'Devices the notification was delivered to',
+  `failure_count` INT NOT NULL DEFAULT 0 COMMENT 'Devices the notification could not be delivered to',
+  `deactivated_count` INT NOT NULL DEFAULT 0 COMMENT 'Invalid device tokens deactivated',
+  `created_at` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT 'Queue timestamp',
+  `updated_at` TIMESTAMP NULL DEFAULT NULL ON UPDATE CURRENT_TIMESTAMP COMMENT 'Last update timestamp',
+  `completed_at` DATETIME DEFAULT NULL COMMENT 'When the job succeeded or was dead-lettered',
+
+  PRIMARY KEY (`id`),
+
+  INDEX `idx_notification_jobs_microapp` (`microapp_id`),
+  INDEX `idx_notification_jobs_due` (`status`, `next_attempt_at`)
+) ENGINE=InnoDB
+  DEFAULT CHARSET=utf8mb4
+  COLLATE=utf8mb4_0900_ai_ci
+  COMMENT='Notification send queue';
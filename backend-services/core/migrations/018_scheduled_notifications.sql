@@ -0,0 +1,16 @@
+-- ========================================
+-- Migration: 018_scheduled_notifications
+-- ========================================
+-- Description: Notifications queued for a later send time, which can be
+--              listed and cancelled until they are sent
+-- ========================================
+
+-- ========================================
+-- notification_jobs: send time and cancellation
+-- ========================================
+
+ALTER TABLE `notification_jobs`
+  MODIFY COLUMN `status` ENUM('queued', 'processing', 'retrying', 'succeeded', 'failed', 'cancelled') NOT NULL DEFAULT 'queued' COMMENT 'Job status; failed jobs are dead-lettered',
+  ADD COLUMN `send_at` DATETIME DEFAULT NULL COMMENT 'Requested send time (UTC) of a scheduled notification' AFTER `payload`,
+  ADD COLUMN `time_zone` VARCHAR(64) DEFAULT NULL COMMENT 'Time zone the send time was given in' AFTER `send_at`,
+  ADD INDEX `idx_notification_jobs_scheduled` (`microapp_id`, `status`, `send_at`);
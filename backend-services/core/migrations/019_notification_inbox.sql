@@ -0,0 +1,15 @@
+-- ========================================
+-- Migration: 019_notification_inbox
+-- ========================================
+-- Description: Read and deleted state of notification log rows, which back
+--              the user-facing notification inbox
+-- ========================================
+
+-- ========================================
+-- notification_logs: inbox state
+-- ========================================
+
+ALTER TABLE `notification_logs`
+  ADD COLUMN `read_at` TIMESTAMP NULL DEFAULT NULL COMMENT 'When the user read the notification' AFTER `microapp_id`,
+  ADD COLUMN `deleted_at` TIMESTAMP NULL DEFAULT NULL COMMENT 'When the user removed the notification from their inbox' AFTER `read_at`,
+  ADD INDEX `idx_notification_logs_inbox` (`user_email`, `deleted_at`, `read_at`);
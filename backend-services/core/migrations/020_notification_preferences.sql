@@ -0,0 +1,53 @@
+-- ========================================
+-- Migration: 020_notification_preferences
+-- ========================================
+-- Description: Per-user push preferences (quiet hours, critical-only mode and
+--              micro app mutes), applied by the notification worker
+-- ========================================
+
+-- ========================================
+-- TABLE: user_notification_preference
+-- Description: A user's quiet hours and critical-only mode
+-- ========================================
+
+CREATE TABLE `user_notification_preference` (
+  `user_email` VARCHAR(319) NOT NULL COMMENT 'User email address',
+  `quiet_hours_start` CHAR(5) DEFAULT NULL COMMENT 'Start of quiet hours (HH:MM in time_zone)',
+  `quiet_hours_end` CHAR(5) DEFAULT NULL COMMENT 'End of quiet hours (HH:MM in time_zone)',
+  `time_zone` VARCHAR(64) DEFAULT NULL COMMENT 'IANA time zone of quiet hours (UTC when NULL)',
+  `critical_only` TINYINT(1) NOT NULL DEFAULT 0 COMMENT 'Only push critical messages',
+  `created_at` DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT 'Creation timestamp',
+  `updated_at` DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP COMMENT 'Last update timestamp',
+
+  PRIMARY KEY (`user_email`)
+) ENGINE=InnoDB
+  DEFAULT CHARSET=utf8mb4
+  COLLATE=utf8mb4_0900_ai_ci
+  COMMENT='Per-user push notification preferences';
+
+-- ========================================
+-- TABLE: user_notification_mute
+-- Description: Micro apps whose pushes a user muted
+-- ========================================
+
+CREATE TABLE `user_notification_mute` (
+  `user_email` VARCHAR(319) NOT NULL COMMENT 'User email address',
+  `micro_app_id` VARCHAR(255) NOT NULL COMMENT 'Muted micro app',
+  `muted_until` DATETIME DEFAULT NULL COMMENT 'End of the mute (NULL mutes for good)',
+  `created_at` DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT 'Creation timestamp',
+
+  PRIMARY KEY (`user_email`, `micro_app_id`),
+
+  INDEX `idx_unm_micro_app` (`micro_app_id`)
+) ENGINE=InnoDB
+  DEFAULT CHARSET=utf8mb4
+  COLLATE=utf8mb4_0900_ai_ci
+  COMMENT='Per-user micro app notification mutes';
+
+-- ========================================
+-- notification_jobs: recipients taken out by their preferences
+-- ========================================
+
+ALTER TABLE `notification_jobs`
+  ADD COLUMN `suppressed_count` INT NOT NULL DEFAULT 0 COMMENT 'Recipients whose preferences suppressed the push' AFTER `deactivated_count`,
+  ADD COLUMN `deferred_count` INT NOT NULL DEFAULT 0 COMMENT 'Recipients the push was deferred for until their quiet hours end' AFTER `suppressed_count`;
@@ -0,0 +1,63 @@
+-- ========================================
+-- Migration: 021_notification_quotas
+-- ========================================
+-- Description: Per micro app notification quotas (send requests per minute,
+--              notifications per day and per recipient per day) and the usage
+--              counters they are enforced against
+-- ========================================
+
+-- ========================================
+-- TABLE: notification_quotas
+-- Description: Admin overrides of the default send limits of a micro app
+-- ========================================
+
+CREATE TABLE `notification_quotas` (
+  `microapp_id` VARCHAR(255) NOT NULL COMMENT 'Micro app the limits apply to',
+  `per_minute` INT DEFAULT NULL COMMENT 'Send requests per minute (NULL uses the default, 0 lifts the limit)',
+  `per_day` INT DEFAULT NULL COMMENT 'Notifications per UTC day (NULL uses the default, 0 lifts the limit)',
+  `per_recipient_per_day` INT DEFAULT NULL COMMENT 'Notifications to one user per UTC day (NULL uses the default, 0 lifts the limit)',
+  `updated_by` VARCHAR(319) NOT NULL COMMENT 'Admin who last changed the limits',
+  `created_at` DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT 'Creation timestamp',
+  `updated_at` DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP COMMENT 'Last update timestamp',
+
+  PRIMARY KEY (`microapp_id`)
+) ENGINE=InnoDB
+  DEFAULT CHARSET=utf8mb4
+  COLLATE=utf8mb4_0900_ai_ci
+  COMMENT='Per micro app notification limit overrides';
+
+-- ========================================
+-- TABLE: notification_quota_usage
+-- Description: What a micro app sent in the current window of each period
+-- ========================================
+
+CREATE TABLE `notification_quota_usage` (
+  `microapp_id` VARCHAR(255) NOT NULL COMMENT 'Sending micro app',
+  `period` ENUM('minute','day') NOT NULL COMMENT 'Quota period',
+  `window_start` DATETIME NOT NULL COMMENT 'Start of the current window (UTC)',
+  `used` INT NOT NULL DEFAULT 0 COMMENT 'Send requests (minute) or notifications (day) in the window',
+
+  PRIMARY KEY (`microapp_id`, `period`)
+) ENGINE=InnoDB
+  DEFAULT CHARSET=utf8mb4
+  COLLATE=utf8mb4_0900_ai_ci
+  COMMENT='Notification quota usage per micro app';
+
+-- ========================================
+-- TABLE: notification_recipient_usage
+-- Description: Notifications a micro app sent each user on the current day
+-- ========================================
+
+CREATE TABLE `notification_recipient_usage` (
+  `microapp_id` VARCHAR(255) NOT NULL COMMENT 'Sending micro app',
+  `user_email` VARCHAR(319) NOT NULL COMMENT 'Recipient email address',
+  `day` DATE NOT NULL COMMENT 'UTC day the count applies to',
+  `used` INT NOT NULL DEFAULT 0 COMMENT 'Notifications sent to the user on the day',
+
+  PRIMARY KEY (`microapp_id`, `user_email`),
+
+  INDEX `idx_nru_microapp_day` (`microapp_id`, `day`)
+) ENGINE=InnoDB
+  DEFAULT CHARSET=utf8mb4
+  COLLATE=utf8mb4_0900_ai_ci
+  COMMENT='Notification quota usage per micro app and recipient';
@@ -0,0 +1,58 @@
+-- ========================================
+-- Migration: 022_notification_templates
+-- ========================================
+-- Description: Localised notification templates of micro apps, and the
+--              language users want templated notifications in
+-- ========================================
+
+-- ========================================
+-- TABLE: notification_templates
+-- Description: Notification messages a micro app registered under a key
+-- ========================================
+
+CREATE TABLE `notification_templates` (
+  `id` BIGINT NOT NULL AUTO_INCREMENT COMMENT 'Template ID',
+  `microapp_id` VARCHAR(255) NOT NULL COMMENT 'Micro app owning the template',
+  `template_key` VARCHAR(100) NOT NULL COMMENT 'Key send requests refer to the template by',
+  `default_locale` VARCHAR(35) NOT NULL COMMENT 'Locale used when no translation matches the recipient (BCP 47)',
+  `created_by` VARCHAR(319) NOT NULL COMMENT 'Admin email or micro app that created the template',
+  `updated_by` VARCHAR(319) DEFAULT NULL COMMENT 'Admin email or micro app that last changed the template',
+  `created_at` DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT 'Creation timestamp',
+  `updated_at` DATETIME NULL DEFAULT NULL ON UPDATE CURRENT_TIMESTAMP COMMENT 'Last update timestamp',
+
+  PRIMARY KEY (`id`),
+
+  UNIQUE KEY `uq_notification_template_key` (`microapp_id`, `template_key`)
+) ENGINE=InnoDB
+  DEFAULT CHARSET=utf8mb4
+  COLLATE=utf8mb4_0900_ai_ci
+  COMMENT='Notification templates of micro apps';
+
+-- ========================================
+-- TABLE: notification_template_translations
+-- Description: The copy of a template in each locale
+-- ========================================
+
+CREATE TABLE `notification_template_translations` (
+  `template_id` BIGINT NOT NULL COMMENT 'Template the copy belongs to',
+  `locale` VARCHAR(35) NOT NULL COMMENT 'Locale of the copy (BCP 47)',
+  `title` VARCHAR(255) NOT NULL COMMENT 'Title, may hold {{placeholders}}',
+  `body` TEXT NOT NULL COMMENT 'Body, may hold {{placeholders}}',
+
+  PRIMARY KEY (`template_id`, `locale`),
+
+  CONSTRAINT `fk_ntt_template`
+    FOREIGN KEY (`template_id`)
+    REFERENCES `notification_templates` (`id`)
+    ON DELETE CASCADE
+) ENGINE=InnoDB
+  DEFAULT CHARSET=utf8mb4
+  COLLATE=utf8mb4_0900_ai_ci
+  COMMENT='Localised copy of notification templates';
+
+-- ========================================
+-- user_notification_preference: language of templated notifications
+-- ========================================
+
+ALTER TABLE `user_notification_preference`
+  ADD COLUMN `locale` VARCHAR(35) DEFAULT NULL COMMENT 'Preferred language of templated notifications (BCP 47)' AFTER `critical_only`;
@@ -0,0 +1,12 @@
+-- ========================================
+-- Migration: 023_catalog_tombstone_roles
+-- ========================================
+-- Description: Groups a deleted micro app had roles for, so delta sync only
+--              reports the delete to users who could have seen the app.
+--              Tombstones written before this migration have no roles and
+--              are no longer reported; affected clients catch up on their
+--              next full sync.
+-- ========================================
+
+ALTER TABLE `catalog_tombstone`
+  ADD COLUMN `roles` JSON DEFAULT NULL COMMENT 'Groups the micro app had roles for when it was deleted' AFTER `revision`;
@@ -0,0 +1,11 @@
+-- ========================================
+-- Migration: 024_catalog_change_base_version
+-- ========================================
+-- Description: Row version of the live micro app a catalog change was drafted
+--              against, so publishing cannot overwrite later live edits.
+--              Changes drafted before this migration have no base version
+--              and are published without the check.
+-- ========================================
+
+ALTER TABLE `catalog_change`
+  ADD COLUMN `base_version` BIGINT DEFAULT NULL COMMENT 'Row version of the live micro app the change was drafted against (0 if it did not exist)' AFTER `source_snapshot_id`;
@@ -0,0 +1,22 @@
+-- ========================================
+-- Migration: 025_micro_app_stored_files
+-- ========================================
+-- Description: Files the backend stored through the file service for a micro
+--              app (published bundles and icons). Permanently deleting an
+--              app only removes these files, never a file a version URL
+--              merely points at. Files stored before this migration are not
+--              recorded and are left in place.
+-- ========================================
+
+CREATE TABLE `micro_app_stored_file` (
+  `file_name` VARCHAR(255) NOT NULL COMMENT 'Name of the file in the file service',
+  `micro_app_id` VARCHAR(255) NOT NULL COMMENT 'Micro app the file was stored for',
+  `created_at` DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT 'Creation timestamp',
+
+  PRIMARY KEY (`file_name`),
+
+  INDEX `idx_masf_micro_app` (`micro_app_id`)
+) ENGINE=InnoDB
+  DEFAULT CHARSET=utf8mb4
+  COLLATE=utf8mb4_0900_ai_ci
+  COMMENT='Files stored for micro apps, removed with the app';
@@ -0,0 +1,11 @@
+-- ========================================
+-- Migration: 026_catalog_change_staged_files
+-- ========================================
+-- Description: Names of the files uploaded for a catalog change (the bundle
+--              and icon of a staged version). Rejecting the change deletes
+--              only these files. Changes staged before this migration have
+--              none recorded and keep their files when rejected.
+-- ========================================
+
+ALTER TABLE `catalog_change`
+  ADD COLUMN `staged_files` JSON DEFAULT NULL COMMENT 'Names of the files uploaded for the change, deleted if it is rejected' AFTER `base_version`;
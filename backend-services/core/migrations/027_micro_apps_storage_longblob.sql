@@ -0,0 +1,11 @@
+-- ========================================
+-- Migration: 027_micro_apps_storage_longblob
+-- ========================================
+-- Description: Widens the DB file service storage from MEDIUMBLOB (16MB) to
+--              LONGBLOB, so bundles up to the 50MB the publish endpoint
+--              accepts can be stored. The server's max_allowed_packet must
+--              also allow them (the MySQL 8 default of 64MB does).
+-- ========================================
+
+ALTER TABLE `micro_apps_storage`
+  MODIFY COLUMN `blob_content` LONGBLOB NOT NULL COMMENT 'Binary content of the file';
@@ -11,7 +11,7 @@ import (
 
 type MicroAppFile struct {
 	FileName    string `gorm:"column:file_name;primaryKey;type:varchar(255)"`
-	BlobContent []byte `gorm:"column:blob_content;type:mediumblob;not null"`
+	BlobContent []byte `gorm:"column:blob_content;type:longblob;not null"`
 }
 
 func (MicroAppFile) TableName() string {
@@ -0,0 +1,330 @@
+// Package apns is a notification service that sends straight to the Apple Push Notification
+// service over HTTP/2, authenticating with a token signing key (.p8) instead of going through
+// Firebase. Device tokens must then be raw APNs tokens registered by the iOS app.
+package apns
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	notificationservice "go-backend/plugins/notification-service"
+
+	"github.com/golang-jwt/jwt/v4"
+)
+
+const (
+	productionURL = "https://api.push.apple.com"
+	sandboxURL    = "https://api.sandbox.push.apple.com"
+
+	// Requests in flight at once; APNs multiplexes them over one HTTP/2 connection
+	maxConcurrentRequests = 20
+	requestTimeout        = 30 * time.Second
+
+	// APNs rejects provider tokens older than an hour and refreshing more often than every
+	// 20 minutes
+	providerTokenLifetime = 50 * time.Minute
+
+	// Most bytes read from an APNs error response
+	maxResponseSize = 64 << 10
+
+	// Error reasons of tokens that will never work again
+	reasonBadDeviceToken         = "BadDeviceToken"
+	reasonDeviceTokenNotForTopic = "DeviceTokenNotForTopic"
+	reasonUnregistered           = "Unregistered"
+	reasonExpiredProviderToken   = "ExpiredProviderToken"
+
+	// APNs only reaches Apple devices
+	platformIOS = "ios"
+)
+
+type APNSService struct {
+	client  *http.Client
+	baseURL string
+	topic   string
+	keyID   string
+	teamID  string
+	key     *ecdsa.PrivateKey
+
+	mu             sync.Mutex
+	providerToken  string
+	providerIssued time.Time
+}
+
+// apnsError is a rejected request, with the reason APNs gave
+type apnsError struct {
+	status int
+	reason string
+}
+
+func (e *apnsError) Error() string {
+	return fmt.Sprintf("apns: %d %s", e.status, e.reason)
+}
+
+func init() {
+	notificationservice.Registry.Register("apns", New)
+}
+
+// New creates the APNs notification service. NOTIFICATION_SERVICE_APNS_KEY_PATH (the .p8 key),
+// NOTIFICATION_SERVICE_APNS_KEY_ID, NOTIFICATION_SERVICE_APNS_TEAM_ID and
+// NOTIFICATION_SERVICE_APNS_TOPIC (the app's bundle ID) are required;
+// NOTIFICATION_SERVICE_APNS_ENVIRONMENT is production (default) or sandbox.
+func New(config map[string]any) (notificationservice.NotificationService, error) {
+	settings := make(map[string]string)
+	for _, name := range []string{"KEY_PATH", "KEY_ID", "TEAM_ID", "TOPIC"} {
+		key := "NOTIFICATION_SERVICE_APNS_" + name
+		value, ok := config[key].(string)
+		if !ok || value == "" {
+			return nil, fmt.Errorf("APNSService: %s is required", key)
+		}
+		settings[name] = value
+	}
+
+	baseURL := productionURL
+	switch environment, _ := config["NOTIFICATION_SERVICE_APNS_ENVIRONMENT"].(string); environment {
+	case "", "production":
+	case "sandbox":
+		baseURL = sandboxURL
+	default:
+		return nil, fmt.Errorf("APNSService: NOTIFICATION_SERVICE_APNS_ENVIRONMENT must be production or sandbox")
+	}
+
+	pem, err := os.ReadFile(settings["KEY_PATH"])
+	if err != nil {
+		return nil, fmt.Errorf("APNSService: failed to read signing key: %w", err)
+	}
+	key, err := jwt.ParseECPrivateKeyFromPEM(pem)
+	if err != nil {
+		return nil, fmt.Errorf("APNSService: invalid signing key: %w", err)
+	}
+
+	slog.Info("Initializing APNSService", "url", baseURL, "topic", settings["TOPIC"], "key_id", settings["KEY_ID"])
+	return &APNSService{
+		client: &http.Client{
+			Timeout: requestTimeout,
+			Transport: &http.Transport{
+				ForceAttemptHTTP2: true,
+				TLSClientConfig:   &tls.Config{MinVersion: tls.VersionTLS12},
+			},
+		},
+		baseURL: baseURL,
+		topic:   settings["TOPIC"],
+		keyID:   settings["KEY_ID"],
+		teamID:  settings["TEAM_ID"],
+		key:     key,
+	}, nil
+}
+
+// Platforms reports that only iOS device tokens can be sent through APNs
+func (s *APNSService) Platforms() []string {
+	return []string{platformIOS}
+}
+
+// SendNotificationToMultiple sends the notification to each device. APNs takes one device
+// per request, so requests go out concurrently.
+func (s *APNSService) SendNotificationToMultiple(
+	ctx context.Context,
+	tokens []string,
+	title string,
+	body string,
+	data map[string]string,
+) (*notificationservice.SendResult, error) {
+	result := &notificationservice.SendResult{}
+	if len(tokens) == 0 {
+		return result, nil
+	}
+
+	payload, err := buildPayload(title, body, data)
+	if err != nil {
+		return result, err
+	}
+
+	errs := make([]error, len(tokens))
+	sem := make(chan struct{}, maxConcurrentRequests)
+	var wg sync.WaitGroup
+	for i, token := range tokens {
+		sem <- struct{}{}
+		wg.Add(1)
+		go func() {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			errs[i] = s.send(ctx, token, payload)
+		}()
+	}
+	wg.Wait()
+
+	if err := ctx.Err(); err != nil {
+		return result, err
+	}
+
+	// Every token being rejected as bad or for another topic points at the environment or
+	// topic rather than the tokens, and APNs being unreachable is worth a retry
+	allBadTokens := true
+	var unreachable error
+	for _, err := range errs {
+		var rejected *apnsError
+		switch {
+		case err == nil:
+			allBadTokens = false
+		case errors.As(err, &rejected):
+			if !isBadTokenReason(rejected.reason) {
+				allBadTokens = false
+			}
+		default:
+			allBadTokens = false
+			unreachable = err
+		}
+	}
+
+	for i, err := range errs {
+		if err == nil {
+			result.SuccessCount++
+		} else {
+			result.FailureCount++
+		}
+		result.Responses = append(result.Responses, notificationservice.TokenResponse{
+			Token:   tokens[i],
+			Error:   err,
+			Invalid: isInvalidToken(err, allBadTokens),
+		})
+	}
+
+	slog.Info("Sent APNs notifications",
+		"success_count", result.SuccessCount,
+		"failure_count", result.FailureCount,
+		"total_tokens", len(tokens))
+
+	if unreachable != nil && result.SuccessCount == 0 {
+		return result, unreachable
+	}
+	return result, nil
+}
+
+// send posts the notification to one device, signing a new provider token once if APNs
+// reports the current one expired
+func (s *APNSService) send(ctx context.Context, token string, payload []byte) error {
+	providerToken, err := s.bearer("")
+	if err != nil {
+		return err
+	}
+	err = s.post(ctx, token, payload, providerToken)
+	var rejected *apnsError
+	if errors.As(err, &rejected) && rejected.reason == reasonExpiredProviderToken {
+		if providerToken, err = s.bearer(providerToken); err != nil {
+			return err
+		}
+		err = s.post(ctx, token, payload, providerToken)
+	}
+	return err
+}
+
+func (s *APNSService) post(ctx context.Context, token string, payload []byte, providerToken string) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.baseURL+"/3/device/"+token, bytes.NewReader(payload))
+	if err != nil {
+		return fmt.Errorf("error creating APNs request: %w", err)
+	}
+	req.Header.Set("Authorization", "bearer "+providerToken)
+	req.Header.Set("apns-topic", s.topic)
+	req.Header.Set("apns-push-type", "alert")
+	req.Header.Set("apns-priority", "10")
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("error calling APNs: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusOK {
+		_, _ = io.Copy(io.Discard, resp.Body)
+		return nil
+	}
+
+	var reason struct {
+		Reason string `json:"reason"`
+	}
+	respBody, _ := io.ReadAll(io.LimitReader(resp.Body, maxResponseSize))
+	if err := json.Unmarshal(respBody, &reason); err != nil {
+		reason.Reason = http.StatusText(resp.StatusCode)
+	}
+	return &apnsError{status: resp.StatusCode, reason: reason.Reason}
+}
+
+// bearer returns the signed provider token. It signs a new one when the current one is due,
+// or is the token passed as expired after APNs rejected it; concurrent sends that saw the same
+// rejection then sign only one replacement, as APNs refuses frequent token updates.
+func (s *APNSService) bearer(expired string) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	if s.providerToken != "" && s.providerToken != expired && now.Sub(s.providerIssued) < providerTokenLifetime {
+		return s.providerToken, nil
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodES256, jwt.MapClaims{
+		"iss": s.teamID,
+		"iat": now.Unix(),
+	})
+	token.Header["kid"] = s.keyID
+	signed, err := token.SignedString(s.key)
+	if err != nil {
+		return "", fmt.Errorf("error signing APNs provider token: %w", err)
+	}
+	s.providerToken = signed
+	s.providerIssued = now
+	return signed, nil
+}
+
+// buildPayload builds the APNs payload: the alert under aps, the data as custom keys
+func buildPayload(title string, body string, data map[string]string) ([]byte, error) {
+	payload := make(map[string]any, len(data)+1)
+	for k, v := range data {
+		payload[k] = v
+	}
+	payload["aps"] = map[string]any{
+		"alert": map[string]string{"title": title, "body": body},
+		"sound": "default",
+		"badge": 1,
+	}
+	encoded, err := json.Marshal(payload)
+	if err != nil {
+		return nil, fmt.Errorf("error encoding APNs payload: %w", err)
+	}
+	return encoded, nil
+}
+
+// isInvalidToken reports whether a send error means the token will never work again: the app
+// was uninstalled, or the token is malformed or belongs to another app. Bad tokens are kept
+// when every token of the send was bad, as the configuration is then more likely at fault.
+func isInvalidToken(err error, allBadTokens bool) bool {
+	var rejected *apnsError
+	if !errors.As(err, &rejected) {
+		return false
+	}
+	if rejected.reason == reasonUnregistered {
+		return true
+	}
+	if isBadTokenReason(rejected.reason) {
+		return !allBadTokens
+	}
+	return rejected.status == http.StatusGone
+}
+
+// isBadTokenReason reports whether APNs rejected a token as malformed or issued for another
+// app, which a wrong environment or topic also causes for every token
+func isBadTokenReason(reason string) bool {
+	return reason == reasonBadDeviceToken || reason == reasonDeviceTokenNotForTopic
+}
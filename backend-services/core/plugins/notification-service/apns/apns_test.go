@@ -0,0 +1,104 @@
+package apns
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// newTestService returns a service sending to a fake APNs that rejects each device token with
+// the reason it names ("token-BadDeviceToken"), and accepts tokens naming none
+func newTestService(t *testing.T) *APNSService {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token := strings.TrimPrefix(r.URL.Path, "/3/device/")
+		_, reason, rejected := strings.Cut(token, "-")
+		if !rejected {
+			w.WriteHeader(http.StatusOK)
+			return
+		}
+		status := http.StatusBadRequest
+		if reason == reasonUnregistered {
+			status = http.StatusGone
+		}
+		w.WriteHeader(status)
+		fmt.Fprintf(w, `{"reason":%q}`, reason)
+	}))
+	t.Cleanup(server.Close)
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &APNSService{
+		client:  server.Client(),
+		baseURL: server.URL,
+		topic:   "com.example.superapp",
+		keyID:   "KEY",
+		teamID:  "TEAM",
+		key:     key,
+	}
+}
+
+func sendInvalid(t *testing.T, s *APNSService, tokens []string) []string {
+	t.Helper()
+	result, err := s.SendNotificationToMultiple(context.Background(), tokens, "title", "body", nil)
+	if err != nil {
+		t.Fatalf("SendNotificationToMultiple: %v", err)
+	}
+	if len(result.Responses) != len(tokens) {
+		t.Fatalf("got %d responses for %d tokens", len(result.Responses), len(tokens))
+	}
+	invalid := result.InvalidTokens()
+	slices.Sort(invalid)
+	return invalid
+}
+
+func TestInvalidTokens(t *testing.T) {
+	s := newTestService(t)
+	invalid := sendInvalid(t, s, []string{
+		"a",
+		"b-" + reasonUnregistered,
+		"c-" + reasonBadDeviceToken,
+		"d-" + reasonDeviceTokenNotForTopic,
+		"e-" + "TooManyRequests",
+	})
+	want := []string{"b-" + reasonUnregistered, "c-" + reasonBadDeviceToken, "d-" + reasonDeviceTokenNotForTopic}
+	if !slices.Equal(invalid, want) {
+		t.Errorf("invalid = %v, want %v", invalid, want)
+	}
+}
+
+func TestAllBadTokensKept(t *testing.T) {
+	s := newTestService(t)
+	// Every token rejected as bad or for another topic points at a wrong environment or topic
+	invalid := sendInvalid(t, s, []string{
+		"a-" + reasonBadDeviceToken,
+		"b-" + reasonDeviceTokenNotForTopic,
+		"c-" + reasonDeviceTokenNotForTopic,
+	})
+	if len(invalid) != 0 {
+		t.Errorf("invalid = %v, want none", invalid)
+	}
+}
+
+func TestUnregisteredAlwaysInvalid(t *testing.T) {
+	s := newTestService(t)
+	invalid := sendInvalid(t, s, []string{"a-" + reasonUnregistered})
+	if want := []string{"a-" + reasonUnregistered}; !slices.Equal(invalid, want) {
+		t.Errorf("invalid = %v, want %v", invalid, want)
+	}
+}
+
+func TestPlatforms(t *testing.T) {
+	if got := (&APNSService{}).Platforms(); !slices.Equal(got, []string{platformIOS}) {
+		t.Errorf("Platforms = %v, want only iOS", got)
+	}
+}
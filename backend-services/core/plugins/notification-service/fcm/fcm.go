@@ -1,4 +1,4 @@
-package services
+package fcm
 
 import (
 	"context"
@@ -7,15 +7,38 @@ import (
 	"log/slog"
 	"os"
 
+	notificationservice "go-backend/plugins/notification-service"
+
 	firebase "firebase.google.com/go/v4"
 	"firebase.google.com/go/v4/messaging"
 	"google.golang.org/api/option"
 )
 
+// Most tokens FCM accepts in one multicast message
+const fcmMulticastLimit = 500
+
 type FCMService struct {
 	client *messaging.Client
 }
 
+func init() {
+	notificationservice.Registry.Register("fcm", New)
+}
+
+// New creates the FCM service from the Firebase Admin SDK credentials file in
+// NOTIFICATION_SERVICE_FCM_CREDENTIALS_PATH
+func New(config map[string]any) (notificationservice.NotificationService, error) {
+	credentialsPath, ok := config["NOTIFICATION_SERVICE_FCM_CREDENTIALS_PATH"].(string)
+	if !ok || credentialsPath == "" {
+		return nil, fmt.Errorf("FCMService: NOTIFICATION_SERVICE_FCM_CREDENTIALS_PATH is required")
+	}
+	service, err := NewFCMService(credentialsPath)
+	if err != nil {
+		return nil, err
+	}
+	return service, nil
+}
+
 // NewFCMService initializes a new FCM service with Firebase Admin SDK
 func NewFCMService(credentialsPath string) (*FCMService, error) {
 	ctx := context.Background()
@@ -64,11 +87,32 @@ func (s *FCMService) SendNotificationToMultiple(
 	title string,
 	body string,
 	data map[string]string,
-) (int, int, error) {
+) (*notificationservice.SendResult, error) {
+	result := &notificationservice.SendResult{}
 	if len(tokens) == 0 {
-		return 0, 0, nil
+		return result, nil
+	}
+
+	// FCM accepts at most 500 tokens per multicast, so larger audiences go out in batches
+	for start := 0; start < len(tokens); start += fcmMulticastLimit {
+		end := min(start+fcmMulticastLimit, len(tokens))
+		if err := s.sendMulticast(ctx, tokens[start:end], title, body, data, result); err != nil {
+			return result, err
+		}
 	}
+	return result, nil
+}
 
+// sendMulticast sends one multicast message of at most fcmMulticastLimit tokens and adds the
+// per-token outcomes to the result
+func (s *FCMService) sendMulticast(
+	ctx context.Context,
+	tokens []string,
+	title string,
+	body string,
+	data map[string]string,
+	result *notificationservice.SendResult,
+) error {
 	message := &messaging.MulticastMessage{
 		Tokens: tokens,
 		Notification: &messaging.Notification{
@@ -97,7 +141,7 @@ func (s *FCMService) SendNotificationToMultiple(
 
 	response, err := s.client.SendEachForMulticast(ctx, message)
 	if err != nil {
-		return 0, 0, fmt.Errorf("error sending multicast message: %w", err)
+		return fmt.Errorf("error sending multicast message: %w", err)
 	}
 
 	slog.Info("Successfully sent multicast message",
@@ -105,7 +149,25 @@ func (s *FCMService) SendNotificationToMultiple(
 		"failure_count", response.FailureCount,
 		"total_tokens", len(tokens))
 
-	return response.SuccessCount, response.FailureCount, nil
+	// An invalid argument on every token points at the message rather than the tokens
+	messageRejected := response.SuccessCount == 0
+	for _, r := range response.Responses {
+		if r.Success || !messaging.IsInvalidArgument(r.Error) {
+			messageRejected = false
+			break
+		}
+	}
+
+	result.SuccessCount += response.SuccessCount
+	result.FailureCount += response.FailureCount
+	for i, r := range response.Responses {
+		result.Responses = append(result.Responses, notificationservice.TokenResponse{
+			Token:   tokens[i],
+			Error:   r.Error,
+			Invalid: !r.Success && isInvalidToken(r.Error, messageRejected),
+		})
+	}
+	return nil
 }
 
 // Helper functions
@@ -114,6 +176,15 @@ func intPtr(i int) *int {
 	return &i
 }
 
+// isInvalidToken reports whether a send error means the token will never work again:
+// the app was uninstalled or the token is malformed
+func isInvalidToken(err error, messageRejected bool) bool {
+	if messaging.IsUnregistered(err) {
+		return true
+	}
+	return messaging.IsInvalidArgument(err) && !messageRejected
+}
+
 // getProjectIDFromCredentials reads the project_id from the Firebase credentials JSON file
 func getProjectIDFromCredentials(credentialsPath string) (string, error) {
 	data, err := os.ReadFile(credentialsPath)
@@ -0,0 +1,97 @@
+package fcm
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+
+	firebase "firebase.google.com/go/v4"
+	"google.golang.org/api/option"
+)
+
+// newTestService returns a service sending to a fake FCM that fails each token with the FCM
+// error code it names ("token-UNREGISTERED"), and accepts tokens naming none
+func newTestService(t *testing.T) *FCMService {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Message struct {
+				Token string `json:"token"`
+			} `json:"message"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		_, code, failed := strings.Cut(req.Message.Token, "-")
+		if !failed {
+			fmt.Fprint(w, `{"name":"projects/test/messages/1"}`)
+			return
+		}
+		status := http.StatusBadRequest
+		if code == "UNREGISTERED" {
+			status = http.StatusNotFound
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		fmt.Fprintf(w, `{"error":{"code":%d,"message":"rejected","status":"INVALID_ARGUMENT","details":[`+
+			`{"@type":"type.googleapis.com/google.firebase.fcm.v1.FcmError","errorCode":%q}]}}`, status, code)
+	}))
+	t.Cleanup(server.Close)
+
+	ctx := context.Background()
+	app, err := firebase.NewApp(ctx, &firebase.Config{ProjectID: "test"},
+		option.WithEndpoint(server.URL), option.WithoutAuthentication())
+	if err != nil {
+		t.Fatal(err)
+	}
+	client, err := app.Messaging(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &FCMService{client: client}
+}
+
+func sendInvalid(t *testing.T, s *FCMService, tokens []string) []string {
+	t.Helper()
+	result, err := s.SendNotificationToMultiple(context.Background(), tokens, "title", "body", nil)
+	if err != nil {
+		t.Fatalf("SendNotificationToMultiple: %v", err)
+	}
+	if len(result.Responses) != len(tokens) {
+		t.Fatalf("got %d responses for %d tokens", len(result.Responses), len(tokens))
+	}
+	invalid := result.InvalidTokens()
+	slices.Sort(invalid)
+	return invalid
+}
+
+func TestInvalidTokens(t *testing.T) {
+	s := newTestService(t)
+	invalid := sendInvalid(t, s, []string{"a", "b-UNREGISTERED", "c-INVALID_ARGUMENT", "d-QUOTA_EXCEEDED"})
+	if want := []string{"b-UNREGISTERED", "c-INVALID_ARGUMENT"}; !slices.Equal(invalid, want) {
+		t.Errorf("invalid = %v, want %v", invalid, want)
+	}
+}
+
+func TestRejectedMessageKeepsTokens(t *testing.T) {
+	s := newTestService(t)
+	// An invalid argument on every token points at the message rather than the tokens
+	invalid := sendInvalid(t, s, []string{"a-INVALID_ARGUMENT", "b-INVALID_ARGUMENT"})
+	if len(invalid) != 0 {
+		t.Errorf("invalid = %v, want none", invalid)
+	}
+}
+
+func TestUnregisteredAlwaysInvalid(t *testing.T) {
+	s := newTestService(t)
+	invalid := sendInvalid(t, s, []string{"a-UNREGISTERED"})
+	if want := []string{"a-UNREGISTERED"}; !slices.Equal(invalid, want) {
+		t.Errorf("invalid = %v, want %v", invalid, want)
+	}
+}
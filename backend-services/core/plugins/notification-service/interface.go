@@ -0,0 +1,49 @@
+package notificationservice
+
+import (
+	"context"
+
+	"go-backend/internal/registry"
+)
+
+// NotificationService defines the interface for sending push notifications to device tokens.
+type NotificationService interface {
+	SendNotificationToMultiple(ctx context.Context, tokens []string, title string, body string, data map[string]string) (*SendResult, error)
+}
+
+// PlatformService is implemented by notification services that only reach devices of some
+// platforms, such as APNs for iOS. They are only sent the tokens registered for those platforms.
+type PlatformService interface {
+	Platforms() []string
+}
+
+// SendResult is the outcome of sending a notification to several devices
+type SendResult struct {
+	SuccessCount int
+	FailureCount int
+	// Outcome of each token, in the order they were sent
+	Responses []TokenResponse
+}
+
+// TokenResponse is the outcome of sending a notification to one device token
+type TokenResponse struct {
+	Token string
+	Error error
+	// Invalid is set when the token will never work again and should be deactivated
+	Invalid bool
+}
+
+// InvalidTokens returns the tokens that should no longer be sent to
+func (r *SendResult) InvalidTokens() []string {
+	var tokens []string
+	for _, t := range r.Responses {
+		if t.Invalid {
+			tokens = append(tokens, t.Token)
+		}
+	}
+	return tokens
+}
+
+// Registry is the global registry for NotificationService implementations.
+// Implementations should register themselves in their init() functions.
+var Registry = registry.New[NotificationService]()
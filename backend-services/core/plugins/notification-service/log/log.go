@@ -0,0 +1,107 @@
+// Package log is a notification service for local development and tests. It sends nothing:
+// each notification is logged and kept in memory, so the backend runs without push
+// credentials.
+package log
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	notificationservice "go-backend/plugins/notification-service"
+)
+
+// Notifications kept in memory when NOTIFICATION_SERVICE_LOG_HISTORY is not set
+const defaultHistory = 100
+
+// errInvalidToken is reported for tokens starting with the configured invalid token prefix
+var errInvalidToken = errors.New("token marked invalid by the log notification service")
+
+// Notification is a notification the service was asked to send
+type Notification struct {
+	Tokens []string
+	Title  string
+	Body   string
+	Data   map[string]string
+	SentAt time.Time
+}
+
+type LogService struct {
+	mu            sync.Mutex
+	history       int
+	invalidPrefix string
+	sent          []Notification
+}
+
+func init() {
+	notificationservice.Registry.Register("log", New)
+}
+
+// New creates the log notification service. NOTIFICATION_SERVICE_LOG_HISTORY sets how many
+// notifications are kept in memory. Tokens starting with NOTIFICATION_SERVICE_LOG_INVALID_PREFIX
+// are reported as invalid, to exercise token deactivation.
+func New(config map[string]any) (notificationservice.NotificationService, error) {
+	history := defaultHistory
+	if value, ok := config["NOTIFICATION_SERVICE_LOG_HISTORY"].(string); ok && value != "" {
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 0 {
+			return nil, fmt.Errorf("LogService: NOTIFICATION_SERVICE_LOG_HISTORY must be a non-negative integer")
+		}
+		history = n
+	}
+	invalidPrefix, _ := config["NOTIFICATION_SERVICE_LOG_INVALID_PREFIX"].(string)
+
+	slog.Warn("Using the log notification service; notifications are logged, not delivered")
+	return &LogService{history: history, invalidPrefix: invalidPrefix}, nil
+}
+
+// SendNotificationToMultiple logs the notification and records it as sent to every token
+func (s *LogService) SendNotificationToMultiple(
+	ctx context.Context,
+	tokens []string,
+	title string,
+	body string,
+	data map[string]string,
+) (*notificationservice.SendResult, error) {
+	if err := ctx.Err(); err != nil {
+		return &notificationservice.SendResult{}, err
+	}
+
+	result := &notificationservice.SendResult{}
+	for _, token := range tokens {
+		response := notificationservice.TokenResponse{Token: token}
+		if s.invalidPrefix != "" && strings.HasPrefix(token, s.invalidPrefix) {
+			response.Error = errInvalidToken
+			response.Invalid = true
+			result.FailureCount++
+		} else {
+			result.SuccessCount++
+		}
+		result.Responses = append(result.Responses, response)
+	}
+
+	slog.Info("Notification logged", "title", title, "body", body, "data", data,
+		"success_count", result.SuccessCount, "failure_count", result.FailureCount, "total_tokens", len(tokens))
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.history > 0 {
+		s.sent = append(s.sent, Notification{Tokens: tokens, Title: title, Body: body, Data: data, SentAt: time.Now()})
+		if len(s.sent) > s.history {
+			s.sent = s.sent[len(s.sent)-s.history:]
+		}
+	}
+	return result, nil
+}
+
+// Sent returns the notifications kept in memory, oldest first
+func (s *LogService) Sent() []Notification {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Notification(nil), s.sent...)
+}
@@ -0,0 +1,208 @@
+// Package webhook is a notification service that hands notifications to an HTTP endpoint,
+// for push gateways or providers the backend has no built-in support for.
+//
+// Each batch of tokens is POSTed as JSON:
+//
+//	{"tokens": ["..."], "title": "...", "body": "...", "data": {"key": "value"}}
+//
+// Any 2xx status means the batch was accepted. The endpoint may answer with the tokens that
+// failed, which are otherwise all counted as sent; invalid tokens are deactivated:
+//
+//	{"responses": [{"token": "...", "error": "Unregistered", "invalid": true}]}
+//
+// With NOTIFICATION_SERVICE_WEBHOOK_SECRET set, requests carry an X-Webhook-Timestamp header
+// and an X-Webhook-Signature header of "sha256=" followed by the hex HMAC-SHA256 of
+// "<timestamp>.<body>" under the secret.
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	notificationservice "go-backend/plugins/notification-service"
+)
+
+const (
+	defaultTimeout   = 10 * time.Second
+	defaultBatchSize = 500
+
+	// Most bytes read from a webhook response
+	maxResponseSize = 1 << 20
+
+	headerTimestamp = "X-Webhook-Timestamp"
+	headerSignature = "X-Webhook-Signature"
+)
+
+type WebhookService struct {
+	client    *http.Client
+	url       string
+	secret    []byte
+	batchSize int
+}
+
+type webhookRequest struct {
+	Tokens []string          `json:"tokens"`
+	Title  string            `json:"title"`
+	Body   string            `json:"body"`
+	Data   map[string]string `json:"data,omitempty"`
+}
+
+type webhookResponse struct {
+	Responses []struct {
+		Token   string `json:"token"`
+		Error   string `json:"error"`
+		Invalid bool   `json:"invalid"`
+	} `json:"responses"`
+}
+
+func init() {
+	notificationservice.Registry.Register("webhook", New)
+}
+
+// New creates the webhook notification service. NOTIFICATION_SERVICE_WEBHOOK_URL is required;
+// NOTIFICATION_SERVICE_WEBHOOK_SECRET, NOTIFICATION_SERVICE_WEBHOOK_TIMEOUT_SEC and
+// NOTIFICATION_SERVICE_WEBHOOK_BATCH_SIZE are optional.
+func New(config map[string]any) (notificationservice.NotificationService, error) {
+	url, ok := config["NOTIFICATION_SERVICE_WEBHOOK_URL"].(string)
+	if !ok || url == "" {
+		return nil, fmt.Errorf("WebhookService: NOTIFICATION_SERVICE_WEBHOOK_URL is required")
+	}
+	secret, _ := config["NOTIFICATION_SERVICE_WEBHOOK_SECRET"].(string)
+
+	timeout := defaultTimeout
+	if value, ok := config["NOTIFICATION_SERVICE_WEBHOOK_TIMEOUT_SEC"].(string); ok && value != "" {
+		seconds, err := strconv.Atoi(value)
+		if err != nil || seconds <= 0 {
+			return nil, fmt.Errorf("WebhookService: NOTIFICATION_SERVICE_WEBHOOK_TIMEOUT_SEC must be a positive integer")
+		}
+		timeout = time.Duration(seconds) * time.Second
+	}
+
+	batchSize := defaultBatchSize
+	if value, ok := config["NOTIFICATION_SERVICE_WEBHOOK_BATCH_SIZE"].(string); ok && value != "" {
+		n, err := strconv.Atoi(value)
+		if err != nil || n <= 0 {
+			return nil, fmt.Errorf("WebhookService: NOTIFICATION_SERVICE_WEBHOOK_BATCH_SIZE must be a positive integer")
+		}
+		batchSize = n
+	}
+
+	slog.Info("Initializing WebhookService", "url", url, "signed", secret != "", "batch_size", batchSize)
+	return &WebhookService{
+		client:    &http.Client{Timeout: timeout},
+		url:       url,
+		secret:    []byte(secret),
+		batchSize: batchSize,
+	}, nil
+}
+
+// SendNotificationToMultiple posts the notification to the webhook in batches of tokens
+func (s *WebhookService) SendNotificationToMultiple(
+	ctx context.Context,
+	tokens []string,
+	title string,
+	body string,
+	data map[string]string,
+) (*notificationservice.SendResult, error) {
+	result := &notificationservice.SendResult{}
+	for start := 0; start < len(tokens); start += s.batchSize {
+		end := min(start+s.batchSize, len(tokens))
+		if err := s.post(ctx, tokens[start:end], title, body, data, result); err != nil {
+			return result, err
+		}
+	}
+	return result, nil
+}
+
+// post sends one batch and adds the per-token outcomes to the result
+func (s *WebhookService) post(
+	ctx context.Context,
+	tokens []string,
+	title string,
+	body string,
+	data map[string]string,
+	result *notificationservice.SendResult,
+) error {
+	payload, err := json.Marshal(webhookRequest{Tokens: tokens, Title: title, Body: body, Data: data})
+	if err != nil {
+		return fmt.Errorf("error encoding webhook request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.url, bytes.NewReader(payload))
+	if err != nil {
+		return fmt.Errorf("error creating webhook request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if len(s.secret) > 0 {
+		timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+		mac := hmac.New(sha256.New, s.secret)
+		mac.Write([]byte(timestamp + "."))
+		mac.Write(payload)
+		req.Header.Set(headerTimestamp, timestamp)
+		req.Header.Set(headerSignature, "sha256="+hex.EncodeToString(mac.Sum(nil)))
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("error calling notification webhook: %w", err)
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(io.LimitReader(resp.Body, maxResponseSize))
+	if err != nil {
+		return fmt.Errorf("error reading webhook response: %w", err)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("notification webhook answered %d: %s", resp.StatusCode, bytes.TrimSpace(respBody))
+	}
+
+	// Tokens the webhook does not report on were accepted
+	var decoded webhookResponse
+	if len(bytes.TrimSpace(respBody)) > 0 {
+		if err := json.Unmarshal(respBody, &decoded); err != nil {
+			slog.Warn("Ignoring unreadable notification webhook response", "error", err)
+		}
+	}
+	failed := make(map[string]notificationservice.TokenResponse, len(decoded.Responses))
+	for _, r := range decoded.Responses {
+		if r.Error == "" && !r.Invalid {
+			continue
+		}
+		response := notificationservice.TokenResponse{Token: r.Token, Invalid: r.Invalid}
+		if r.Error != "" {
+			response.Error = errors.New(r.Error)
+		} else {
+			response.Error = errors.New("invalid token")
+		}
+		failed[r.Token] = response
+	}
+
+	failureCount := 0
+	for _, token := range tokens {
+		if response, ok := failed[token]; ok {
+			failureCount++
+			result.Responses = append(result.Responses, response)
+			continue
+		}
+		result.Responses = append(result.Responses, notificationservice.TokenResponse{Token: token})
+	}
+	result.SuccessCount += len(tokens) - failureCount
+	result.FailureCount += failureCount
+
+	slog.Info("Successfully posted notification webhook",
+		"success_count", len(tokens)-failureCount,
+		"failure_count", failureCount,
+		"total_tokens", len(tokens))
+	return nil
+}
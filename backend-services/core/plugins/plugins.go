@@ -9,4 +9,10 @@ import (
 	// Default Implementations
 	_ "go-backend/plugins/file-service/default-db"
 	_ "go-backend/plugins/user-service/default-db"
+
+	// Notification Services
+	_ "go-backend/plugins/notification-service/apns"
+	_ "go-backend/plugins/notification-service/fcm"
+	_ "go-backend/plugins/notification-service/log"
+	_ "go-backend/plugins/notification-service/webhook"
 )